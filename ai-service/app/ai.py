@@ -12,8 +12,25 @@ from .latex import clean_latex_body
 logger = logging.getLogger("ai_service.ai")
 
 
+def sampling_params(
+    temperature: Optional[float], max_tokens: Optional[int]
+) -> Dict:
+    """Return the sampling arguments for a chat call, leaving out the unset
+    ones so the model's defaults apply."""
+    params = {}
+    if temperature is not None:
+        params["temperature"] = temperature
+    if max_tokens:
+        params["max_tokens"] = max_tokens
+    return params
+
+
 def generate_search_queries(
-    api_key: str, model: str, topic: str
+    api_key: str,
+    model: str,
+    topic: str,
+    temperature: Optional[float] = None,
+    max_tokens: Optional[int] = None,
 ) -> List[str]:
     """Ask the LLM to produce short, keyword-style search queries."""
     client = Mistral(api_key=api_key)
@@ -36,6 +53,7 @@ def generate_search_queries(
         resp = client.chat.complete(
             model=model,
             messages=[{"role": "user", "content": prompt}],
+            **sampling_params(temperature, max_tokens),
         )
         if resp and resp.choices:
             text = resp.choices[0].message.content.strip()
@@ -63,15 +81,56 @@ def generate_search_queries(
     ]
 
 
+# Names of the report languages the backend accepts, by ISO 639-1 code.
+LANGUAGE_NAMES = {
+    "en": "English",
+    "es": "Spanish",
+    "fr": "French",
+    "de": "German",
+    "it": "Italian",
+    "pt": "Portuguese",
+    "nl": "Dutch",
+    "pl": "Polish",
+    "sv": "Swedish",
+    "tr": "Turkish",
+    "ru": "Russian",
+    "zh": "Chinese",
+    "ja": "Japanese",
+    "ko": "Korean",
+    "ar": "Arabic",
+    "hi": "Hindi",
+}
+
+
+# How each citation style the backend accepts formats a \bibitem entry.
+CITATION_STYLES = {
+    "apa": "APA: Author (Year). \\textit{Title}. Site. \\url{...}",
+    "mla": "MLA: Author. ``Title.'' \\textit{Site}, Year, \\url{...}.",
+    "ieee": "IEEE: Author, ``Title,'' \\textit{Site}, Year. [Online]. Available: \\url{...}",
+    "chicago": "Chicago: Author. ``Title.'' Site, Year. Accessed online. \\url{...}.",
+}
+
+
 def generate_latex_report(
     api_key: str,
     model: str,
     topic: str,
     context: str,
     sources: List[Dict],
+    target_words: int = 0,
+    target_sections: int = 0,
+    language: str = "en",
+    citation_style: str = "ieee",
+    temperature: Optional[float] = None,
+    max_tokens: Optional[int] = None,
 ) -> Optional[str]:
     """Generate a LaTeX-formatted research report body (no preamble)."""
     client = Mistral(api_key=api_key)
+    language_name = LANGUAGE_NAMES.get(language.lower(), "English")
+    citation_format = CITATION_STYLES.get(citation_style.lower(), CITATION_STYLES["ieee"])
+    length = f"about {target_words} words" if target_words > 0 else "at least 1500 words"
+    if target_sections > 0:
+        length += f", in {target_sections} \\section{{}} headings"
 
     bib_lines = ""
     for i, s in enumerate(sources, 1):
@@ -165,7 +224,8 @@ REFERENCES:
 OTHER RULES:
 • Do NOT use --- or em-dashes as section separators.
 • Do NOT use \newpage except for major section breaks.
-• Write at least 1500 words. Be thorough, data-driven, and analytical.
+• Write the length asked for in the user message. Be thorough,
+  data-driven, and analytical.
 • Use an academic but accessible tone.
 • Include concrete examples, statistics, and comparisons where possible.
 
@@ -182,6 +242,13 @@ SELF-CHECK before submitting:
         f"  {topic}\n\n"
         f"WEB SEARCH CONTEXT:\n{context}\n\n"
         f"SOURCES FOR BIBLIOGRAPHY:\n{bib_lines}\n\n"
+        f"LENGTH: Write {length}.\n\n"
+        "LANGUAGE: Write the entire report, including headings and table\n"
+        f"captions, in {language_name}. Keep LaTeX commands, source titles\n"
+        "and URLs unchanged.\n\n"
+        f"CITATION STYLE: Format every \\bibitem in {citation_format}\n"
+        "Leave out the author or year when a source doesn't give them, and\n"
+        "keep the \\bibitem{sourceN} keys so the \\cite{} references match.\n\n"
         "Begin writing the LaTeX body now. Remember: NO preamble, "
         "NO markdown, proper tables with tabular environment, "
         "escape all special characters."
@@ -194,6 +261,7 @@ SELF-CHECK before submitting:
                 {"role": "system", "content": system_prompt},
                 {"role": "user", "content": user_prompt},
             ],
+            **sampling_params(temperature, max_tokens),
         )
         if resp and resp.choices:
             content = resp.choices[0].message.content.strip()
@@ -204,3 +272,48 @@ SELF-CHECK before submitting:
     except Exception as exc:
         logger.error("Report generation error: %s", exc)
     return None
+
+
+# How much of the report is sent when asking for gaps; the start of a long
+# report is enough to see what it covers.
+GAP_REPORT_CHARS = 20000
+
+
+def generate_gap_queries(
+    api_key: str, model: str, topic: str, report: str
+) -> Optional[List[str]]:
+    """Ask the LLM which angles a finished report misses and return short
+    search queries that would fill them."""
+    client = Mistral(api_key=api_key)
+    prompt = (
+        "Below is a research report written in LaTeX on the topic:\n\n"
+        f"  {topic}\n\n"
+        "Identify the most important angles the report does NOT cover well:\n"
+        "missing perspectives, outdated or unsupported claims, data it lacks.\n"
+        "For each gap, write one SHORT search-engine query (max 8 words) that\n"
+        "would find sources to fill it. Suggest between 3 and 5 queries.\n\n"
+        "IMPORTANT: Keep queries SHORT like real Google searches — just keywords,\n"
+        "no full sentences, and do not repeat what the report already covers.\n\n"
+        f"REPORT:\n{report[:GAP_REPORT_CHARS]}\n\n"
+        'Return ONLY a JSON array of strings, e.g.:\n'
+        '["solar panel recycling costs", "perovskite cell durability 2024"]'
+    )
+    try:
+        resp = client.chat.complete(
+            model=model,
+            messages=[{"role": "user", "content": prompt}],
+        )
+        if resp and resp.choices:
+            text = resp.choices[0].message.content.strip()
+            match = re.search(r"\[.*?\]", text, re.DOTALL)
+            if match:
+                queries = json.loads(match.group())
+                cleaned = []
+                for q in queries[:5]:
+                    words = str(q).split()
+                    if words:
+                        cleaned.append(" ".join(words[:10]))
+                return cleaned
+    except Exception as exc:
+        logger.error("Gap-query generation error: %s", exc)
+    return None
@@ -8,8 +8,9 @@ from .schemas import (
     GenerateQueriesRequest, GenerateQueriesResponse,
     SearchRequest, SearchResponse, Source,
     GenerateReportRequest, GenerateReportResponse,
+    GapQueriesRequest, GapQueriesResponse,
 )
-from .ai import generate_search_queries, generate_latex_report
+from .ai import generate_search_queries, generate_latex_report, generate_gap_queries
 from .search import multi_search
 
 logging.basicConfig(
@@ -31,6 +32,8 @@ async def api_generate_queries(req: GenerateQueriesRequest):
         api_key=req.api_key,
         model=req.model,
         topic=req.topic,
+        temperature=req.temperature,
+        max_tokens=req.max_tokens,
     )
     return GenerateQueriesResponse(queries=queries)
 
@@ -43,6 +46,9 @@ async def api_search(req: SearchRequest):
             title=r.get("title", "N/A"),
             body=r.get("body", "N/A"),
             href=r.get("href", ""),
+            author=r.get("author", ""),
+            published_date=r.get("date", ""),
+            site_name=r.get("source", ""),
         )
         for r in raw
     ]
@@ -58,6 +64,12 @@ async def api_generate_report(req: GenerateReportRequest):
         topic=req.topic,
         context=req.context,
         sources=sources_dicts,
+        target_words=req.target_words,
+        target_sections=req.target_sections,
+        language=req.language,
+        citation_style=req.citation_style,
+        temperature=req.temperature,
+        max_tokens=req.max_tokens,
     )
     if latex_body is None:
         return JSONResponse(
@@ -65,3 +77,19 @@ async def api_generate_report(req: GenerateReportRequest):
             content={"detail": "Failed to generate report"},
         )
     return GenerateReportResponse(latex_body=latex_body)
+
+
+@app.post("/api/gap-queries", response_model=GapQueriesResponse)
+async def api_gap_queries(req: GapQueriesRequest):
+    queries = generate_gap_queries(
+        api_key=req.api_key,
+        model=req.model,
+        topic=req.topic,
+        report=req.report,
+    )
+    if queries is None:
+        return JSONResponse(
+            status_code=500,
+            content={"detail": "Failed to generate follow-up queries"},
+        )
+    return GapQueriesResponse(queries=queries)
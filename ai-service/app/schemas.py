@@ -1,7 +1,7 @@
 """Pydantic request/response models for the AI service."""
 
 from pydantic import BaseModel
-from typing import List
+from typing import List, Optional
 
 
 # ---------------------------------------------------------------------------
@@ -12,6 +12,10 @@ class Source(BaseModel):
     title: str = "N/A"
     body: str = "N/A"
     href: str = ""
+    # Publication metadata, when the search backend provides it.
+    author: str = ""
+    published_date: str = ""
+    site_name: str = ""
 
 
 # ---------------------------------------------------------------------------
@@ -22,6 +26,9 @@ class GenerateQueriesRequest(BaseModel):
     topic: str
     model: str = "mistral-medium-latest"
     api_key: str
+    # Sampling parameters; None keeps the model's defaults.
+    temperature: Optional[float] = None
+    max_tokens: Optional[int] = None
 
 
 class GenerateQueriesResponse(BaseModel):
@@ -51,7 +58,31 @@ class GenerateReportRequest(BaseModel):
     api_key: str
     context: str
     sources: List[Source]
+    # ISO 639-1 code of the language to write the report in.
+    language: str = "en"
+    # Length targets; 0 leaves the length to the prompt's default.
+    target_words: int = 0
+    target_sections: int = 0
+    # Bibliography style: apa, mla, ieee or chicago.
+    citation_style: str = "ieee"
+    temperature: Optional[float] = None
+    max_tokens: Optional[int] = None
 
 
 class GenerateReportResponse(BaseModel):
     latex_body: str
+
+
+# ---------------------------------------------------------------------------
+# /api/gap-queries
+# ---------------------------------------------------------------------------
+
+class GapQueriesRequest(BaseModel):
+    topic: str
+    model: str = "mistral-medium-latest"
+    api_key: str
+    report: str
+
+
+class GapQueriesResponse(BaseModel):
+    queries: List[str]
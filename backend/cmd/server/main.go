@@ -16,14 +16,22 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/ayush/research-ai-agent/backend/internal/account"
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
 	"github.com/ayush/research-ai-agent/backend/internal/auth"
 	"github.com/ayush/research-ai-agent/backend/internal/config"
+	"github.com/ayush/research-ai-agent/backend/internal/health"
+	"github.com/ayush/research-ai-agent/backend/internal/mail"
 	"github.com/ayush/research-ai-agent/backend/internal/middleware"
 	"github.com/ayush/research-ai-agent/backend/internal/research"
+	"github.com/ayush/research-ai-agent/backend/internal/secrets"
+	"github.com/ayush/research-ai-agent/backend/internal/shutdown"
 	"github.com/ayush/research-ai-agent/backend/internal/store"
+	"github.com/ayush/research-ai-agent/backend/internal/version"
 )
 
 func main() {
+	startedAt := time.Now()
 	cfg := config.Load()
 	ctx := context.Background()
 
@@ -46,6 +54,13 @@ func main() {
 	defer mongoClient.Disconnect(ctx)
 	mongoDB := mongoClient.Database(cfg.MongoDB)
 	mongoStore := store.NewMongoStore(mongoDB)
+	if err := mongoStore.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("mongo indexes: %v", err)
+	}
+	subscriptions := store.NewSubscriptionStore(mongoDB)
+	if err := subscriptions.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("mongo indexes: %v", err)
+	}
 
 	// ── Redis ────────────────────────────────────────────────
 	rdb, err := store.NewRedisClient(ctx, cfg.RedisAddr, cfg.RedisPassword)
@@ -53,36 +68,188 @@ func main() {
 		log.Fatalf("redis connect: %v", err)
 	}
 	defer rdb.Close()
-	sessions := auth.NewSessionStore(rdb)
+	if (cfg.SessionMode == auth.SessionModeJWT || cfg.SessionStatelessFallback) && cfg.SessionSecret == "" {
+		log.Fatal("JWT sessions require SESSION_SECRET")
+	}
+	sameSite, err := auth.ParseSameSite(cfg.CookieSameSite)
+	if err != nil {
+		log.Fatalf("cookie config: %v", err)
+	}
+	cookies := auth.CookieOptions{Secure: cfg.CookieSecure, SameSite: sameSite}
+	if err := cookies.Validate(); err != nil {
+		log.Fatalf("cookie config: %v", err)
+	}
+	sessions := auth.NewSessionStore(rdb, auth.SessionOptions{
+		Mode:              cfg.SessionMode,
+		Secret:            cfg.SessionSecret,
+		StatelessFallback: cfg.SessionStatelessFallback,
+		TTL:               cfg.SessionTTL,
+		TouchInterval:     cfg.SessionTouchInterval,
+		Cookie:            cookies,
+	})
 
-	// ── MinIO ────────────────────────────────────────────────
-	minioStore, err := store.NewMinioStore(
-		ctx, cfg.MinioEndpoint, cfg.MinioAccessKey,
-		cfg.MinioSecretKey, cfg.MinioBucket, cfg.MinioUseSSL,
-	)
+	// ── File storage ─────────────────────────────────────────
+	fileStore, err := store.NewFileStore(ctx, store.FileStoreConfig{
+		Backend:        cfg.StorageBackend,
+		LocalDir:       cfg.LocalStorageDir,
+		MinioEndpoint:  cfg.MinioEndpoint,
+		MinioAccessKey: cfg.MinioAccessKey,
+		MinioSecretKey: cfg.MinioSecretKey,
+		MinioBucket:    cfg.MinioBucket,
+		MinioUseSSL:    cfg.MinioUseSSL,
+		S3Endpoint:     cfg.S3Endpoint,
+		S3Region:       cfg.S3Region,
+		S3AccessKey:    cfg.S3AccessKey,
+		S3SecretKey:    cfg.S3SecretKey,
+		S3Bucket:       cfg.S3Bucket,
+	})
 	if err != nil {
-		log.Fatalf("minio connect: %v", err)
+		log.Fatalf("file storage: %v", err)
 	}
+	log.Printf("Using %T file storage", fileStore)
+
+	// Background jobs stop when the server shuts down.
+	bgCtx, stopBackground := context.WithCancel(ctx)
+	defer stopBackground()
+	if sweeper, ok := fileStore.(store.PrefixSweeper); ok {
+		go store.RunSweeper(bgCtx, sweeper, cfg.GuestPrefix, cfg.GuestRetention, cfg.GuestSweepInterval)
+	}
+
+	retry := research.RetryPolicy{MaxAttempts: cfg.HTTPRetryAttempts, BaseDelay: cfg.HTTPRetryBackoff}
 
 	// ── AI client ────────────────────────────────────────────
-	aiClient := research.NewAIClient(cfg.AIServiceURL)
+	aiClient := research.NewAIClient(cfg.AIServiceURL, retry)
 
 	// ── LaTeX client ─────────────────────────────────────────
-	latexClient := research.NewLaTeXClient(cfg.LaTeXServiceURL)
+	latexClient := research.NewLaTeXClient(cfg.LaTeXServiceURL, retry)
+
+	// ── Stored API keys ──────────────────────────────────────
+	var keyVault *account.KeyVault
+	if cfg.SessionSecret != "" {
+		box, err := secrets.NewBox(cfg.SessionSecret, "api-keys")
+		if err != nil {
+			log.Fatalf("api key encryption: %v", err)
+		}
+		keyVault = account.NewKeyVault(pgStore, box)
+	} else {
+		log.Println("SESSION_SECRET not set, stored API keys are disabled")
+	}
+
+	var mailer interface {
+		auth.Mailer
+		research.EmailSender
+	} = mail.LogMailer{}
+	if cfg.SMTPAddr != "" {
+		mailer = mail.NewSMTPMailer(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	} else {
+		log.Println("SMTP_ADDR not set, account emails will be logged")
+	}
 
 	// ── Handlers ─────────────────────────────────────────────
-	authHandler := auth.NewHandler(pgStore, sessions)
-	researchHandler := research.NewHandler(mongoStore, minioStore, aiClient, latexClient)
+	var guestPrefix string
+	if cfg.GuestAccess {
+		guestPrefix = cfg.GuestPrefix
+	}
+	authHandler := auth.NewHandler(pgStore, sessions, auth.Options{
+		PasswordPolicy: auth.PasswordPolicy{
+			MinLength:        cfg.PasswordMinLength,
+			RequireMixedCase: cfg.PasswordRequireMixedCase,
+			RequireDigit:     cfg.PasswordRequireDigit,
+			RequireSymbol:    cfg.PasswordRequireSymbol,
+			RejectCommon:     cfg.PasswordRejectCommon,
+		},
+		Resets:        auth.NewResetStore(rdb),
+		Mailer:        mailer,
+		ResetURL:      cfg.PasswordResetURL,
+		Verifications: auth.NewVerifyStore(rdb),
+		VerifyURL:     cfg.EmailVerifyURL,
+		GuestPrefix:   guestPrefix,
+	})
+	var pricing research.Pricing
+	if len(cfg.ModelPricing) > 0 {
+		if pricing, err = research.ParsePricing(cfg.ModelPricing); err != nil {
+			log.Fatalf("model pricing: %v", err)
+		}
+	}
+	var sourceLimits map[string]int
+	if len(cfg.SourceLimits) > 0 {
+		if sourceLimits, err = research.ParseSourceLimits(cfg.SourceLimits); err != nil {
+			log.Fatalf("source limits: %v", err)
+		}
+	}
+	var callbacks *research.Callbacks
+	if cfg.CallbackSecret != "" {
+		callbacks = research.NewCallbacks(cfg.CallbackSecret, retry)
+	}
+	researchHandler := research.NewHandler(mongoStore, fileStore, aiClient, latexClient, research.Options{
+		UploadConcurrency: cfg.UploadConcurrency,
+		SearchConcurrency: cfg.SearchConcurrency,
+		MaxSources:        cfg.MaxSources,
+		ModelHealth: research.NewModelHealth(
+			rdb, cfg.FallbackModel, cfg.ModelTimeoutThreshold, cfg.ModelTimeoutWindow,
+		),
+		Progress:        research.NewProgress(rdb),
+		CredibleDomains: cfg.CredibleDomains,
+		AllowedDomains:  cfg.AllowedDomains,
+		BlockedDomains:  cfg.BlockedDomains,
+		DedupMode:       cfg.DedupMode,
+		DedupThreshold:  cfg.DedupThreshold,
+		Debug:           research.NewDebugStore(rdb, cfg.DebugCaptureRetention, cfg.DebugCaptureMaxWindow),
+		Suggestions:     research.NewSuggestionStore(rdb, time.Hour),
+		Shares:          research.NewShareStore(rdb, cfg.ShareTTL),
+		APIKeys:         keyVault,
+		GuestPrefix:     cfg.GuestPrefix,
+		Locks:           research.NewDocLocker(rdb, cfg.DocLockTTL),
+		Idempotency:     research.NewIdempotencyStore(rdb, cfg.IdempotencyTTL),
+		Subscriptions:   subscriptions,
+		Email:           mailer,
+		Users:           pgStore,
+		PublicURL:       cfg.PublicURL,
+		PresignExpiry:   cfg.PresignExpiry,
+		Callbacks:       callbacks,
+		Cache:           research.NewQueryCache(rdb, cfg.QueryCacheTTL, cfg.SearchCacheTTL),
+		MaxLatexBytes:   cfg.MaxLatexBytes,
+		AILimit:         research.NewAILimiter(cfg.AIConcurrency, cfg.AIQueueTimeout),
+		AllowedModels:   cfg.AllowedModels,
+		Pricing:         pricing,
+		Quota:           research.NewQuotaStore(rdb, cfg.MonthlyQuota),
+		SourceLimits:    sourceLimits,
+		LinkCheck:       research.NewLinkChecker(cfg.LinkCheckTimeout, cfg.LinkCheckConcurrency),
+		ListTimeout:     cfg.ListTimeout,
+		ListCache:       research.NewListCache(rdb, cfg.ListCacheTTL),
+		StepTimeouts: research.StepTimeouts{
+			Queries: cfg.QueriesTimeout,
+			Search:  cfg.SearchTimeout,
+			Report:  cfg.ReportTimeout,
+			Compile: cfg.CompileTimeout,
+		},
+	})
+
+	go researchHandler.RunSubscriptions(bgCtx, cfg.SubscriptionPollInterval)
+	go researchHandler.RunTrashSweeper(bgCtx, cfg.TrashRetention, cfg.TrashSweepInterval)
+	// Guests' documents go with their files; the file sweep above also
+	// catches files left behind under the guest prefix.
+	go researchHandler.RunGuestSweeper(bgCtx, cfg.GuestRetention, cfg.GuestSweepInterval)
+	userDeleter := account.NewUserDeleter(pgStore, researchHandler, sessions)
+	accountHandler := account.NewHandler(pgStore, mongoStore, keyVault, pgStore, userDeleter, sessions.Cookies())
 
 	// ── Router ───────────────────────────────────────────────
 	r := chi.NewRouter()
 	r.Use(chimw.Logger)
 	r.Use(chimw.Recoverer)
 	r.Use(chimw.RealIP)
+	r.Use(middleware.Gzip(cfg.CompressMinBytes, cfg.CompressTypes))
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, http.StatusMethodNotAllowed, apierror.InvalidRequest, "method not allowed")
+	})
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173", "http://localhost:3000"},
+		AllowedOrigins:   cfg.CORSOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", research.IdempotencyHeader, auth.CSRFHeader},
+		ExposedHeaders:   []string{middleware.APIVersionHeader, "Deprecation", "Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -91,24 +258,143 @@ func main() {
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`{"status":"ok"}`))
 	})
+	readyChecks := []health.Check{
+		{Name: "postgres", Ping: pgPool.Ping},
+		{Name: "mongo", Ping: func(ctx context.Context) error { return mongoClient.Ping(ctx, nil) }},
+		{Name: "redis", Ping: func(ctx context.Context) error { return rdb.Ping(ctx).Err() }},
+	}
+	readyChecks = append(readyChecks, health.Check{Name: "storage", Ping: fileStore.Ping})
+	r.Get("/health/ready", health.Ready(2*time.Second, readyChecks...))
 
-	// Auth routes (public)
-	r.Route("/api/auth", func(r chi.Router) {
-		r.Post("/register", authHandler.Register)
-		r.Post("/login", authHandler.Login)
-		r.Post("/logout", authHandler.Logout)
-		r.With(middleware.RequireAuth(sessions)).Get("/me", authHandler.Me)
-	})
+	requireAuth := middleware.RequireAuth(sessions, pgStore)
+	authBodyLimit := middleware.MaxBody(cfg.MaxAuthBodyBytes)
+	researchBodyLimit := middleware.MaxBody(cfg.MaxBodyBytes)
+	csrf := middleware.CSRF(sessions.Cookies())
+
+	// routesV1 registers version 1 of the API on r. Breaking changes go
+	// into a new version mounted next to it, not in here.
+	routesV1 := func(r chi.Router) {
+		// Build info, for support (public)
+		r.Get("/version", version.Handler)
+
+		// Models requests may use, for the frontend's model picker (public)
+		r.Get("/models", researchHandler.Models)
+
+		// Auth routes (public)
+		r.Route("/auth", func(r chi.Router) {
+			r.Use(authBodyLimit)
+			r.Use(middleware.Timeout(cfg.AuthRequestTimeout))
+			r.Post("/register", authHandler.Register)
+			r.Post("/login", authHandler.Login)
+			r.Post("/guest", authHandler.Guest)
+			r.Post("/logout", authHandler.Logout)
+			r.Post("/forgot-password", authHandler.ForgotPassword)
+			r.Post("/reset-password", authHandler.ResetPassword)
+			r.Post("/verify-email", authHandler.VerifyEmail)
+			r.With(requireAuth).Get("/me", authHandler.Me)
+		})
+
+		// Research routes (protected)
+		r.Route("/research", func(r chi.Router) {
+			r.Use(requireAuth)
+			r.Use(csrf)
+			r.Use(researchBodyLimit)
+			// Previews, regenerates and source refreshes spend the same
+			// AI and search calls as creates, so they share the create
+			// rate limit.
+			limitCreate := r.With(middleware.Timeout(cfg.CreateRequestTimeout), middleware.RateLimit(rdb, "research_create", cfg.ResearchRateLimit, cfg.ResearchRateWindow))
+			limitCreate.Post("/", researchHandler.Create)
+			limitCreate.Post("/preview", researchHandler.Preview)
+			r.Get("/", researchHandler.List)
+			r.Get("/search", researchHandler.Search)
+			r.Get("/trash", researchHandler.Trash)
+			r.Post("/estimate", researchHandler.EstimateUsage)
+			r.Post("/bulk-tag", researchHandler.BulkTag)
+			r.Get("/tags", researchHandler.Tags)
+			r.Get("/stats", researchHandler.UserStats)
+			r.Post("/subscriptions", researchHandler.CreateSubscription)
+			r.Get("/subscriptions", researchHandler.ListSubscriptions)
+			r.Delete("/subscriptions/{id}", researchHandler.DeleteSubscription)
+			r.Get("/{id}", researchHandler.Get)
+			r.Get("/{id}/status", researchHandler.Status)
+			r.Get("/{id}/events", researchHandler.Events)
+			r.Post("/{id}/gap-queries", researchHandler.GapQueries)
+			limitCreate.Post("/{id}/regenerate", researchHandler.Regenerate)
+			r.Post("/{id}/cancel", researchHandler.Cancel)
+			r.Delete("/{id}", researchHandler.Delete)
+			r.Post("/{id}/restore", researchHandler.Restore)
+			r.Get("/{id}/pdf", researchHandler.DownloadPDF)
+			r.Get("/{id}/pdf/url", researchHandler.PresignedPDFURL)
+			r.Get("/{id}/tex", researchHandler.DownloadTex)
+			r.Get("/{id}/bundle.zip", researchHandler.Bundle)
+			// Every edit recompiles the PDF in the LaTeX service.
+			r.With(middleware.RateLimit(rdb, "latex_compile", cfg.CompileRateLimit, cfg.CompileRateWindow)).Put("/{id}/latex", researchHandler.UpdateLatex)
+			r.Get("/{id}/versions", researchHandler.ListVersions)
+			r.Post("/{id}/versions/{v}/restore", researchHandler.RestoreVersion)
+			r.Get("/{id}/sources", researchHandler.Sources)
+			r.Post("/{id}/sources", researchHandler.AddSource)
+			r.Delete("/{id}/sources/{index}", researchHandler.RemoveSource)
+			limitCreate.Post("/{id}/refresh-sources", researchHandler.RefreshSources)
+			r.Post("/{id}/check-links", researchHandler.CheckLinks)
+			r.Get("/{id}/sources.csv", researchHandler.SourcesCSV)
+			r.Get("/{id}/sources.bib", researchHandler.SourcesBibTeX)
+			r.Put("/{id}/tags", researchHandler.SetTags)
+			r.Post("/{id}/share", researchHandler.CreateShare)
+			r.Delete("/{id}/share", researchHandler.RevokeShare)
+		})
 
-	// Research routes (protected)
-	r.Route("/api/research", func(r chi.Router) {
-		r.Use(middleware.RequireAuth(sessions))
-		r.Post("/", researchHandler.Create)
-		r.Get("/", researchHandler.List)
-		r.Get("/{id}", researchHandler.Get)
-		r.Delete("/{id}", researchHandler.Delete)
-		r.Get("/{id}/pdf", researchHandler.DownloadPDF)
-		r.Get("/{id}/tex", researchHandler.DownloadTex)
+		// Shared documents (public, token-gated)
+		r.Route("/share", func(r chi.Router) {
+			r.Get("/{token}", researchHandler.GetShared)
+			r.Get("/{token}/pdf", researchHandler.SharedPDF)
+		})
+
+		// User self-service routes (protected)
+		r.Route("/user", func(r chi.Router) {
+			r.Use(requireAuth)
+			r.Use(csrf)
+			r.Use(authBodyLimit)
+			r.Put("/debug-consent", researchHandler.SetDebugConsent)
+			r.Delete("/", accountHandler.DeleteAccount)
+			r.Put("/profile", authHandler.UpdateProfile)
+			r.Post("/change-password", authHandler.ChangePassword)
+			r.Get("/sessions", authHandler.ListSessions)
+			r.Delete("/sessions/{sid}", authHandler.DeleteSession)
+			r.Post("/sessions/revoke-all", authHandler.RevokeAllSessions)
+			r.Get("/data.json", accountHandler.ExportJSON)
+			r.Get("/domains", researchHandler.Domains)
+			r.Get("/usage", researchHandler.Usage)
+			r.Post("/tokens", accountHandler.CreateToken)
+			r.Get("/tokens", accountHandler.ListTokens)
+			r.Delete("/tokens/{id}", accountHandler.DeleteToken)
+			if keyVault != nil {
+				r.Put("/api-key", accountHandler.PutAPIKey)
+				r.Delete("/api-key", accountHandler.DeleteAPIKey)
+			}
+		})
+
+		// Admin routes (protected, admin only)
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(requireAuth)
+			r.Use(csrf)
+			r.Use(middleware.RequireAdmin(cfg.AdminUserIDs))
+			r.Post("/debug/users/{userID}", researchHandler.EnableDebug)
+			r.Delete("/debug/users/{userID}", researchHandler.DisableDebug)
+			r.Get("/debug/runs/{id}", researchHandler.GetDebugRun)
+			r.Get("/cache", researchHandler.CacheStats)
+		})
+	}
+
+	// /api/v1 is current. The unversioned /api routes are aliases kept for
+	// clients from before versioning and will be removed.
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(middleware.APIVersion("v1"))
+		routesV1(r)
+	})
+	r.Route("/api", func(r chi.Router) {
+		r.Use(middleware.APIVersion("v1"))
+		r.Use(middleware.Deprecated("/api", "/api/v1"))
+		routesV1(r)
 	})
 
 	// ── Server ───────────────────────────────────────────────
@@ -131,7 +417,37 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down...")
-	shutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	researchHandler.Drain()
+	stopBackground()
+	shutCtx, cancel := context.WithTimeout(ctx, cfg.ShutdownTimeout)
 	defer cancel()
 	srv.Shutdown(shutCtx)
+
+	drainCtx, cancelDrain := context.WithTimeout(ctx, cfg.JobGracePeriod)
+	defer cancelDrain()
+	if err := researchHandler.Wait(drainCtx); err != nil {
+		abortCtx, cancelAbort := context.WithTimeout(ctx, 5*time.Second)
+		n := researchHandler.Abort(abortCtx)
+		cancelAbort()
+		log.Printf("research jobs still running after %s, marked %d failed", cfg.JobGracePeriod, n)
+	}
+
+	var hooks shutdown.Hooks
+	hooks.Add("shutdown event", func(context.Context) error {
+		stats := researchHandler.Stats()
+		return shutdown.Record(shutdown.Event{
+			Time:            time.Now().UTC(),
+			UptimeSeconds:   time.Since(startedAt).Seconds(),
+			JobsProcessed:   stats.Finished,
+			JobsFailed:      stats.Failed,
+			JobsInterrupted: stats.Running(),
+		}, cfg.ShutdownEventPath)
+	})
+	hooks.Add("flush logs", func(context.Context) error {
+		return os.Stderr.Sync()
+	})
+	// shutCtx is spent by now; the hooks get a budget of their own.
+	hooksCtx, cancelHooks := context.WithTimeout(ctx, cfg.ShutdownTimeout)
+	defer cancelHooks()
+	hooks.Run(hooksCtx)
 }
@@ -0,0 +1,99 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/secrets"
+)
+
+// APIKeyStore persists encrypted provider API keys per user.
+type APIKeyStore interface {
+	SetAPIKey(ctx context.Context, userID string, encrypted []byte) error
+	ClearAPIKey(ctx context.Context, userID string) error
+	GetAPIKey(ctx context.Context, userID string) ([]byte, error)
+}
+
+// KeyVault encrypts provider API keys before they reach the database.
+type KeyVault struct {
+	store APIKeyStore
+	box   *secrets.Box
+}
+
+func NewKeyVault(store APIKeyStore, box *secrets.Box) *KeyVault {
+	return &KeyVault{store: store, box: box}
+}
+
+// Set encrypts and stores key for userID.
+func (v *KeyVault) Set(ctx context.Context, userID, key string) error {
+	enc, err := v.box.Seal([]byte(key))
+	if err != nil {
+		return err
+	}
+	return v.store.SetAPIKey(ctx, userID, enc)
+}
+
+// Clear removes the stored key of userID.
+func (v *KeyVault) Clear(ctx context.Context, userID string) error {
+	return v.store.ClearAPIKey(ctx, userID)
+}
+
+// Get returns the decrypted key of userID, or "" if none is stored or the
+// vault is disabled.
+func (v *KeyVault) Get(ctx context.Context, userID string) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	enc, err := v.store.GetAPIKey(ctx, userID)
+	if err != nil || enc == nil {
+		return "", err
+	}
+	key, err := v.box.Open(enc)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// PutAPIKey stores the current user's provider API key.
+func (h *Handler) PutAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		APIKey string `json:"api_key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	req.APIKey = strings.TrimSpace(req.APIKey)
+	if req.APIKey == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "api_key is required")
+		return
+	}
+	if err := h.keys.Set(r.Context(), userID, req.APIKey); err != nil {
+		log.Printf("store api key %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to store api key")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"has_api_key": true})
+}
+
+// DeleteAPIKey removes the current user's stored provider API key.
+func (h *Handler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if err := h.keys.Clear(r.Context(), userID); err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to delete api key")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"has_api_key": false})
+}
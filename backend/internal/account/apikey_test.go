@@ -0,0 +1,94 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+	"github.com/ayush/research-ai-agent/backend/internal/secrets"
+)
+
+// memKeys is an in-memory APIKeyStore.
+type memKeys map[string][]byte
+
+func (m memKeys) SetAPIKey(ctx context.Context, userID string, encrypted []byte) error {
+	m[userID] = encrypted
+	return nil
+}
+
+func (m memKeys) ClearAPIKey(ctx context.Context, userID string) error {
+	delete(m, userID)
+	return nil
+}
+
+func (m memKeys) GetAPIKey(ctx context.Context, userID string) ([]byte, error) {
+	return m[userID], nil
+}
+
+func newTestVault(t *testing.T) (*KeyVault, memKeys) {
+	t.Helper()
+	box, err := secrets.NewBox("server-secret", "api-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := memKeys{}
+	return NewKeyVault(keys, box), keys
+}
+
+func serveAs(h http.HandlerFunc, method, body, userID string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/api/user/api-key", strings.NewReader(body))
+	r = r.WithContext(auth.WithUserID(r.Context(), userID))
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func TestPutAndDeleteAPIKey(t *testing.T) {
+	ctx := context.Background()
+	vault, stored := newTestVault(t)
+	h := NewHandler(nil, nil, vault, nil, nil, auth.CookieOptions{})
+
+	w := serveAs(h.PutAPIKey, http.MethodPut, `{"api_key":"  sk-secret-key "}`, "user-a")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"has_api_key":true}` {
+		t.Fatalf("put: got %d %s", w.Code, w.Body)
+	}
+	if bytes.Contains(stored["user-a"], []byte("sk-secret-key")) {
+		t.Error("key stored in plaintext")
+	}
+	if key, err := vault.Get(ctx, "user-a"); err != nil || key != "sk-secret-key" {
+		t.Errorf("Get = %q, %v; want the trimmed key", key, err)
+	}
+	if key, _ := vault.Get(ctx, "user-b"); key != "" {
+		t.Errorf("user-b got key %q", key)
+	}
+
+	w = serveAs(h.DeleteAPIKey, http.MethodDelete, "", "user-a")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"has_api_key":false}` {
+		t.Fatalf("delete: got %d %s", w.Code, w.Body)
+	}
+	if key, _ := vault.Get(ctx, "user-a"); key != "" {
+		t.Errorf("key %q still stored after delete", key)
+	}
+}
+
+func TestPutAPIKeyValidates(t *testing.T) {
+	vault, stored := newTestVault(t)
+	h := NewHandler(nil, nil, vault, nil, nil, auth.CookieOptions{})
+	for _, body := range []string{`{"api_key":""}`, `{"api_key":"   "}`, `not json`} {
+		if w := serveAs(h.PutAPIKey, http.MethodPut, body, "user-a"); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", body, w.Code)
+		}
+	}
+	if len(stored) != 0 {
+		t.Errorf("stored %v for invalid requests", stored)
+	}
+
+	var disabled *KeyVault
+	if key, err := disabled.Get(context.Background(), "user-a"); key != "" || err != nil {
+		t.Errorf("nil vault Get = %q, %v", key, err)
+	}
+}
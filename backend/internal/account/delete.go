@@ -0,0 +1,89 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserRemover deletes user accounts.
+type UserRemover interface {
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// DataPurger removes everything a service stores for a user.
+type DataPurger interface {
+	DeleteUserData(ctx context.Context, userID string) error
+}
+
+// SessionRevoker ends all sessions of a user.
+type SessionRevoker interface {
+	DeleteAll(ctx context.Context, userID string) error
+}
+
+// UserDeleter erases an account across every store. Sessions go first so
+// the account can't be used mid-deletion, and the user row goes last.
+type UserDeleter struct {
+	users    UserRemover
+	data     DataPurger
+	sessions SessionRevoker
+}
+
+func NewUserDeleter(users UserRemover, data DataPurger, sessions SessionRevoker) *UserDeleter {
+	return &UserDeleter{users: users, data: data, sessions: sessions}
+}
+
+// Delete erases userID. Session and data cleanup are best-effort: failures
+// are logged and the remaining steps still run. Only a failure to delete
+// the user row itself is returned.
+func (d *UserDeleter) Delete(ctx context.Context, userID string) error {
+	if err := d.sessions.DeleteAll(ctx, userID); err != nil {
+		log.Printf("delete user %s: sessions: %v", userID, err)
+	}
+	if err := d.data.DeleteUserData(ctx, userID); err != nil {
+		log.Printf("delete user %s: research data: %v", userID, err)
+	}
+	if err := d.users.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("delete user row: %w", err)
+	}
+	return nil
+}
+
+// DeleteAccount permanently deletes the current user and all their data
+// after re-checking their password.
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "password is required")
+		return
+	}
+	hash, err := h.users.GetPasswordHash(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "user not found")
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "password is incorrect")
+		return
+	}
+
+	if err := h.deleter.Delete(r.Context(), userID); err != nil {
+		log.Printf("delete account %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to delete account")
+		return
+	}
+	h.cookies.ClearSession(w)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "account deleted"})
+}
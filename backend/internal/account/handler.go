@@ -0,0 +1,106 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// UserStore is the subset of user persistence the account handlers need.
+type UserStore interface {
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	GetPasswordHash(ctx context.Context, id string) (string, error)
+}
+
+// DocumentStore is the subset of research persistence the account handlers need.
+type DocumentStore interface {
+	EachByUser(ctx context.Context, userID string, fn func(*models.Document) error) error
+}
+
+// Handler holds account-wide HTTP handlers that span users and research data.
+type Handler struct {
+	users   UserStore
+	docs    DocumentStore
+	keys    *KeyVault
+	tokens  TokenStore
+	deleter *UserDeleter
+	cookies auth.CookieOptions
+}
+
+func NewHandler(users UserStore, docs DocumentStore, keys *KeyVault, tokens TokenStore, deleter *UserDeleter, cookies auth.CookieOptions) *Handler {
+	return &Handler{users: users, docs: docs, keys: keys, tokens: tokens, deleter: deleter, cookies: cookies}
+}
+
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// currentUser returns the authenticated user's ID, writing a 401 if the
+// request carries none, e.g. because a route is missing RequireAuth.
+func currentUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
+	}
+	return userID, ok
+}
+
+// exportedDocument shadows LatexContent so it's omitted unless requested.
+type exportedDocument struct {
+	models.Document
+	LatexContent string `json:"latex_content,omitempty"`
+}
+
+// ExportJSON streams all of the current user's data as a single JSON
+// document. LaTeX bodies are only included with ?include_content=true.
+func (h *Handler) ExportJSON(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	includeContent := r.URL.Query().Get("include_content") == "true"
+
+	user, err := h.users.GetUserByID(r.Context(), userID)
+	if err != nil || user == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "user not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=data.json")
+	enc := json.NewEncoder(w)
+
+	w.Write([]byte(`{"exported_at":`))
+	enc.Encode(time.Now().UTC())
+	w.Write([]byte(`,"profile":`))
+	enc.Encode(user)
+	w.Write([]byte(`,"documents":[`))
+
+	first := true
+	err = h.docs.EachByUser(r.Context(), userID, func(doc *models.Document) error {
+		if !first {
+			w.Write([]byte(","))
+		}
+		first = false
+		out := exportedDocument{Document: *doc}
+		if includeContent {
+			out.LatexContent = doc.LatexContent
+		}
+		return enc.Encode(out)
+	})
+	if err != nil {
+		// Headers are already sent; the truncated body signals the failure.
+		log.Printf("export %s: %v", userID, err)
+		return
+	}
+	w.Write([]byte("]}"))
+}
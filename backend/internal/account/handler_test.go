@@ -0,0 +1,127 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// fakeUsers is a UserStore over a fixed set of users.
+type fakeUsers map[string]*models.User
+
+func (u fakeUsers) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	return u[id], nil
+}
+
+func (u fakeUsers) GetPasswordHash(ctx context.Context, id string) (string, error) {
+	if u[id] == nil {
+		return "", errors.New("user not found")
+	}
+	return u[id].Password, nil
+}
+
+// fakeDocs is a DocumentStore over a fixed list of documents.
+type fakeDocs []models.Document
+
+func (d fakeDocs) EachByUser(ctx context.Context, userID string, fn func(*models.Document) error) error {
+	for i := range d {
+		if d[i].UserID != userID {
+			continue
+		}
+		if err := fn(&d[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTestHandler() *Handler {
+	users := fakeUsers{"user-a": {ID: "user-a", Username: "alice", Email: "alice@example.com", Password: "$2a$10$secrethash"}}
+	docs := fakeDocs{
+		{UserID: "user-a", Topic: "solar panels", LatexContent: "\\section{Solar}", Sources: []models.Source{{Title: "NREL", Href: "https://nrel.gov"}}},
+		{UserID: "user-b", Topic: "not mine", LatexContent: "\\section{Other}"},
+		{UserID: "user-a", Topic: "wind", LatexContent: "\\section{Wind}"},
+	}
+	return NewHandler(users, docs, nil, nil, nil, auth.CookieOptions{})
+}
+
+func export(t *testing.T, h *Handler, userID, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r = r.WithContext(auth.WithUserID(r.Context(), userID))
+	w := httptest.NewRecorder()
+	h.ExportJSON(w, r)
+	return w
+}
+
+type exportResponse struct {
+	ExportedAt string                   `json:"exported_at"`
+	Profile    map[string]interface{}   `json:"profile"`
+	Documents  []map[string]interface{} `json:"documents"`
+}
+
+func TestExportJSON(t *testing.T) {
+	h := newTestHandler()
+	w := export(t, h, "user-a", "/api/user/data.json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "secrethash") {
+		t.Error("export contains the password hash")
+	}
+
+	var resp exportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if resp.ExportedAt == "" || resp.Profile["email"] != "alice@example.com" {
+		t.Errorf("bad header fields: %+v", resp)
+	}
+	if _, ok := resp.Profile["password"]; ok {
+		t.Error("profile has a password field")
+	}
+	if len(resp.Documents) != 2 || resp.Documents[0]["topic"] != "solar panels" || resp.Documents[1]["topic"] != "wind" {
+		t.Fatalf("documents = %v, want user-a's two", resp.Documents)
+	}
+	if len(resp.Documents[0]["sources"].([]interface{})) != 1 {
+		t.Errorf("sources missing: %v", resp.Documents[0])
+	}
+	for _, d := range resp.Documents {
+		if _, ok := d["latex_content"]; ok {
+			t.Errorf("latex_content exported without include_content: %v", d)
+		}
+	}
+}
+
+func TestExportJSONIncludesContent(t *testing.T) {
+	h := newTestHandler()
+	w := export(t, h, "user-a", "/api/user/data.json?include_content=true")
+	var resp exportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if len(resp.Documents) != 2 || resp.Documents[0]["latex_content"] != "\\section{Solar}" {
+		t.Errorf("documents = %v, want LaTeX bodies", resp.Documents)
+	}
+}
+
+func TestExportJSONUnknownUser(t *testing.T) {
+	if w := export(t, newTestHandler(), "ghost", "/api/user/data.json"); w.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404", w.Code)
+	}
+}
+
+func TestExportJSONWithoutUser(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestHandler().ExportJSON(w, httptest.NewRequest(http.MethodGet, "/api/user/data.json", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("got %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// maxTokenName caps the length of a personal access token's label.
+const maxTokenName = 100
+
+// TokenStore persists personal access tokens by hash.
+type TokenStore interface {
+	CreateAccessToken(ctx context.Context, userID, name, prefix string, hash []byte) (*models.AccessToken, error)
+	ListAccessTokens(ctx context.Context, userID string) ([]models.AccessToken, error)
+	DeleteAccessToken(ctx context.Context, userID, id string) error
+}
+
+// CreateToken issues a personal access token for the current user. The
+// plaintext token is only returned in this response.
+func (h *Handler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxTokenName {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "name is required and must be at most 100 characters")
+		return
+	}
+
+	token, hash, err := auth.NewAccessToken()
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "internal error")
+		return
+	}
+	prefix := token[:len(auth.AccessTokenPrefix)+6]
+	meta, err := h.tokens.CreateAccessToken(r.Context(), userID, req.Name, prefix, hash)
+	if err != nil {
+		log.Printf("create access token %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to create token")
+		return
+	}
+	writeJSON(w, http.StatusCreated, struct {
+		*models.AccessToken
+		Token string `json:"token"`
+	}{meta, token})
+}
+
+// ListTokens returns the metadata of the current user's access tokens.
+func (h *Handler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	tokens, err := h.tokens.ListAccessTokens(r.Context(), userID)
+	if err != nil {
+		log.Printf("list access tokens %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to list tokens")
+		return
+	}
+	if tokens == nil {
+		tokens = []models.AccessToken{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]models.AccessToken{"tokens": tokens})
+}
+
+// DeleteToken revokes one of the current user's access tokens.
+func (h *Handler) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "not found")
+		return
+	}
+	err := h.tokens.DeleteAccessToken(r.Context(), userID, id)
+	if errors.Is(err, models.ErrTokenNotFound) {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "not found")
+		return
+	}
+	if err != nil {
+		log.Printf("delete access token %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to delete token")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "deleted"})
+}
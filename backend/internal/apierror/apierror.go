@@ -0,0 +1,63 @@
+// Package apierror writes the JSON error responses shared by all handlers:
+//
+//	{"error":{"code":"not_found","message":"not found"}}
+//
+// Code is a stable machine-readable identifier; message is for humans and
+// may change. Extra context goes in an optional details object.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Code identifies the kind of failure.
+type Code string
+
+// Error codes. Most follow the status they're sent with; the rest narrow
+// one down where clients need to tell cases apart.
+const (
+	InvalidRequest  Code = "invalid_request"
+	Unauthorized    Code = "unauthorized"
+	InvalidToken    Code = "invalid_token"
+	InvalidSession  Code = "invalid_session"
+	Forbidden       Code = "forbidden"
+	CSRFFailed      Code = "csrf_failed"
+	NotFound        Code = "not_found"
+	Conflict        Code = "conflict"
+	TooLarge        Code = "payload_too_large"
+	Unprocessable   Code = "unprocessable"
+	RateLimited     Code = "rate_limited"
+	QuotaExceeded   Code = "quota_exceeded"
+	Internal        Code = "internal_error"
+	NotImplemented  Code = "not_implemented"
+	UpstreamFailure Code = "upstream_failure"
+	Unavailable     Code = "unavailable"
+)
+
+// Body is the JSON shape of an error response.
+type Body struct {
+	Error Error `json:"error"`
+}
+
+// Error describes a failure.
+type Error struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Write sends an error response with the given status, code and message.
+func Write(w http.ResponseWriter, status int, code Code, message string) {
+	WriteDetails(w, status, code, message, nil)
+}
+
+// WriteDetails is Write with extra context for the client, such as the
+// accepted values of a rejected field.
+func WriteDetails(w http.ResponseWriter, status int, code Code, message string, details map[string]interface{}) {
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Body{Error{Code: code, Message: message, Details: details}})
+}
@@ -0,0 +1,87 @@
+package apierror
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	// Codes are part of the API: clients switch on them, so each is pinned
+	// here with the status it is sent with.
+	tests := []struct {
+		status int
+		code   Code
+		want   string
+	}{
+		{http.StatusBadRequest, InvalidRequest, "invalid_request"},
+		{http.StatusUnauthorized, Unauthorized, "unauthorized"},
+		{http.StatusUnauthorized, InvalidToken, "invalid_token"},
+		{http.StatusUnauthorized, InvalidSession, "invalid_session"},
+		{http.StatusForbidden, Forbidden, "forbidden"},
+		{http.StatusForbidden, CSRFFailed, "csrf_failed"},
+		{http.StatusNotFound, NotFound, "not_found"},
+		{http.StatusConflict, Conflict, "conflict"},
+		{http.StatusRequestEntityTooLarge, TooLarge, "payload_too_large"},
+		{http.StatusUnprocessableEntity, Unprocessable, "unprocessable"},
+		{http.StatusTooManyRequests, RateLimited, "rate_limited"},
+		{http.StatusTooManyRequests, QuotaExceeded, "quota_exceeded"},
+		{http.StatusInternalServerError, Internal, "internal_error"},
+		{http.StatusNotImplemented, NotImplemented, "not_implemented"},
+		{http.StatusBadGateway, UpstreamFailure, "upstream_failure"},
+		{http.StatusServiceUnavailable, Unavailable, "unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Write(w, tt.status, tt.code, "something went wrong")
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			want := `{"error":{"code":"` + tt.want + `","message":"something went wrong"}}` + "\n"
+			if got := w.Body.String(); got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	Write(w, http.StatusNotFound, NotFound, "not found")
+
+	for name, want := range map[string]string{
+		"Content-Type":           "application/json",
+		"X-Content-Type-Options": "nosniff",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWriteDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		details map[string]interface{}
+		want    string
+	}{
+		{"nil details are left out", nil, `{"error":{"code":"invalid_request","message":"bad field"}}`},
+		{"empty details are left out", map[string]interface{}{}, `{"error":{"code":"invalid_request","message":"bad field"}}`},
+		{
+			"details are nested under error",
+			map[string]interface{}{"field": "dedup", "allowed": []string{"none", "url"}},
+			`{"error":{"code":"invalid_request","message":"bad field","details":{"allowed":["none","url"],"field":"dedup"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			WriteDetails(w, http.StatusBadRequest, InvalidRequest, "bad field", tt.details)
+			if got := w.Body.String(); got != tt.want+"\n" {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
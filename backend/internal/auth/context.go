@@ -0,0 +1,19 @@
+package auth
+
+import "context"
+
+// userIDKey is the context key for the authenticated user's ID. Being an
+// unexported type, it can't collide with keys set by other packages.
+type userIDKey struct{}
+
+// WithUserID returns a copy of ctx carrying the authenticated user's ID.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext returns the user ID set by WithUserID. It reports false
+// when the request didn't pass through the auth middleware.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(string)
+	return userID, ok && userID != ""
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"net/http"
+)
+
+// CSRF double-submit names: the token is sent as a cookie scripts can read
+// and must be echoed in the header on unsafe requests.
+const (
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// NewCSRFToken returns a random CSRF token.
+func NewCSRFToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// SetCSRF sends token as the CSRF cookie. It lives as long as the browser
+// session and, unlike the session cookie, is readable by the frontend.
+func (c CookieOptions) SetCSRF(w http.ResponseWriter, token string) {
+	cookie := c.cookie(token, 0)
+	cookie.Name, cookie.HttpOnly = CSRFCookie, false
+	http.SetCookie(w, cookie)
+}
+
+// IssueCSRF sends a new CSRF token, logging rather than failing if none
+// could be generated: the next safe request issues one instead.
+func (c CookieOptions) IssueCSRF(w http.ResponseWriter) {
+	token, err := NewCSRFToken()
+	if err != nil {
+		log.Printf("generate csrf token: %v", err)
+		return
+	}
+	c.SetCSRF(w, token)
+}
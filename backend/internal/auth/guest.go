@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// isGuest reports whether userID belongs to a guest.
+func (h *Handler) isGuest(userID string) bool {
+	return h.opts.GuestPrefix != "" && strings.HasPrefix(userID, h.opts.GuestPrefix)
+}
+
+// guestUser is what Me and Guest return for a guest.
+func guestUser(userID string) *models.User {
+	return &models.User{ID: userID, Username: "guest", Guest: true}
+}
+
+// Guest starts a session for a new guest, who can try research without an
+// account. Guests' documents are deleted after the guest retention period.
+func (h *Handler) Guest(w http.ResponseWriter, r *http.Request) {
+	if h.opts.GuestPrefix == "" {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "guest access is disabled")
+		return
+	}
+	if !h.sessions.Available(r.Context()) {
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "authentication temporarily unavailable")
+		return
+	}
+
+	userID := h.opts.GuestPrefix + uuid.New().String()
+	sid, err := h.sessions.CreateGuest(r.Context(), userID)
+	if errors.Is(err, ErrSessionStoreUnavailable) {
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "authentication temporarily unavailable")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "session creation failed")
+		return
+	}
+
+	h.sessions.Cookies().SetSession(w, sid, h.sessions.TTL())
+	h.sessions.Cookies().IssueCSRF(w)
+	writeJSON(w, http.StatusCreated, guestUser(userID))
+}
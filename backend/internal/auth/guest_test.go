@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestGuest(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	sessions := NewSessionStore(rdb, SessionOptions{})
+	users := newFakeUsers(t, "Correct1Horse")
+
+	w := httptest.NewRecorder()
+	NewHandler(users, sessions, Options{}).Guest(w, httptest.NewRequest(http.MethodPost, "/api/auth/guest", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("without a guest prefix: got %d, want 404", w.Code)
+	}
+
+	h := NewHandler(users, sessions, Options{GuestPrefix: "guest/"})
+	w = httptest.NewRecorder()
+	h.Guest(w, httptest.NewRequest(http.MethodPost, "/api/auth/guest", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got %d %s, want 201", w.Code, w.Body)
+	}
+	var guest models.User
+	if err := json.NewDecoder(w.Body).Decode(&guest); err != nil || !guest.Guest || !strings.HasPrefix(guest.ID, "guest/") {
+		t.Fatalf("guest = %+v, %v; want a guest ID under guest/", guest, err)
+	}
+	c := cookieNamed(w.Result().Cookies(), SessionCookie)
+	if c == nil {
+		t.Fatal("no session cookie")
+	}
+	if got, err := sessions.Get(context.Background(), c.Value); err != nil || got != guest.ID {
+		t.Errorf("session resolves to %q, %v; want %q", got, err, guest.ID)
+	}
+	if mr.Exists(userSessionsKey(guest.ID)) {
+		t.Error("guest session was indexed; the index would outlive it")
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/auth/me", nil)
+	h.Me(w, r.WithContext(WithUserID(r.Context(), guest.ID)))
+	var me models.User
+	if err := json.NewDecoder(w.Body).Decode(&me); err != nil || w.Code != http.StatusOK || me.ID != guest.ID || !me.Guest {
+		t.Errorf("me: got %d %+v, want the guest", w.Code, me)
+	}
+}
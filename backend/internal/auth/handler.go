@@ -3,9 +3,11 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"time"
+	"sync"
 
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
 	"github.com/ayush/research-ai-agent/backend/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -15,39 +17,86 @@ type UserStore interface {
 	CreateUser(ctx context.Context, username, email, hashedPw string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	UpdatePassword(ctx context.Context, id, hashedPw string) error
+	GetPasswordHash(ctx context.Context, id string) (string, error)
+	UpdateUser(ctx context.Context, id, username, email string) (*models.User, error)
+	SetEmailVerified(ctx context.Context, id, email string) error
+}
+
+// Options tunes auth behaviour.
+type Options struct {
+	PasswordPolicy PasswordPolicy
+	Resets         *ResetStore
+	Mailer         Mailer
+	// ResetURL is the frontend page reset links point to; the token is
+	// appended as a query parameter.
+	ResetURL string
+	// Verifications and VerifyURL do the same for email verification
+	// links. Without Verifications no verification emails are sent.
+	Verifications *VerifyStore
+	VerifyURL     string
+	// GuestPrefix, when set, lets visitors start a guest session without an
+	// account. Guest user IDs are GuestPrefix followed by a random ID.
+	GuestPrefix string
 }
 
 // Handler holds auth-related HTTP handlers.
 type Handler struct {
 	users    UserStore
 	sessions *SessionStore
+	opts     Options
+	// mail tracks the reset emails sent in the background.
+	mail sync.WaitGroup
+}
+
+func NewHandler(users UserStore, sessions *SessionStore, opts Options) *Handler {
+	return &Handler{users: users, sessions: sessions, opts: opts}
 }
 
-func NewHandler(users UserStore, sessions *SessionStore) *Handler {
-	return &Handler{users: users, sessions: sessions}
+// writeJSON writes a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// checkPassword validates pw against the configured policy and writes a 400
+// listing the failed rules if it doesn't pass.
+func (h *Handler) checkPassword(w http.ResponseWriter, pw string) bool {
+	var perr *PasswordError
+	if !errors.As(ValidatePassword(pw, h.opts.PasswordPolicy), &perr) {
+		return true
+	}
+	apierror.WriteDetails(w, http.StatusBadRequest, apierror.InvalidRequest, perr.Error(), map[string]interface{}{
+		"field_errors": perr.Errors,
+	})
+	return false
 }
 
 // Register creates a new user.
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
 		return
 	}
 	if req.Username == "" || req.Email == "" || req.Password == "" {
-		http.Error(w, `{"error":"username, email, and password are required"}`, http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "username, email, and password are required")
+		return
+	}
+	if !h.checkPassword(w, req.Password) {
 		return
 	}
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, `{"error":"internal error"}`, http.StatusInternalServerError)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "internal error")
 		return
 	}
 
 	user, err := h.users.CreateUser(r.Context(), req.Username, req.Email, string(hashed))
 	if err != nil {
-		http.Error(w, `{"error":"user already exists or database error"}`, http.StatusConflict)
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "user already exists or database error")
 		return
 	}
 
@@ -60,35 +109,38 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+
+	if !h.sessions.Available(r.Context()) {
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "authentication temporarily unavailable")
 		return
 	}
 
 	user, err := h.users.GetUserByEmail(r.Context(), req.Email)
 	if err != nil || user == nil {
-		http.Error(w, `{"error":"invalid credentials"}`, http.StatusUnauthorized)
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "invalid credentials")
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		http.Error(w, `{"error":"invalid credentials"}`, http.StatusUnauthorized)
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "invalid credentials")
 		return
 	}
 
-	sid, err := h.sessions.Create(r.Context(), user.ID)
+	sid, err := h.sessions.Create(r.Context(), user.ID, MetaFromRequest(r))
+	if errors.Is(err, ErrSessionStoreUnavailable) {
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "authentication temporarily unavailable")
+		return
+	}
 	if err != nil {
-		http.Error(w, `{"error":"session creation failed"}`, http.StatusInternalServerError)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "session creation failed")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     SessionCookie,
-		Value:    sid,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   int(SessionTTL / time.Second),
-	})
+	h.sessions.Cookies().SetSession(w, sid, h.sessions.TTL())
+	h.sessions.Cookies().IssueCSRF(w)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
@@ -101,13 +153,7 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 		h.sessions.Delete(r.Context(), cookie.Value)
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     SessionCookie,
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   -1,
-	})
+	h.sessions.Cookies().ClearSession(w)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(`{"message":"logged out"}`))
@@ -115,15 +161,20 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 
 // Me returns the currently authenticated user.
 func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id")
-	if userID == nil {
-		http.Error(w, `{"error":"not authenticated"}`, http.StatusUnauthorized)
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
+		return
+	}
+
+	if h.isGuest(userID) {
+		writeJSON(w, http.StatusOK, guestUser(userID))
 		return
 	}
 
-	user, err := h.users.GetUserByID(r.Context(), userID.(string))
+	user, err := h.users.GetUserByID(r.Context(), userID)
 	if err != nil || user == nil {
-		http.Error(w, `{"error":"user not found"}`, http.StatusNotFound)
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "user not found")
 		return
 	}
 
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// fakeUsers is a UserStore holding users by email.
+type fakeUsers struct {
+	UserStore
+	byEmail map[string]*models.User
+}
+
+func (u *fakeUsers) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return u.byEmail[email], nil
+}
+
+func (u *fakeUsers) byID(id string) *models.User {
+	for _, user := range u.byEmail {
+		if user.ID == id {
+			return user
+		}
+	}
+	return nil
+}
+
+func (u *fakeUsers) GetPasswordHash(ctx context.Context, id string) (string, error) {
+	user := u.byID(id)
+	if user == nil {
+		return "", errors.New("user not found")
+	}
+	return user.Password, nil
+}
+
+func (u *fakeUsers) UpdatePassword(ctx context.Context, id, hashedPw string) error {
+	user := u.byID(id)
+	if user == nil {
+		return errors.New("user not found")
+	}
+	user.Password = hashedPw
+	return nil
+}
+
+// newFakeUsers holds alice@example.com with the given password.
+func newFakeUsers(t *testing.T, password string) *fakeUsers {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeUsers{byEmail: map[string]*models.User{
+		"alice@example.com": {ID: "user-a", Username: "alice", Email: "alice@example.com", Password: string(hash)},
+	}}
+}
+
+func login(h *Handler, email, password string) *httptest.ResponseRecorder {
+	body := `{"email":"` + email + `","password":"` + password + `"}`
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body)))
+	return w
+}
+
+// cookieNamed returns the cookie called name, or nil.
+func cookieNamed(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestLogin(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	sessions := NewSessionStore(rdb, SessionOptions{})
+	h := NewHandler(newFakeUsers(t, "Correct1Horse"), sessions, Options{})
+
+	w := login(h, "alice@example.com", "Correct1Horse")
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var sid string
+	if c := cookieNamed(w.Result().Cookies(), SessionCookie); c != nil {
+		sid = c.Value
+	}
+	if c := cookieNamed(w.Result().Cookies(), CSRFCookie); c == nil || c.Value == "" || c.HttpOnly {
+		t.Errorf("csrf cookie = %v, want a token scripts can read", c)
+	}
+	if got, err := sessions.Get(context.Background(), sid); err != nil || got != "user-a" {
+		t.Errorf("session %q resolves to %q, %v", sid, got, err)
+	}
+
+	for _, bad := range [][2]string{{"alice@example.com", "wrong"}, {"bob@example.com", "Correct1Horse"}} {
+		if w := login(h, bad[0], bad[1]); w.Code != http.StatusUnauthorized {
+			t.Errorf("login %s/%s: got %d, want 401", bad[0], bad[1], w.Code)
+		}
+	}
+}
+
+func TestSessionCookieAttributes(t *testing.T) {
+	tests := []struct {
+		name         string
+		cookies      CookieOptions
+		wantSecure   bool
+		wantSameSite http.SameSite
+	}{
+		{"development", CookieOptions{}, false, http.SameSiteLaxMode},
+		{"production", CookieOptions{Secure: true}, true, http.SameSiteLaxMode},
+		{"production cross-site", CookieOptions{Secure: true, SameSite: http.SameSiteNoneMode}, true, http.SameSiteNoneMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, rdb := newTestRedis(t)
+			h := NewHandler(newFakeUsers(t, "Correct1Horse"), NewSessionStore(rdb, SessionOptions{Cookie: tt.cookies}), Options{})
+
+			w := login(h, "alice@example.com", "Correct1Horse")
+			if w.Code != http.StatusOK {
+				t.Fatalf("login: got %d %s, want 200", w.Code, w.Body)
+			}
+			set := cookieNamed(w.Result().Cookies(), SessionCookie)
+			r := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+			r.AddCookie(set)
+			w = httptest.NewRecorder()
+			h.Logout(w, r)
+			cleared := cookieNamed(w.Result().Cookies(), SessionCookie)
+
+			for _, c := range []*http.Cookie{set, cleared} {
+				if c.Name != SessionCookie || !c.HttpOnly || c.Secure != tt.wantSecure || c.SameSite != tt.wantSameSite {
+					t.Errorf("cookie %+v, want HttpOnly with Secure=%v SameSite=%v", c, tt.wantSecure, tt.wantSameSite)
+				}
+			}
+			if cleared.MaxAge >= 0 || cleared.Value != "" {
+				t.Errorf("logout cookie %+v does not clear the session", cleared)
+			}
+		})
+	}
+}
+
+func TestCookieOptionsValidate(t *testing.T) {
+	if err := (CookieOptions{SameSite: http.SameSiteNoneMode}).Validate(); err == nil {
+		t.Error("SameSite=None without Secure is valid")
+	}
+	for _, s := range []string{"", "lax", "Strict", " none "} {
+		if _, err := ParseSameSite(s); err != nil {
+			t.Errorf("ParseSameSite(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseSameSite("sometimes"); err == nil {
+		t.Error("ParseSameSite accepted an unknown mode")
+	}
+}
+
+func TestLoginSessionStoreDown(t *testing.T) {
+	users := newFakeUsers(t, "Correct1Horse")
+
+	h := NewHandler(users, NewSessionStore(downRedis(t), SessionOptions{}), Options{})
+	if w := login(h, "alice@example.com", "Correct1Horse"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("without fallback: got %d %s, want 503", w.Code, w.Body)
+	}
+
+	sessions := NewSessionStore(downRedis(t), SessionOptions{Secret: "secret", StatelessFallback: true})
+	h = NewHandler(users, sessions, Options{})
+	w := login(h, "alice@example.com", "Correct1Horse")
+	if w.Code != http.StatusOK {
+		t.Fatalf("with fallback: got %d %s, want 200", w.Code, w.Body)
+	}
+	session := cookieNamed(w.Result().Cookies(), SessionCookie)
+	if session == nil || !looksLikeJWT(session.Value) {
+		t.Fatalf("cookies = %v, want a stateless session", w.Result().Cookies())
+	}
+	if got, _ := sessions.Get(context.Background(), session.Value); got != "user-a" {
+		t.Errorf("stateless session resolves to %q", got)
+	}
+	if w := login(h, "alice@example.com", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password with fallback: got %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, forged, or expired.
+var ErrInvalidToken = errors.New("invalid token")
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// tokenClaims are the registered JWT claims used for stateless sessions.
+type tokenClaims struct {
+	Subject   string `json:"sub"`
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// tokenSigner issues and verifies HS256 JWTs.
+type tokenSigner struct {
+	secret []byte
+}
+
+func (t *tokenSigner) mac(signingInput string) string {
+	m := hmac.New(sha256.New, t.secret)
+	m.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func (t *tokenSigner) sign(c tokenClaims) string {
+	payload, _ := json.Marshal(c)
+	input := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return input + "." + t.mac(input)
+}
+
+func (t *tokenSigner) verify(token string, now time.Time) (tokenClaims, error) {
+	var c tokenClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return c, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(t.mac(parts[0]+"."+parts[1]))) {
+		return c, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(payload, &c) != nil {
+		return c, ErrInvalidToken
+	}
+	if c.Subject == "" || now.Unix() >= c.ExpiresAt {
+		return c, ErrInvalidToken
+	}
+	return c, nil
+}
+
+// looksLikeJWT reports whether s has the three-segment shape of a JWT.
+func looksLikeJWT(s string) bool {
+	return strings.Count(s, ".") == 2
+}
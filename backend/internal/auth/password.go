@@ -0,0 +1,108 @@
+package auth
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords is the embedded deny-list, lowercased.
+var commonPasswords = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			m[strings.ToLower(line)] = true
+		}
+	}
+	return m
+}()
+
+// PasswordPolicy describes the rules a new password must satisfy.
+type PasswordPolicy struct {
+	MinLength        int
+	RequireMixedCase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	RejectCommon     bool
+}
+
+// DefaultPasswordPolicy matches the configuration defaults.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:        8,
+	RequireMixedCase: true,
+	RequireDigit:     true,
+	RejectCommon:     true,
+}
+
+// PasswordError lists the rules a password failed.
+type PasswordError struct {
+	Errors []FieldError
+}
+
+func (e *PasswordError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Message
+	}
+	return "password " + strings.Join(msgs, ", ")
+}
+
+// ValidatePassword checks pw against policy, returning a *PasswordError
+// listing every unmet rule. Registration and the password change and reset
+// flows call it with the configured policy.
+func ValidatePassword(pw string, policy PasswordPolicy) error {
+	if errs := validatePassword(pw, policy); len(errs) > 0 {
+		return &PasswordError{Errors: errs}
+	}
+	return nil
+}
+
+// FieldError describes one failed validation rule for a request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// validatePassword returns every rule pw fails under policy, or nil.
+func validatePassword(pw string, policy PasswordPolicy) []FieldError {
+	var errs []FieldError
+	fail := func(rule, msg string) {
+		errs = append(errs, FieldError{Field: "password", Rule: rule, Message: msg})
+	}
+
+	if len([]rune(pw)) < policy.MinLength {
+		fail("min_length", fmt.Sprintf("must be at least %d characters", policy.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if policy.RequireMixedCase && !(upper && lower) {
+		fail("mixed_case", "must contain both upper and lower case letters")
+	}
+	if policy.RequireDigit && !digit {
+		fail("digit", "must contain a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		fail("symbol", "must contain a symbol")
+	}
+	if policy.RejectCommon && commonPasswords[strings.ToLower(pw)] {
+		fail("common", "is too common")
+	}
+	return errs
+}
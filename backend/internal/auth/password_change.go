@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// confirmPassword checks pw against the stored hash of userID, writing a
+// 401 if it doesn't match.
+func (h *Handler) confirmPassword(w http.ResponseWriter, r *http.Request, userID, pw string) bool {
+	hash, err := h.users.GetPasswordHash(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "user not found")
+		return false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(pw)) != nil {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "current password is incorrect")
+		return false
+	}
+	return true
+}
+
+// ChangePassword replaces the current user's password after checking the
+// current one. With revoke_other_sessions every other session is logged
+// out; the caller's session is replaced by a fresh one.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
+		return
+	}
+
+	var req struct {
+		CurrentPassword     string `json:"current_password"`
+		NewPassword         string `json:"new_password"`
+		RevokeOtherSessions bool   `json:"revoke_other_sessions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "current_password and new_password are required")
+		return
+	}
+	if !h.confirmPassword(w, r, userID, req.CurrentPassword) {
+		return
+	}
+	if !h.checkPassword(w, req.NewPassword) {
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "internal error")
+		return
+	}
+	if err := h.users.UpdatePassword(r.Context(), userID, string(hashed)); err != nil {
+		log.Printf("change password for %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to update password")
+		return
+	}
+
+	if req.RevokeOtherSessions {
+		if err := h.sessions.DeleteAll(r.Context(), userID); err != nil {
+			log.Printf("revoke sessions for %s: %v", userID, err)
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "password updated, but other sessions could not be revoked")
+			return
+		}
+		// Requests authenticated by an access token have no session to keep.
+		if _, err := r.Cookie(SessionCookie); err == nil {
+			if sid, err := h.sessions.Create(r.Context(), userID, MetaFromRequest(r)); err == nil {
+				h.sessions.Cookies().SetSession(w, sid, h.sessions.TTL())
+			} else {
+				log.Printf("recreate session for %s: %v", userID, err)
+				h.sessions.Cookies().ClearSession(w)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "password updated"})
+}
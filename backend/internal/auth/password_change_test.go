@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func changePassword(h *Handler, sid, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/user/change-password", strings.NewReader(body))
+	r.AddCookie(&http.Cookie{Name: SessionCookie, Value: sid})
+	r = r.WithContext(WithUserID(r.Context(), "user-a"))
+	w := httptest.NewRecorder()
+	h.ChangePassword(w, r)
+	return w
+}
+
+func TestChangePasswordRejects(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	users := newFakeUsers(t, "Correct1Horse")
+	before := users.byID("user-a").Password
+	h := NewHandler(users, NewSessionStore(rdb, SessionOptions{}), Options{PasswordPolicy: defaultPolicy})
+
+	tests := []struct {
+		name, body string
+		code       int
+	}{
+		{"wrong current password", `{"current_password":"Wrong1Horse","new_password":"Battery9Staple"}`, http.StatusUnauthorized},
+		{"weak new password", `{"current_password":"Correct1Horse","new_password":"short"}`, http.StatusBadRequest},
+		{"common new password", `{"current_password":"Correct1Horse","new_password":"Password1"}`, http.StatusBadRequest},
+		{"missing fields", `{"current_password":"Correct1Horse"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := changePassword(h, "", tt.body); w.Code != tt.code {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+		})
+	}
+	if users.byID("user-a").Password != before {
+		t.Error("a rejected change updated the password")
+	}
+}
+
+func TestChangePasswordRevokesOtherSessions(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	users := newFakeUsers(t, "Correct1Horse")
+	sessions := NewSessionStore(rdb, SessionOptions{})
+	h := NewHandler(users, sessions, Options{PasswordPolicy: defaultPolicy})
+	ctx := context.Background()
+	current, _ := sessions.Create(ctx, "user-a", SessionMeta{})
+	other, _ := sessions.Create(ctx, "user-a", SessionMeta{})
+
+	w := changePassword(h, current, `{"current_password":"Correct1Horse","new_password":"Battery9Staple","revoke_other_sessions":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(users.byID("user-a").Password), []byte("Battery9Staple")) != nil {
+		t.Error("password was not updated")
+	}
+	for _, sid := range []string{current, other} {
+		if got, _ := sessions.Get(ctx, sid); got != "" {
+			t.Errorf("old session %s still resolves to %q", sid, got)
+		}
+	}
+	var fresh string
+	for _, c := range w.Result().Cookies() {
+		if c.Name == SessionCookie {
+			fresh = c.Value
+		}
+	}
+	if got, err := sessions.Get(ctx, fresh); err != nil || got != "user-a" {
+		t.Errorf("new session %q resolves to %q, %v", fresh, got, err)
+	}
+}
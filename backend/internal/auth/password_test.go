@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// defaultPolicy matches the configuration defaults.
+var defaultPolicy = PasswordPolicy{MinLength: 8, RequireMixedCase: true, RequireDigit: true, RejectCommon: true}
+
+// fieldErrorsBody is the error response for a rejected password.
+type fieldErrorsBody struct {
+	Error struct {
+		Code    apierror.Code `json:"code"`
+		Details struct {
+			FieldErrors []FieldError `json:"field_errors"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// failedRules returns the rules in errs.
+func failedRules(t *testing.T, errs []FieldError) []string {
+	t.Helper()
+	var failed []string
+	for _, fe := range errs {
+		if fe.Field != "password" {
+			t.Errorf("field = %q, want password", fe.Field)
+		}
+		failed = append(failed, fe.Rule)
+	}
+	return failed
+}
+
+func TestValidatePasswordDefaultPolicy(t *testing.T) {
+	tests := []struct {
+		pw   string
+		want []string
+	}{
+		{"a", []string{"min_length", "mixed_case", "digit"}},
+		{"short1A", []string{"min_length"}},
+		{"alllowercase1", []string{"mixed_case"}},
+		{"NoDigitsHere", []string{"digit"}},
+		{"Password1", []string{"common"}},
+		{"password123", []string{"mixed_case", "common"}},
+		{"Correct1Horse", nil},
+		{"Tr0ub4dor&3", nil},
+		{"Ünïcode5ecret", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pw, func(t *testing.T) {
+			got := failedRules(t, validatePassword(tt.pw, defaultPolicy))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("failed rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePasswordUsesPolicy(t *testing.T) {
+	strict := PasswordPolicy{MinLength: 12, RequireMixedCase: true, RequireDigit: true, RequireSymbol: true, RejectCommon: true}
+	lax := PasswordPolicy{MinLength: 4}
+
+	tests := []struct {
+		pw     string
+		policy PasswordPolicy
+		want   []string
+	}{
+		{"Correct1Horse", strict, []string{"symbol"}},
+		{"Correct1Horse!", strict, nil},
+		{"Short1!a", strict, []string{"min_length"}},
+		{"abcd", lax, nil},
+		{"abc", lax, []string{"min_length"}},
+		{"password", lax, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pw, func(t *testing.T) {
+			got := failedRules(t, validatePassword(tt.pw, tt.policy))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("failed rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	for _, pw := range []string{"Correct1Horse", "Tr0ub4dor&3"} {
+		if err := ValidatePassword(pw, DefaultPasswordPolicy); err != nil {
+			t.Errorf("%q: %v", pw, err)
+		}
+	}
+
+	for _, pw := range []string{"a", "password", "Password1", "ALLCAPS123"} {
+		var perr *PasswordError
+		if err := ValidatePassword(pw, DefaultPasswordPolicy); !errors.As(err, &perr) || len(perr.Errors) == 0 {
+			t.Errorf("%q: got %v, want a *PasswordError", pw, err)
+		}
+	}
+
+	err := ValidatePassword("a", PasswordPolicy{MinLength: 8, RequireDigit: true})
+	if want := "password must be at least 8 characters, must contain a digit"; err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
+
+func TestRegisterRejectsWeakPassword(t *testing.T) {
+	h := NewHandler(nil, nil, Options{PasswordPolicy: defaultPolicy})
+	body := `{"username":"alice","email":"alice@example.com","password":"password"}`
+	w := httptest.NewRecorder()
+	h.Register(w, httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got %d %s, want 400", w.Code, w.Body)
+	}
+	var resp fieldErrorsBody
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if resp.Error.Code != apierror.InvalidRequest {
+		t.Errorf("code = %q, want %q", resp.Error.Code, apierror.InvalidRequest)
+	}
+	if got := failedRules(t, resp.Error.Details.FieldErrors); !slices.Equal(got, []string{"mixed_case", "digit", "common"}) {
+		t.Errorf("field errors = %v", got)
+	}
+}
+
+func TestRegisterUsesConfiguredPolicy(t *testing.T) {
+	h := NewHandler(nil, nil, Options{PasswordPolicy: PasswordPolicy{MinLength: 10, RequireSymbol: true}})
+	body := `{"username":"alice","email":"alice@example.com","password":"Correct1Horse"}`
+	w := httptest.NewRecorder()
+	h.Register(w, httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got %d %s, want 400", w.Code, w.Body)
+	}
+	var resp fieldErrorsBody
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	if got := failedRules(t, resp.Error.Details.FieldErrors); !slices.Equal(got, []string{"symbol"}) {
+		t.Errorf("field errors = %v, want only the configured symbol rule", got)
+	}
+}
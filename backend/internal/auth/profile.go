@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Username length limits; the column is VARCHAR(50).
+const (
+	minUsernameLen = 3
+	maxUsernameLen = 50
+)
+
+// validEmail reports whether s is a bare email address.
+func validEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && len(s) <= 255
+}
+
+// UpdateProfile changes the current user's username and/or email. A new
+// email address starts out unverified and is sent a verification link.
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
+		return
+	}
+
+	var req struct {
+		Username *string `json:"username"`
+		Email    *string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	if req.Username == nil && req.Email == nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "username or email is required")
+		return
+	}
+
+	var username, email string
+	if req.Username != nil {
+		username = strings.TrimSpace(*req.Username)
+		if n := len(username); n < minUsernameLen || n > maxUsernameLen {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "username must be between 3 and 50 characters")
+			return
+		}
+	}
+	if req.Email != nil {
+		email = strings.TrimSpace(*req.Email)
+		if !validEmail(email) {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid email address")
+			return
+		}
+	}
+
+	before, err := h.users.GetUserByID(r.Context(), userID)
+	if err != nil || before == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "user not found")
+		return
+	}
+	user, err := h.users.UpdateUser(r.Context(), userID, username, email)
+	if errors.Is(err, models.ErrUserConflict) {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "username or email already in use")
+		return
+	}
+	if err != nil {
+		log.Printf("update profile for %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to update profile")
+		return
+	}
+	if user.Email != before.Email {
+		h.sendVerification(r.Context(), user.ID, user.Email)
+	}
+	writeJSON(w, http.StatusOK, user)
+}
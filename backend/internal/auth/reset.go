@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// ResetTokenTTL is how long a password reset link stays valid.
+const ResetTokenTTL = time.Hour
+
+// resetMailTimeout bounds looking up an account and emailing it a link.
+const resetMailTimeout = 30 * time.Second
+
+// ErrInvalidResetToken means the reset token is unknown, expired or used.
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// Mailer delivers account emails.
+type Mailer interface {
+	SendPasswordReset(ctx context.Context, to, link string) error
+	SendEmailVerification(ctx context.Context, to, link string) error
+}
+
+// ResetStore wraps Redis for single-use password reset tokens.
+type ResetStore struct {
+	rdb *redis.Client
+}
+
+func NewResetStore(rdb *redis.Client) *ResetStore {
+	return &ResetStore{rdb: rdb}
+}
+
+// Create issues a new reset token for userID.
+func (s *ResetStore) Create(ctx context.Context, userID string) (string, error) {
+	return issueToken(ctx, s.rdb, "password_reset:", userID, ResetTokenTTL)
+}
+
+// issueToken stores value under a new random token with the given key
+// prefix and returns the token.
+func issueToken(ctx context.Context, rdb *redis.Client, prefix, value string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	if err := rdb.Set(ctx, prefix+token, value, ttl).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Consume returns the user a token was issued for and deletes it, so each
+// token works only once.
+func (s *ResetStore) Consume(ctx context.Context, token string) (string, error) {
+	userID, err := s.rdb.GetDel(ctx, "password_reset:"+token).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrInvalidResetToken
+	}
+	return userID, err
+}
+
+// ForgotPassword emails a reset link to the account with the given email.
+// It always answers 200 so the response doesn't reveal which emails exist,
+// and looks the account up and sends the email in the background so the
+// response time doesn't either.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "email is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), resetMailTimeout)
+	h.mail.Add(1)
+	go func() {
+		defer h.mail.Done()
+		defer cancel()
+		if user, err := h.users.GetUserByEmail(ctx, req.Email); err == nil && user != nil {
+			h.sendReset(ctx, user.ID, user.Email)
+		}
+	}()
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "if an account exists for that email, a reset link has been sent",
+	})
+}
+
+func (h *Handler) sendReset(ctx context.Context, userID, email string) {
+	token, err := h.opts.Resets.Create(ctx, userID)
+	if err != nil {
+		log.Printf("create reset token for %s: %v", userID, err)
+		return
+	}
+	link := h.opts.ResetURL + "?token=" + url.QueryEscape(token)
+	if err := h.opts.Mailer.SendPasswordReset(ctx, email, link); err != nil {
+		log.Printf("send reset email for %s: %v", userID, err)
+	}
+}
+
+// ResetPassword sets a new password for the account a reset token was
+// issued for and ends all of its sessions.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	if req.Token == "" || req.Password == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "token and password are required")
+		return
+	}
+	// Check the password before consuming the token so a rejected password
+	// doesn't burn the link.
+	if !h.checkPassword(w, req.Password) {
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "internal error")
+		return
+	}
+
+	userID, err := h.opts.Resets.Consume(r.Context(), req.Token)
+	if errors.Is(err, ErrInvalidResetToken) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid or expired reset token")
+		return
+	}
+	if err != nil {
+		log.Printf("consume reset token: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "internal error")
+		return
+	}
+
+	if err := h.users.UpdatePassword(r.Context(), userID, string(hashed)); err != nil {
+		log.Printf("update password for %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to update password")
+		return
+	}
+	// Whoever knew the old password may still be signed in.
+	if err := h.sessions.DeleteAll(r.Context(), userID); err != nil {
+		log.Printf("revoke sessions for %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "password updated, but existing sessions could not be revoked")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "password updated"})
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sentLinks is a Mailer that records the links it was asked to send.
+type sentLinks struct {
+	mu    sync.Mutex
+	links map[string]string // recipient to link
+}
+
+func (m *sentLinks) SendPasswordReset(ctx context.Context, to, link string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.links[to] = link
+	return nil
+}
+
+func (m *sentLinks) SendEmailVerification(ctx context.Context, to, link string) error {
+	return nil
+}
+
+func forgotPassword(h *Handler, email string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ForgotPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/forgot-password", strings.NewReader(`{"email":"`+email+`"}`)))
+	return w
+}
+
+func resetPassword(h *Handler, token, password string) *httptest.ResponseRecorder {
+	body := `{"token":"` + token + `","password":"` + password + `"}`
+	w := httptest.NewRecorder()
+	h.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/reset-password", strings.NewReader(body)))
+	return w
+}
+
+// requestReset asks for a reset link for alice and returns its token.
+func requestReset(t *testing.T, h *Handler, mailer *sentLinks) string {
+	t.Helper()
+	if w := forgotPassword(h, "alice@example.com"); w.Code != http.StatusOK {
+		t.Fatalf("forgot password: got %d %s, want 200", w.Code, w.Body)
+	}
+	h.mail.Wait()
+	link, err := url.Parse(mailer.links["alice@example.com"])
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("reset link %q has no token", mailer.links["alice@example.com"])
+	}
+	return link.Query().Get("token")
+}
+
+func newResetHandler(t *testing.T) (*Handler, *fakeUsers, *SessionStore, *sentLinks, func(time.Duration)) {
+	t.Helper()
+	mr, rdb := newTestRedis(t)
+	users := newFakeUsers(t, "Correct1Horse")
+	sessions := NewSessionStore(rdb, SessionOptions{})
+	mailer := &sentLinks{links: map[string]string{}}
+	h := NewHandler(users, sessions, Options{
+		PasswordPolicy: defaultPolicy,
+		Mailer:         mailer,
+		Resets:         NewResetStore(rdb),
+		ResetURL:       "https://app.example.com/reset",
+	})
+	return h, users, sessions, mailer, mr.FastForward
+}
+
+func TestForgotPassword(t *testing.T) {
+	h, _, _, mailer, _ := newResetHandler(t)
+
+	requestReset(t, h, mailer)
+	if w := forgotPassword(h, "nobody@example.com"); w.Code != http.StatusOK {
+		t.Errorf("unknown email: got %d, want the same 200", w.Code)
+	}
+	h.mail.Wait()
+	if len(mailer.links) != 1 {
+		t.Errorf("sent %v, want only alice's link", mailer.links)
+	}
+}
+
+func TestResetPasswordIsSingleUse(t *testing.T) {
+	h, users, _, mailer, _ := newResetHandler(t)
+	token := requestReset(t, h, mailer)
+
+	if w := resetPassword(h, token, "short"); w.Code != http.StatusBadRequest {
+		t.Errorf("weak password: got %d, want 400", w.Code)
+	}
+	if w := resetPassword(h, token, "Battery9Staple"); w.Code != http.StatusOK {
+		t.Fatalf("reset: got %d %s, want 200", w.Code, w.Body)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(users.byID("user-a").Password), []byte("Battery9Staple")) != nil {
+		t.Error("password was not updated")
+	}
+	if w := resetPassword(h, token, "Another7Horse"); w.Code != http.StatusBadRequest {
+		t.Errorf("reused token: got %d, want 400", w.Code)
+	}
+}
+
+func TestResetPasswordTokenExpires(t *testing.T) {
+	h, users, _, mailer, fastForward := newResetHandler(t)
+	before := users.byID("user-a").Password
+	token := requestReset(t, h, mailer)
+
+	fastForward(ResetTokenTTL + time.Second)
+	if w := resetPassword(h, token, "Battery9Staple"); w.Code != http.StatusBadRequest {
+		t.Errorf("expired token: got %d, want 400", w.Code)
+	}
+	if users.byID("user-a").Password != before {
+		t.Error("an expired token updated the password")
+	}
+}
+
+func TestResetPasswordRevokesSessions(t *testing.T) {
+	h, _, sessions, mailer, _ := newResetHandler(t)
+	ctx := context.Background()
+	sids := make([]string, 2)
+	for i := range sids {
+		sids[i], _ = sessions.Create(ctx, "user-a", SessionMeta{})
+	}
+
+	if w := resetPassword(h, requestReset(t, h, mailer), "Battery9Staple"); w.Code != http.StatusOK {
+		t.Fatalf("reset: got %d %s, want 200", w.Code, w.Body)
+	}
+	for _, sid := range sids {
+		if got, _ := sessions.Get(ctx, sid); got != "" {
+			t.Errorf("session %s still resolves to %q after the reset", sid, got)
+		}
+	}
+}
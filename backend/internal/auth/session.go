@@ -2,6 +2,16 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,36 +19,500 @@ import (
 )
 
 const (
-	SessionTTL    = 24 * time.Hour
+	SessionTTL    = 24 * time.Hour // default session lifetime
 	SessionCookie = "session_id"
+	// SessionTouchInterval is the default minimum gap between TTL refreshes
+	// of the same session.
+	SessionTouchInterval = 5 * time.Minute
 )
 
+// ErrSessionStoreUnavailable means Redis couldn't be reached, as opposed to
+// the session simply not existing.
+var ErrSessionStoreUnavailable = errors.New("session store unavailable")
+
+// Session modes.
+const (
+	SessionModeRedis = "redis" // opaque IDs looked up in Redis
+	SessionModeJWT   = "jwt"   // signed tokens verified without a lookup
+)
+
+// SessionOptions tunes session behaviour.
+type SessionOptions struct {
+	Mode   string // SessionModeRedis (default) or SessionModeJWT
+	Secret string // HS256 key for JWT sessions
+	// StatelessFallback lets redis mode issue JWT sessions while Redis is
+	// unreachable. Requires Secret.
+	StatelessFallback bool
+	// TTL is how long a session lives without activity. Defaults to SessionTTL.
+	TTL time.Duration
+	// TouchInterval throttles Touch. Defaults to SessionTouchInterval.
+	TouchInterval time.Duration
+	// Cookie sets the attributes of the session cookie.
+	Cookie CookieOptions
+}
+
 // SessionStore wraps Redis for session management.
 type SessionStore struct {
-	rdb *redis.Client
+	rdb      *redis.Client
+	mode     string
+	signer   *tokenSigner
+	fallback bool
+	ttl      time.Duration
+	interval time.Duration
+	cookie   CookieOptions
+
+	mu      sync.Mutex
+	touched map[string]time.Time // session ID → last refresh
+}
+
+func NewSessionStore(rdb *redis.Client, opts SessionOptions) *SessionStore {
+	s := &SessionStore{
+		rdb:      rdb,
+		mode:     opts.Mode,
+		fallback: opts.StatelessFallback,
+		ttl:      opts.TTL,
+		interval: opts.TouchInterval,
+		cookie:   opts.Cookie,
+		touched:  make(map[string]time.Time),
+	}
+	if s.mode != SessionModeJWT {
+		s.mode = SessionModeRedis
+	}
+	if s.ttl <= 0 {
+		s.ttl = SessionTTL
+	}
+	if s.interval <= 0 {
+		s.interval = SessionTouchInterval
+	}
+	if opts.Secret != "" {
+		s.signer = &tokenSigner{secret: []byte(opts.Secret)}
+	}
+	return s
+}
+
+// Cookies returns the attributes the session cookie is sent with.
+func (s *SessionStore) Cookies() CookieOptions {
+	return s.cookie
+}
+
+// TTL returns the lifetime of new and refreshed sessions.
+func (s *SessionStore) TTL() time.Duration {
+	return s.ttl
+}
+
+// acceptsJWT reports whether signed tokens are valid sessions.
+func (s *SessionStore) acceptsJWT() bool {
+	return s.signer != nil && (s.mode == SessionModeJWT || s.fallback)
+}
+
+// Available reports whether sessions can be created right now, either in
+// Redis or as signed tokens.
+func (s *SessionStore) Available(ctx context.Context) bool {
+	if s.acceptsJWT() {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return s.rdb.Ping(ctx).Err() == nil
+}
+
+func (s *SessionStore) issueJWT(userID, sid string) string {
+	now := time.Now()
+	return s.signer.sign(tokenClaims{
+		Subject:   userID,
+		ID:        sid,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.ttl).Unix(),
+	})
+}
+
+// SessionMeta describes the client a session was created for.
+type SessionMeta struct {
+	UserAgent string
+	IP        string
+}
+
+// MetaFromRequest extracts the session metadata of r.
+func MetaFromRequest(r *http.Request) SessionMeta {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return SessionMeta{UserAgent: r.UserAgent(), IP: ip}
+}
+
+// Create stores a new session mapping sessionID -> userID, along with meta
+// for the session list.
+func (s *SessionStore) Create(ctx context.Context, userID string, meta SessionMeta) (string, error) {
+	return s.create(ctx, userID, &meta)
 }
 
-func NewSessionStore(rdb *redis.Client) *SessionStore {
-	return &SessionStore{rdb: rdb}
+// CreateGuest stores a new session for a guest. Guest sessions aren't
+// indexed: a guest has no account to list or revoke sessions from, and the
+// index would outlive the session.
+func (s *SessionStore) CreateGuest(ctx context.Context, userID string) (string, error) {
+	return s.create(ctx, userID, nil)
 }
 
-// Create stores a new session mapping sessionID -> userID.
-func (s *SessionStore) Create(ctx context.Context, userID string) (string, error) {
+// create stores a new session, indexing it under meta unless meta is nil.
+func (s *SessionStore) create(ctx context.Context, userID string, meta *SessionMeta) (string, error) {
 	sid := uuid.New().String()
-	err := s.rdb.Set(ctx, "session:"+sid, userID, SessionTTL).Err()
-	return sid, err
+	if s.mode == SessionModeJWT {
+		return s.issueJWT(userID, sid), nil
+	}
+	err := s.rdb.Set(ctx, "session:"+sid, userID, s.ttl).Err()
+	if err == nil {
+		if meta != nil {
+			s.index(ctx, userID, sid, *meta)
+		}
+		return sid, nil
+	}
+	if !s.acceptsJWT() {
+		return "", errors.Join(ErrSessionStoreUnavailable, err)
+	}
+	log.Printf("redis session create failed, issuing stateless session: %v", err)
+	return s.issueJWT(userID, sid), nil
+}
+
+// userSessionsKey holds the IDs of a user's Redis sessions. It has no TTL
+// because Touch extends sessions without knowing their user; entries whose
+// session has expired are pruned on the next login instead.
+func userSessionsKey(userID string) string {
+	return "user_sessions:" + userID
+}
+
+// sessionMetaKey holds the SessionMeta and creation time of a Redis
+// session. It expires together with the session.
+func sessionMetaKey(sid string) string {
+	return "session_meta:" + sid
+}
+
+// index records sid among userID's sessions. Failures only mean the session
+// can't be listed or revoked in bulk, so they are logged rather than
+// returned.
+func (s *SessionStore) index(ctx context.Context, userID, sid string, meta SessionMeta) {
+	pipe := s.rdb.TxPipeline()
+	pipe.SAdd(ctx, userSessionsKey(userID), sid)
+	pipe.HSet(ctx, sessionMetaKey(sid),
+		"created_at", time.Now().UTC().Format(time.RFC3339),
+		"user_agent", meta.UserAgent,
+		"ip", meta.IP,
+	)
+	pipe.Expire(ctx, sessionMetaKey(sid), s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("index session for %s: %v", userID, err)
+		return
+	}
+	// Listing prunes too; this keeps the set bounded for users who never
+	// look at it.
+	if _, err := s.List(ctx, userID); err != nil {
+		log.Printf("prune sessions for %s: %v", userID, err)
+	}
+}
+
+// SessionInfo is an entry of a user's session list. ID is an opaque handle
+// derived from the session ID, which itself is a credential and is never
+// listed.
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	Current   bool      `json:"current"`
+}
+
+// SessionHandle returns the public handle of a session ID.
+func SessionHandle(sid string) string {
+	sum := sha256.Sum256([]byte(sid))
+	return hex.EncodeToString(sum[:8])
+}
+
+// List returns the active Redis sessions of userID, newest first, dropping
+// IDs of sessions that have expired from the per-user set. Signed sessions
+// aren't tracked and don't appear.
+func (s *SessionStore) List(ctx context.Context, userID string) ([]SessionInfo, error) {
+	key := userSessionsKey(userID)
+	sids, err := s.rdb.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	pipe := s.rdb.Pipeline()
+	exists := make([]*redis.IntCmd, len(sids))
+	metas := make([]*redis.MapStringStringCmd, len(sids))
+	for i, sid := range sids {
+		exists[i] = pipe.Exists(ctx, "session:"+sid)
+		metas[i] = pipe.HGetAll(ctx, sessionMetaKey(sid))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	var stale []interface{}
+	infos := make([]SessionInfo, 0, len(sids))
+	for i, sid := range sids {
+		if exists[i].Val() == 0 {
+			stale = append(stale, sid)
+			continue
+		}
+		m := metas[i].Val()
+		created, _ := time.Parse(time.RFC3339, m["created_at"])
+		infos = append(infos, SessionInfo{
+			ID:        SessionHandle(sid),
+			CreatedAt: created,
+			UserAgent: m["user_agent"],
+			IP:        m["ip"],
+		})
+	}
+	if len(stale) > 0 {
+		if err := s.rdb.SRem(ctx, key, stale...).Err(); err != nil {
+			log.Printf("prune sessions for %s: %v", userID, err)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].CreatedAt.After(infos[j].CreatedAt) })
+	return infos, nil
+}
+
+// DeleteByHandle ends the session of userID with the given handle and
+// reports whether one was found.
+func (s *SessionStore) DeleteByHandle(ctx context.Context, userID, handle string) (bool, error) {
+	sids, err := s.rdb.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return false, err
+	}
+	for _, sid := range sids {
+		if SessionHandle(sid) == handle {
+			return true, s.Delete(ctx, sid)
+		}
+	}
+	return false, nil
+}
+
+// sessionsRevokedKey holds the Unix time before which a user's signed
+// sessions are no longer accepted.
+func sessionsRevokedKey(userID string) string {
+	return "sessions_revoked:" + userID
+}
+
+// DeleteAll ends every session of userID. Signed sessions issued before
+// now are rejected from here on.
+func (s *SessionStore) DeleteAll(ctx context.Context, userID string) error {
+	key := userSessionsKey(userID)
+	sids, err := s.rdb.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	keys := []string{key}
+	s.mu.Lock()
+	for _, sid := range sids {
+		keys = append(keys, "session:"+sid, sessionMetaKey(sid))
+		delete(s.touched, sid)
+	}
+	s.mu.Unlock()
+	if err := s.rdb.Del(ctx, keys...).Err(); err != nil {
+		return err
+	}
+	if s.acceptsJWT() {
+		return s.rdb.Set(ctx, sessionsRevokedKey(userID), time.Now().Unix(), s.ttl).Err()
+	}
+	return nil
 }
 
 // Get returns the userID for a session, or "" if not found / expired.
 func (s *SessionStore) Get(ctx context.Context, sessionID string) (string, error) {
+	if looksLikeJWT(sessionID) {
+		return s.getJWT(ctx, sessionID)
+	}
 	val, err := s.rdb.Get(ctx, "session:"+sessionID).Result()
 	if err == redis.Nil {
 		return "", nil
 	}
-	return val, err
+	if err != nil {
+		return "", errors.Join(ErrSessionStoreUnavailable, err)
+	}
+	return val, nil
 }
 
-// Delete removes a session.
+// Touch resets the TTL of a Redis session so active users stay logged in.
+// Refreshes of the same session are throttled to one per touch interval;
+// it reports whether the TTL was actually extended. Signed sessions can't
+// be extended in place and are never touched.
+func (s *SessionStore) Touch(ctx context.Context, sessionID string) (bool, error) {
+	if looksLikeJWT(sessionID) {
+		return false, nil
+	}
+	now := time.Now()
+	s.mu.Lock()
+	if last, ok := s.touched[sessionID]; ok && now.Sub(last) < s.interval {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.touched[sessionID] = now
+	s.pruneTouched(now)
+	s.mu.Unlock()
+
+	pipe := s.rdb.Pipeline()
+	expire := pipe.Expire(ctx, "session:"+sessionID, s.ttl)
+	pipe.Expire(ctx, sessionMetaKey(sessionID), s.ttl)
+	_, err := pipe.Exec(ctx)
+	ok := expire.Val()
+	if err != nil {
+		s.mu.Lock()
+		delete(s.touched, sessionID)
+		s.mu.Unlock()
+		return false, errors.Join(ErrSessionStoreUnavailable, err)
+	}
+	return ok, nil
+}
+
+// pruneTouched drops throttle entries that have aged out once the map gets
+// large, so it doesn't grow with every session ever seen. Callers hold mu.
+func (s *SessionStore) pruneTouched(now time.Time) {
+	if len(s.touched) < 1024 {
+		return
+	}
+	for id, last := range s.touched {
+		if now.Sub(last) >= s.interval {
+			delete(s.touched, id)
+		}
+	}
+}
+
+// getJWT verifies a signed session and checks it hasn't been revoked. The
+// revocation check is best-effort: if Redis is unreachable the token is
+// still accepted, which is the point of stateless sessions.
+func (s *SessionStore) getJWT(ctx context.Context, token string) (string, error) {
+	if !s.acceptsJWT() {
+		return "", nil
+	}
+	c, err := s.signer.verify(token, time.Now())
+	if err != nil {
+		return "", nil
+	}
+	pipe := s.rdb.Pipeline()
+	revoked := pipe.Exists(ctx, revokedKey(c.ID))
+	cutoff := pipe.Get(ctx, sessionsRevokedKey(c.Subject))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("session revocation check failed: %v", err)
+		return c.Subject, nil
+	}
+	if revoked.Val() > 0 {
+		return "", nil
+	}
+	// Tokens issued in the same second as a bulk revocation survive it, so
+	// the session created right after one stays valid.
+	if t, err := cutoff.Int64(); err == nil && c.IssuedAt < t {
+		return "", nil
+	}
+	return c.Subject, nil
+}
+
+// revokedKey marks a revoked JWT session. Each entry expires with the token
+// it revokes, so the deny-list stays small.
+func revokedKey(jti string) string {
+	return "revoked_session:" + jti
+}
+
+// Delete removes a session. Signed sessions are added to the revocation
+// list until they would have expired anyway.
 func (s *SessionStore) Delete(ctx context.Context, sessionID string) error {
-	return s.rdb.Del(ctx, "session:"+sessionID).Err()
+	if !looksLikeJWT(sessionID) {
+		s.mu.Lock()
+		delete(s.touched, sessionID)
+		s.mu.Unlock()
+		userID, err := s.rdb.GetDel(ctx, "session:"+sessionID).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		pipe := s.rdb.Pipeline()
+		pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+		pipe.Del(ctx, sessionMetaKey(sessionID))
+		_, err = pipe.Exec(ctx)
+		return err
+	}
+	if !s.acceptsJWT() {
+		return nil
+	}
+	c, err := s.signer.verify(sessionID, time.Now())
+	if err != nil {
+		return nil // already invalid
+	}
+	ttl := time.Until(time.Unix(c.ExpiresAt, 0))
+	return s.rdb.Set(ctx, revokedKey(c.ID), "1", ttl).Err()
+}
+
+// ValidID reports whether sessionID has a format this store issues.
+func (s *SessionStore) ValidID(sessionID string) bool {
+	if looksLikeJWT(sessionID) {
+		return s.acceptsJWT()
+	}
+	if s.mode == SessionModeJWT {
+		return false
+	}
+	_, err := uuid.Parse(sessionID)
+	return err == nil
+}
+
+// CookieOptions are the attributes of the session cookie. The zero value
+// suits local development over plain HTTP.
+type CookieOptions struct {
+	// Secure restricts the cookie to HTTPS.
+	Secure bool
+	// SameSite defaults to Lax. None, needed when the frontend is on
+	// another site, requires Secure.
+	SameSite http.SameSite
+}
+
+// ParseSameSite parses a SameSite setting: lax, strict or none.
+func ParseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("invalid SameSite %q: want lax, strict or none", s)
+}
+
+// Validate rejects attribute combinations browsers refuse.
+func (c CookieOptions) Validate() error {
+	if c.SameSite == http.SameSiteNoneMode && !c.Secure {
+		return errors.New("SameSite=None cookies must be Secure")
+	}
+	return nil
+}
+
+// cookie returns the session cookie with these attributes.
+func (c CookieOptions) cookie(value string, maxAge int) *http.Cookie {
+	sameSite := c.SameSite
+	if sameSite == 0 || sameSite == http.SameSiteDefaultMode {
+		sameSite = http.SameSiteLaxMode
+	}
+	return &http.Cookie{
+		Name:     SessionCookie,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   c.Secure,
+		SameSite: sameSite,
+		MaxAge:   maxAge,
+	}
+}
+
+// SetSession sends the session cookie with the given lifetime.
+func (c CookieOptions) SetSession(w http.ResponseWriter, sessionID string, ttl time.Duration) {
+	http.SetCookie(w, c.cookie(sessionID, int(ttl/time.Second)))
+}
+
+// ClearSession instructs the client to drop its session and CSRF cookies.
+// The attributes match SetSession's, or browsers may ignore it.
+func (c CookieOptions) ClearSession(w http.ResponseWriter) {
+	http.SetCookie(w, c.cookie("", -1))
+	csrf := c.cookie("", -1)
+	csrf.Name, csrf.HttpOnly = CSRFCookie, false
+	http.SetCookie(w, csrf)
 }
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis returns a client for a fresh in-memory Redis.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+// downRedis returns a client for a Redis server that has gone away.
+func downRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	mr, rdb := newTestRedis(t)
+	mr.Close()
+	return rdb
+}
+
+func TestValidID(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	plain := NewSessionStore(rdb, SessionOptions{})
+	withFallback := NewSessionStore(rdb, SessionOptions{Secret: "secret", StatelessFallback: true})
+
+	for id, want := range map[string]bool{
+		uuid.New().String(): true,
+		"":                  false,
+		"not-a-session":     false,
+		"../../etc/passwd":  false,
+		"6f1c1a48-3b0e-4c2f-9d6b-1a2b3c4d5e6f-extra": false,
+	} {
+		if got := plain.ValidID(id); got != want {
+			t.Errorf("ValidID(%q) = %v, want %v", id, got, want)
+		}
+	}
+	if plain.ValidID("a.b.c") || !withFallback.ValidID("a.b.c") {
+		t.Error("JWT-shaped IDs should only be valid with the stateless fallback")
+	}
+}
+
+func TestSessionStoreDown(t *testing.T) {
+	ctx := context.Background()
+	s := NewSessionStore(downRedis(t), SessionOptions{})
+
+	if s.Available(ctx) {
+		t.Error("Available with Redis down")
+	}
+	if _, err := s.Create(ctx, "user-a", SessionMeta{}); !errors.Is(err, ErrSessionStoreUnavailable) {
+		t.Errorf("Create: got %v, want ErrSessionStoreUnavailable", err)
+	}
+	if _, err := s.Get(ctx, uuid.New().String()); !errors.Is(err, ErrSessionStoreUnavailable) {
+		t.Errorf("Get: got %v, want ErrSessionStoreUnavailable", err)
+	}
+}
+
+func TestStatelessFallback(t *testing.T) {
+	ctx := context.Background()
+	s := NewSessionStore(downRedis(t), SessionOptions{Secret: "secret", StatelessFallback: true})
+
+	if !s.Available(ctx) {
+		t.Error("not Available with the stateless fallback")
+	}
+	sid, err := s.Create(ctx, "user-a", SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !looksLikeJWT(sid) {
+		t.Fatalf("Create returned %q, want a stateless token", sid)
+	}
+	if got, err := s.Get(ctx, sid); err != nil || got != "user-a" {
+		t.Errorf("Get = %q, %v; want user-a", got, err)
+	}
+
+	forged := NewSessionStore(downRedis(t), SessionOptions{Secret: "other", StatelessFallback: true})
+	if got, _ := forged.Get(ctx, sid); got != "" {
+		t.Errorf("token accepted under another secret as %q", got)
+	}
+	signer := &tokenSigner{secret: []byte("secret")}
+	expired := signer.sign(tokenClaims{Subject: "user-a", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if got, _ := s.Get(ctx, expired); got != "" {
+		t.Errorf("expired token accepted as %q", got)
+	}
+}
+
+func TestJWTSessions(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	s := NewSessionStore(rdb, SessionOptions{Mode: SessionModeJWT, Secret: "secret"})
+
+	token, err := s.Create(ctx, "user-a", SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !looksLikeJWT(token) || !s.ValidID(token) {
+		t.Fatalf("Create returned %q, want a JWT", token)
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Errorf("JWT session stored keys %v in Redis", keys)
+	}
+	if got, err := s.Get(ctx, token); err != nil || got != "user-a" {
+		t.Errorf("Get = %q, %v; want user-a", got, err)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-b","exp":9999999999}`)) + "." + parts[2]
+	if got, _ := s.Get(ctx, tampered); got != "" {
+		t.Errorf("tampered token accepted as %q", got)
+	}
+	if s.ValidID(uuid.New().String()) {
+		t.Error("JWT mode accepts opaque session IDs")
+	}
+
+	if err := s.Delete(ctx, token); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got, _ := s.Get(ctx, token); got != "" {
+		t.Errorf("revoked token accepted as %q", got)
+	}
+	c, _ := s.signer.verify(token, time.Now())
+	if ttl := mr.TTL(revokedKey(c.ID)); ttl <= 0 || ttl > SessionTTL {
+		t.Errorf("revocation TTL = %v, want until the token expires", ttl)
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	signer := &tokenSigner{secret: []byte("secret")}
+	now := time.Now()
+	token := signer.sign(tokenClaims{Subject: "user-a", ID: "jti", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()})
+
+	if _, err := signer.verify(token, now.Add(59*time.Minute)); err != nil {
+		t.Errorf("verify before expiry: %v", err)
+	}
+	if _, err := signer.verify(token, now.Add(time.Hour)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("verify at expiry: got %v, want ErrInvalidToken", err)
+	}
+	for _, bad := range []string{"", "a.b.c", token + "x", strings.Replace(token, ".", "..", 1)} {
+		if _, err := signer.verify(bad, now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("verify(%q): got %v, want ErrInvalidToken", bad, err)
+		}
+	}
+}
+
+func TestTouch(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	s := NewSessionStore(rdb, SessionOptions{TTL: time.Hour, TouchInterval: time.Minute})
+
+	sid, err := s.Create(ctx, "user-a", SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(30 * time.Minute)
+	if touched, err := s.Touch(ctx, sid); err != nil || !touched {
+		t.Fatalf("Touch = %v, %v; want the TTL extended", touched, err)
+	}
+	if ttl := mr.TTL("session:" + sid); ttl != time.Hour {
+		t.Errorf("TTL after Touch = %v, want 1h", ttl)
+	}
+
+	mr.FastForward(10 * time.Minute)
+	if touched, _ := s.Touch(ctx, sid); touched {
+		t.Error("second Touch within the interval was not throttled")
+	}
+	if ttl := mr.TTL("session:" + sid); ttl != 50*time.Minute {
+		t.Errorf("TTL after a throttled Touch = %v, want 50m", ttl)
+	}
+
+	if touched, _ := s.Touch(ctx, uuid.New().String()); touched {
+		t.Error("Touch extended a session that doesn't exist")
+	}
+	if touched, _ := s.Touch(ctx, "a.b.c"); touched {
+		t.Error("Touch extended a signed session")
+	}
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// currentHandle returns the handle of the session the request came in on,
+// or "" if it wasn't authenticated by a session cookie.
+func currentHandle(r *http.Request) string {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return ""
+	}
+	return SessionHandle(cookie.Value)
+}
+
+// ListSessions returns the current user's active sessions.
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
+		return
+	}
+	sessions, err := h.sessions.List(r.Context(), userID)
+	if err != nil {
+		log.Printf("list sessions for %s: %v", userID, err)
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "session store unavailable")
+		return
+	}
+	current := currentHandle(r)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// DeleteSession logs out one of the current user's sessions by its handle.
+func (h *Handler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
+		return
+	}
+	handle := chi.URLParam(r, "sid")
+	found, err := h.sessions.DeleteByHandle(r.Context(), userID, handle)
+	if err != nil {
+		log.Printf("delete session for %s: %v", userID, err)
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "session store unavailable")
+		return
+	}
+	if !found {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "session not found")
+		return
+	}
+	if handle == currentHandle(r) {
+		h.sessions.Cookies().ClearSession(w)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "session revoked"})
+}
+
+// RevokeAllSessions logs the current user out everywhere, including the
+// session making the request.
+func (h *Handler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
+		return
+	}
+	if err := h.sessions.DeleteAll(r.Context(), userID); err != nil {
+		log.Printf("revoke sessions for %s: %v", userID, err)
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "session store unavailable")
+		return
+	}
+	h.sessions.Cookies().ClearSession(w)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "all sessions revoked"})
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// VerifyTokenTTL is how long an email verification link stays valid.
+const VerifyTokenTTL = 24 * time.Hour
+
+// ErrInvalidVerifyToken means the verification token is unknown, expired
+// or used.
+var ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+
+// VerifyStore wraps Redis for single-use email verification tokens. Each
+// token is bound to the address it was sent to.
+type VerifyStore struct {
+	rdb *redis.Client
+}
+
+func NewVerifyStore(rdb *redis.Client) *VerifyStore {
+	return &VerifyStore{rdb: rdb}
+}
+
+// Create issues a new verification token for userID and email.
+func (s *VerifyStore) Create(ctx context.Context, userID, email string) (string, error) {
+	return issueToken(ctx, s.rdb, "email_verify:", userID+"\n"+email, VerifyTokenTTL)
+}
+
+// Consume returns the user and address a token was issued for and deletes
+// it.
+func (s *VerifyStore) Consume(ctx context.Context, token string) (userID, email string, err error) {
+	v, err := s.rdb.GetDel(ctx, "email_verify:"+token).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", "", ErrInvalidVerifyToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+	userID, email, _ = strings.Cut(v, "\n")
+	return userID, email, nil
+}
+
+// sendVerification emails a verification link for email to the user.
+func (h *Handler) sendVerification(ctx context.Context, userID, email string) {
+	if h.opts.Verifications == nil {
+		return
+	}
+	token, err := h.opts.Verifications.Create(ctx, userID, email)
+	if err != nil {
+		log.Printf("create verification token for %s: %v", userID, err)
+		return
+	}
+	link := h.opts.VerifyURL + "?token=" + url.QueryEscape(token)
+	if err := h.opts.Mailer.SendEmailVerification(ctx, email, link); err != nil {
+		log.Printf("send verification email for %s: %v", userID, err)
+	}
+}
+
+// VerifyEmail marks the address a verification token was sent to as
+// verified.
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "token is required")
+		return
+	}
+	if h.opts.Verifications == nil {
+		apierror.Write(w, http.StatusNotImplemented, apierror.NotImplemented, "email verification is not enabled")
+		return
+	}
+
+	userID, email, err := h.opts.Verifications.Consume(r.Context(), req.Token)
+	if errors.Is(err, ErrInvalidVerifyToken) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid or expired verification token")
+		return
+	}
+	if err != nil {
+		log.Printf("consume verification token: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "internal error")
+		return
+	}
+	if err := h.users.SetEmailVerified(r.Context(), userID, email); err != nil {
+		// The address changed again after this link was sent.
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid or expired verification token")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "email verified"})
+}
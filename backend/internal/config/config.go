@@ -1,26 +1,153 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Config holds all service configuration loaded from environment variables.
 type Config struct {
-	Port           string
-	PostgresDSN    string
-	MongoURI       string
-	MongoDB        string
-	RedisAddr      string
-	RedisPassword  string
-	MinioEndpoint  string
-	MinioAccessKey string
-	MinioSecretKey string
-	MinioBucket    string
-	MinioUseSSL    bool
+	Port            string
+	PostgresDSN     string
+	MongoURI        string
+	MongoDB         string
+	RedisAddr       string
+	RedisPassword   string
+	MinioEndpoint   string
+	MinioAccessKey  string
+	MinioSecretKey  string
+	MinioBucket     string
+	MinioUseSSL     bool
+	StorageBackend  string
+	LocalStorageDir string
 	AIServiceURL    string
 	LaTeXServiceURL string
 	SessionSecret   string
+
+	S3Endpoint  string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	S3Bucket    string
+
+	UploadConcurrency int
+	SearchConcurrency int
+	MaxSources        int
+
+	AIConcurrency  int
+	AIQueueTimeout time.Duration
+
+	AllowedModels []string
+	ModelPricing  []string
+
+	SourceLimits []string
+
+	QueriesTimeout time.Duration
+	SearchTimeout  time.Duration
+	ReportTimeout  time.Duration
+	CompileTimeout time.Duration
+
+	FallbackModel         string
+	ModelTimeoutThreshold int
+	ModelTimeoutWindow    time.Duration
+
+	HTTPRetryAttempts int
+	HTTPRetryBackoff  time.Duration
+
+	CredibleDomains []string
+	AllowedDomains  []string
+	BlockedDomains  []string
+
+	DedupMode      string
+	DedupThreshold float64
+
+	AdminUserIDs          []string
+	DebugCaptureRetention time.Duration
+	DebugCaptureMaxWindow time.Duration
+
+	PasswordMinLength        int
+	PasswordRequireMixedCase bool
+	PasswordRequireDigit     bool
+	PasswordRequireSymbol    bool
+	PasswordRejectCommon     bool
+
+	SessionMode              string
+	SessionStatelessFallback bool
+	SessionTTL               time.Duration
+	SessionTouchInterval     time.Duration
+
+	Env            string
+	CookieSecure   bool
+	CookieSameSite string
+
+	PublicURL string
+	ShareTTL  time.Duration
+
+	PresignExpiry time.Duration
+
+	CallbackSecret string
+
+	QueryCacheTTL  time.Duration
+	SearchCacheTTL time.Duration
+
+	CORSOrigins []string
+
+	PasswordResetURL string
+	EmailVerifyURL   string
+	SMTPAddr         string
+	SMTPFrom         string
+	SMTPUsername     string
+	SMTPPassword     string
+
+	DocLockTTL     time.Duration
+	IdempotencyTTL time.Duration
+
+	ResearchRateLimit  int
+	ResearchRateWindow time.Duration
+	CompileRateLimit   int
+	CompileRateWindow  time.Duration
+
+	// GuestAccess lets visitors try research without an account. Guest
+	// user IDs start with GuestPrefix, so their files do too, and their
+	// documents and files are deleted after GuestRetention.
+	GuestAccess        bool
+	GuestPrefix        string
+	GuestRetention     time.Duration
+	GuestSweepInterval time.Duration
+
+	SubscriptionPollInterval time.Duration
+
+	TrashRetention     time.Duration
+	TrashSweepInterval time.Duration
+
+	MaxBodyBytes     int64
+	MaxAuthBodyBytes int64
+
+	MaxLatexBytes int
+
+	MonthlyQuota int
+
+	LinkCheckTimeout     time.Duration
+	LinkCheckConcurrency int
+
+	CreateRequestTimeout time.Duration
+	AuthRequestTimeout   time.Duration
+
+	ListTimeout  time.Duration
+	ListCacheTTL time.Duration
+
+	CompressMinBytes int
+	CompressTypes    []string
+
+	ShutdownTimeout   time.Duration
+	JobGracePeriod    time.Duration
+	ShutdownEventPath string
 }
 
 func Load() *Config {
+	env := getenv("ENV", "development")
 	return &Config{
 		Port:            getenv("PORT", "8080"),
 		PostgresDSN:     getenv("POSTGRES_DSN", ""),
@@ -33,9 +160,127 @@ func Load() *Config {
 		MinioSecretKey:  getenv("MINIO_SECRET_KEY", ""),
 		MinioBucket:     getenv("MINIO_BUCKET", "research-pdfs"),
 		MinioUseSSL:     getenv("MINIO_USE_SSL", "false") == "true",
+		StorageBackend:  getenv("STORAGE_BACKEND", "minio"),
+		LocalStorageDir: getenv("LOCAL_STORAGE_DIR", "./data"),
 		AIServiceURL:    getenv("AI_SERVICE_URL", "http://ai-service:8000"),
 		LaTeXServiceURL: getenv("LATEX_SERVICE_URL", "http://latex-service:8001"),
 		SessionSecret:   getenv("SESSION_SECRET", ""),
+
+		S3Endpoint:  getenv("S3_ENDPOINT", "s3.amazonaws.com"),
+		S3Region:    getenv("S3_REGION", ""),
+		S3AccessKey: getenv("S3_ACCESS_KEY", ""),
+		S3SecretKey: getenv("S3_SECRET_KEY", ""),
+		S3Bucket:    getenv("S3_BUCKET", "research-pdfs"),
+
+		UploadConcurrency: getenvInt("UPLOAD_CONCURRENCY", 4),
+		SearchConcurrency: getenvInt("SEARCH_CONCURRENCY", 4),
+		MaxSources:        getenvInt("MAX_SOURCES", 50),
+
+		AIConcurrency:  getenvInt("AI_CONCURRENCY", 8),
+		AIQueueTimeout: getenvDuration("AI_QUEUE_TIMEOUT", 30*time.Second),
+
+		AllowedModels: getenvList("ALLOWED_MODELS", nil),
+		ModelPricing:  getenvList("MODEL_PRICING", nil),
+
+		SourceLimits: getenvList("SOURCE_LIMITS", nil),
+
+		QueriesTimeout: getenvDuration("QUERIES_TIMEOUT", time.Minute),
+		SearchTimeout:  getenvDuration("SEARCH_TIMEOUT", 2*time.Minute),
+		ReportTimeout:  getenvDuration("REPORT_TIMEOUT", 5*time.Minute),
+		CompileTimeout: getenvDuration("COMPILE_TIMEOUT", 2*time.Minute),
+
+		FallbackModel:         getenv("FALLBACK_MODEL", ""),
+		ModelTimeoutThreshold: getenvInt("MODEL_TIMEOUT_THRESHOLD", 3),
+		ModelTimeoutWindow:    getenvDuration("MODEL_TIMEOUT_WINDOW", 10*time.Minute),
+
+		HTTPRetryAttempts: getenvInt("HTTP_RETRY_ATTEMPTS", 3),
+		HTTPRetryBackoff:  getenvDuration("HTTP_RETRY_BACKOFF", 500*time.Millisecond),
+
+		CredibleDomains: getenvList("CREDIBLE_DOMAINS", nil),
+		AllowedDomains:  getenvList("ALLOWED_DOMAINS", nil),
+		BlockedDomains:  getenvList("BLOCKED_DOMAINS", nil),
+
+		DedupMode:      getenv("DEDUP_MODE", "url"),
+		DedupThreshold: getenvFloat("DEDUP_THRESHOLD", 0.85),
+
+		AdminUserIDs:          getenvList("ADMIN_USER_IDS", nil),
+		DebugCaptureRetention: getenvDuration("DEBUG_CAPTURE_RETENTION", 72*time.Hour),
+		DebugCaptureMaxWindow: getenvDuration("DEBUG_CAPTURE_MAX_WINDOW", 24*time.Hour),
+
+		PasswordMinLength:        getenvInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireMixedCase: getenv("PASSWORD_REQUIRE_MIXED_CASE", "true") == "true",
+		PasswordRequireDigit:     getenv("PASSWORD_REQUIRE_DIGIT", "true") == "true",
+		PasswordRequireSymbol:    getenv("PASSWORD_REQUIRE_SYMBOL", "false") == "true",
+		PasswordRejectCommon:     getenv("PASSWORD_REJECT_COMMON", "true") == "true",
+
+		SessionMode:              getenv("SESSION_MODE", "redis"),
+		SessionStatelessFallback: getenv("SESSION_STATELESS_FALLBACK", "false") == "true",
+		SessionTTL:               getenvDuration("SESSION_TTL", 24*time.Hour),
+		SessionTouchInterval:     getenvDuration("SESSION_TOUCH_INTERVAL", 5*time.Minute),
+
+		Env:            env,
+		CookieSecure:   getenv("COOKIE_SECURE", strconv.FormatBool(env == "production")) == "true",
+		CookieSameSite: getenv("COOKIE_SAMESITE", "lax"),
+
+		PublicURL: getenv("PUBLIC_URL", ""),
+		ShareTTL:  getenvDuration("SHARE_TTL", 7*24*time.Hour),
+
+		PresignExpiry: getenvDuration("PRESIGN_EXPIRY", 15*time.Minute),
+
+		CallbackSecret: getenv("CALLBACK_SECRET", ""),
+
+		QueryCacheTTL:  getenvDuration("QUERY_CACHE_TTL", 24*time.Hour),
+		SearchCacheTTL: getenvDuration("SEARCH_CACHE_TTL", 6*time.Hour),
+
+		CORSOrigins: getenvList("CORS_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000"}),
+
+		PasswordResetURL: getenv("PASSWORD_RESET_URL", "http://localhost:5173/reset-password"),
+		EmailVerifyURL:   getenv("EMAIL_VERIFY_URL", "http://localhost:5173/verify-email"),
+		SMTPAddr:         getenv("SMTP_ADDR", ""),
+		SMTPFrom:         getenv("SMTP_FROM", "no-reply@localhost"),
+		SMTPUsername:     getenv("SMTP_USERNAME", ""),
+		SMTPPassword:     getenv("SMTP_PASSWORD", ""),
+
+		DocLockTTL:     getenvPositiveDuration("DOC_LOCK_TTL", time.Minute),
+		IdempotencyTTL: getenvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		ResearchRateLimit:  getenvInt("RESEARCH_RATE_LIMIT", 5),
+		ResearchRateWindow: getenvDuration("RESEARCH_RATE_WINDOW", time.Minute),
+		CompileRateLimit:   getenvInt("COMPILE_RATE_LIMIT", 20),
+		CompileRateWindow:  getenvDuration("COMPILE_RATE_WINDOW", time.Minute),
+
+		GuestAccess:        getenv("GUEST_ACCESS", "false") == "true",
+		GuestPrefix:        getenv("GUEST_PREFIX", "guest/"),
+		GuestRetention:     getenvPositiveDuration("GUEST_RETENTION", 24*time.Hour),
+		GuestSweepInterval: getenvPositiveDuration("GUEST_SWEEP_INTERVAL", time.Hour),
+
+		SubscriptionPollInterval: getenvPositiveDuration("SUBSCRIPTION_POLL_INTERVAL", 5*time.Minute),
+
+		TrashRetention:     getenvPositiveDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashSweepInterval: getenvPositiveDuration("TRASH_SWEEP_INTERVAL", time.Hour),
+
+		MaxBodyBytes:     int64(getenvInt("MAX_BODY_BYTES", 8<<20)),
+		MaxAuthBodyBytes: int64(getenvInt("MAX_AUTH_BODY_BYTES", 64<<10)),
+
+		MaxLatexBytes: getenvInt("MAX_LATEX_BYTES", 1<<20),
+
+		MonthlyQuota: getenvInt("MONTHLY_QUOTA", 0),
+
+		LinkCheckTimeout:     getenvDuration("LINK_CHECK_TIMEOUT", 5*time.Second),
+		LinkCheckConcurrency: getenvInt("LINK_CHECK_CONCURRENCY", 8),
+
+		CreateRequestTimeout: getenvDuration("CREATE_REQUEST_TIMEOUT", 2*time.Minute),
+		AuthRequestTimeout:   getenvDuration("AUTH_REQUEST_TIMEOUT", 10*time.Second),
+
+		ListTimeout:  getenvDuration("LIST_TIMEOUT", 5*time.Second),
+		ListCacheTTL: getenvDuration("LIST_CACHE_TTL", 5*time.Minute),
+
+		CompressMinBytes: getenvInt("COMPRESS_MIN_BYTES", 1024),
+		CompressTypes:    getenvList("COMPRESS_TYPES", nil),
+
+		ShutdownTimeout:   getenvDuration("SHUTDOWN_TIMEOUT", 10*time.Second),
+		JobGracePeriod:    getenvDuration("JOB_GRACE_PERIOD", 2*time.Minute),
+		ShutdownEventPath: getenv("SHUTDOWN_EVENT_PATH", ""),
 	}
 }
 
@@ -45,3 +290,48 @@ func getenv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getenvInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func getenvFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func getenvDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return d
+	}
+	return fallback
+}
+
+// getenvPositiveDuration is getenvDuration for intervals and TTLs, which
+// must be positive: zero and negative values fall back too.
+func getenvPositiveDuration(key string, fallback time.Duration) time.Duration {
+	if d := getenvDuration(key, fallback); d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// getenvList splits a comma-separated value, trimming whitespace and
+// dropping empty entries.
+func getenvList(key string, fallback []string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	if len(out) == 0 {
+		return fallback
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCORSOrigins(t *testing.T) {
+	defaults := []string{"http://localhost:5173", "http://localhost:3000"}
+	tests := []struct {
+		name, env string
+		want      []string
+	}{
+		{"unset", "", defaults},
+		{"single", "https://app.example.com", []string{"https://app.example.com"}},
+		{"whitespace", " https://a.example.com ,https://b.example.com,\thttps://c.example.com ", []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"}},
+		{"empty entries", "https://a.example.com,, ,", []string{"https://a.example.com"}},
+		{"only separators", " , ,", defaults},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ORIGINS", tt.env)
+			if got := Load().CORSOrigins; !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookieSecure(t *testing.T) {
+	tests := []struct {
+		name, env, secure string
+		want              bool
+	}{
+		{"development", "development", "", false},
+		{"production", "production", "", true},
+		{"forced on in development", "development", "true", true},
+		{"forced off in production", "production", "false", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENV", tt.env)
+			t.Setenv("COOKIE_SECURE", tt.secure)
+			if got := Load().CookieSecure; got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositiveDurations(t *testing.T) {
+	tests := []struct {
+		name, env string
+		want      time.Duration
+	}{
+		{"unset", "", 5 * time.Minute},
+		{"set", "30s", 30 * time.Second},
+		{"zero", "0", 5 * time.Minute},
+		{"negative", "-1m", 5 * time.Minute},
+		{"invalid", "soon", 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SUBSCRIPTION_POLL_INTERVAL", tt.env)
+			if got := Load().SubscriptionPollInterval; got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuestSweepDurations(t *testing.T) {
+	t.Setenv("GUEST_RETENTION", "0")
+	t.Setenv("GUEST_SWEEP_INTERVAL", "-1h")
+	cfg := Load()
+	if cfg.GuestRetention != 24*time.Hour || cfg.GuestSweepInterval != time.Hour {
+		t.Errorf("got retention %v and interval %v, want the defaults", cfg.GuestRetention, cfg.GuestSweepInterval)
+	}
+}
+
+func TestTrashSweepDurations(t *testing.T) {
+	t.Setenv("TRASH_RETENTION", "-24h")
+	t.Setenv("TRASH_SWEEP_INTERVAL", "0s")
+	cfg := Load()
+	if cfg.TrashRetention != 30*24*time.Hour || cfg.TrashSweepInterval != time.Hour {
+		t.Errorf("got retention %v and interval %v, want the defaults", cfg.TrashRetention, cfg.TrashSweepInterval)
+	}
+}
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/version"
+)
+
+// Check pings one dependency.
+type Check struct {
+	Name string
+	Ping func(ctx context.Context) error
+}
+
+// Ready runs every check in parallel, each bounded by timeout, and answers
+// 200 with per-dependency status when all pass or 503 when any fails. The
+// build info is included to tell which build is answering. Failures are
+// only reported as "unavailable"; their errors can name hosts and
+// credentials, so they go to the log instead.
+func Ready(timeout time.Duration, checks ...Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		results := make(map[string]string, len(checks))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		healthy := true
+		for _, c := range checks {
+			wg.Add(1)
+			go func(c Check) {
+				defer wg.Done()
+				status := "ok"
+				if err := c.Ping(ctx); err != nil {
+					log.Printf("health check %s: %v", c.Name, err)
+					status = "unavailable"
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				results[c.Name] = status
+				if status != "ok" {
+					healthy = false
+				}
+			}(c)
+		}
+		wg.Wait()
+
+		code, overall := http.StatusOK, "ok"
+		if !healthy {
+			code, overall = http.StatusServiceUnavailable, "unavailable"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":       overall,
+			"dependencies": results,
+			"build":        version.Get(),
+		})
+	}
+}
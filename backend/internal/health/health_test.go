@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	ok := Check{Name: "redis", Ping: func(ctx context.Context) error { return nil }}
+	down := Check{Name: "postgres", Ping: func(ctx context.Context) error {
+		return errors.New("dial tcp db.internal:5432: password authentication failed for user admin")
+	}}
+
+	tests := []struct {
+		name   string
+		checks []Check
+		code   int
+		want   map[string]string
+	}{
+		{"healthy", []Check{ok}, http.StatusOK, map[string]string{"redis": "ok"}},
+		{"failing", []Check{ok, down}, http.StatusServiceUnavailable, map[string]string{"redis": "ok", "postgres": "unavailable"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Ready(time.Second, tt.checks...)(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			if w.Code != tt.code {
+				t.Errorf("got %d, want %d", w.Code, tt.code)
+			}
+			if strings.Contains(w.Body.String(), "db.internal") {
+				t.Errorf("response leaks the dependency error: %s", w.Body)
+			}
+			var body struct {
+				Dependencies map[string]string `json:"dependencies"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.want {
+				if got := body.Dependencies[name]; got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
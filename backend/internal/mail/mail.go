@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// LogMailer writes outgoing messages to the log instead of sending them.
+// It is meant for development, where no SMTP server is configured.
+type LogMailer struct{}
+
+func (LogMailer) SendPasswordReset(_ context.Context, to, link string) error {
+	log.Printf("password reset for %s: %s", to, link)
+	return nil
+}
+
+func (LogMailer) SendEmailVerification(_ context.Context, to, link string) error {
+	log.Printf("email verification for %s: %s", to, link)
+	return nil
+}
+
+func (LogMailer) Send(_ context.Context, to, subject, body string) error {
+	log.Printf("email to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+// SMTPMailer sends messages through an SMTP server using PLAIN auth.
+type SMTPMailer struct {
+	addr     string
+	from     string
+	username string
+	password string
+}
+
+func NewSMTPMailer(addr, from, username, password string) *SMTPMailer {
+	return &SMTPMailer{addr: addr, from: from, username: username, password: password}
+}
+
+// SendPasswordReset emails a password reset link to the given address.
+func (m *SMTPMailer) SendPasswordReset(_ context.Context, to, link string) error {
+	body := "You asked to reset your Research AI Agent password.\r\n\r\n" +
+		"Open this link within the next hour to choose a new password:\r\n" +
+		link + "\r\n\r\n" +
+		"If you didn't ask for this, you can ignore this email.\r\n"
+	return m.send(to, "Reset your password", body)
+}
+
+// SendEmailVerification emails a link confirming the given address.
+func (m *SMTPMailer) SendEmailVerification(_ context.Context, to, link string) error {
+	body := "Please confirm this email address for your Research AI Agent account.\r\n\r\n" +
+		"Open this link within the next 24 hours:\r\n" +
+		link + "\r\n\r\n" +
+		"If you didn't change your email, you can ignore this email.\r\n"
+	return m.send(to, "Confirm your email address", body)
+}
+
+// Send emails a plain-text message.
+func (m *SMTPMailer) Send(_ context.Context, to, subject, body string) error {
+	return m.send(to, subject, body)
+}
+
+func (m *SMTPMailer) send(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid recipient or subject %q", to)
+	}
+	msg := "From: " + m.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + body
+
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, _ := net.SplitHostPort(m.addr)
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+	return smtp.SendMail(m.addr, auth, m.from, []string{to}, []byte(msg))
+}
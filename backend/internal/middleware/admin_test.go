@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	handler := RequireAdmin([]string{"admin-1"})(whoami)
+	for user, want := range map[string]int{
+		"admin-1": http.StatusOK,
+		"user-a":  http.StatusForbidden,
+		"":        http.StatusForbidden,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/admin/x", nil)
+		if user != "" {
+			r = r.WithContext(auth.WithUserID(r.Context(), user))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("user %q: got %d, want %d", user, w.Code, want)
+		}
+	}
+}
@@ -1,31 +1,67 @@
 package middleware
 
 import (
-	"context"
+	"errors"
+	"log"
 	"net/http"
 
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
 	"github.com/ayush/research-ai-agent/backend/internal/auth"
 )
 
-// RequireAuth is middleware that validates the session cookie and
-// injects the user_id into the request context.
-func RequireAuth(sessions *auth.SessionStore) func(http.Handler) http.Handler {
+// RequireAuth is middleware that validates the session cookie, or a
+// personal access token sent as "Authorization: Bearer", and injects the
+// user ID into the request context (see auth.UserIDFromContext). With a
+// nil sessions store only access tokens are accepted.
+func RequireAuth(sessions *auth.SessionStore, tokens auth.TokenResolver) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := auth.BearerToken(r); token != "" {
+				userID, err := tokens.UserIDForToken(r.Context(), auth.HashAccessToken(token))
+				if err != nil {
+					log.Printf("access token lookup failed: %v", err)
+					apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "authentication temporarily unavailable")
+					return
+				}
+				if userID == "" {
+					apierror.Write(w, http.StatusUnauthorized, apierror.InvalidToken, "invalid access token")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
+				return
+			}
+
 			cookie, err := r.Cookie(auth.SessionCookie)
-			if err != nil {
-				http.Error(w, `{"error":"not authenticated"}`, http.StatusUnauthorized)
+			if err != nil || sessions == nil {
+				apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
+				return
+			}
+
+			if !sessions.ValidID(cookie.Value) {
+				sessions.Cookies().ClearSession(w)
+				apierror.Write(w, http.StatusUnauthorized, apierror.InvalidSession, "invalid session cookie")
 				return
 			}
 
 			userID, err := sessions.Get(r.Context(), cookie.Value)
+			if errors.Is(err, auth.ErrSessionStoreUnavailable) {
+				apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "authentication temporarily unavailable")
+				return
+			}
 			if err != nil || userID == "" {
-				http.Error(w, `{"error":"session expired"}`, http.StatusUnauthorized)
+				apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "session expired")
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), "user_id", userID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			// Slide the expiry forward for active users, refreshing the
+			// cookie along with the Redis TTL.
+			if touched, err := sessions.Touch(r.Context(), cookie.Value); err != nil {
+				log.Printf("session touch failed: %v", err)
+			} else if touched {
+				sessions.Cookies().SetSession(w, cookie.Value, sessions.TTL())
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
 		})
 	}
 }
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+)
+
+func newTestSessions(t *testing.T) *auth.SessionStore {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return auth.NewSessionStore(rdb, auth.SessionOptions{})
+}
+
+// whoami answers with the authenticated user ID.
+var whoami = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	userID, _ := auth.UserIDFromContext(r.Context())
+	w.Write([]byte(userID))
+})
+
+func TestRequireAuth(t *testing.T) {
+	sessions := newTestSessions(t)
+	sid, err := sessions.Create(context.Background(), "user-a", auth.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := RequireAuth(sessions, nil)(whoami)
+
+	tests := []struct {
+		name        string
+		cookie      string
+		wantCode    int
+		wantCleared bool
+	}{
+		{"valid session", sid, http.StatusOK, false},
+		{"no cookie", "", http.StatusUnauthorized, false},
+		{"malformed cookie", "not-a-session", http.StatusUnauthorized, true},
+		{"unknown session", "6f1c1a48-3b0e-4c2f-9d6b-1a2b3c4d5e6f", http.StatusUnauthorized, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: auth.SessionCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusOK && w.Body.String() != "user-a" {
+				t.Errorf("user = %q, want user-a", w.Body)
+			}
+			cleared := false
+			for _, c := range w.Result().Cookies() {
+				if c.Name == auth.SessionCookie && c.MaxAge < 0 {
+					cleared = true
+				}
+			}
+			if cleared != tt.wantCleared {
+				t.Errorf("cookie cleared = %v, want %v", cleared, tt.wantCleared)
+			}
+		})
+	}
+}
+
+func TestRequireAuthSessionStoreDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	sessions := auth.NewSessionStore(rdb, auth.SessionOptions{})
+	sid, err := sessions.Create(context.Background(), "user-a", auth.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.Close()
+
+	r := httptest.NewRequest(http.MethodGet, "/api/research", nil)
+	r.AddCookie(&http.Cookie{Name: auth.SessionCookie, Value: sid})
+	w := httptest.NewRecorder()
+	RequireAuth(sessions, nil)(whoami).ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d %s, want 503", w.Code, w.Body)
+	}
+}
+
+func TestRequireAuthSlidesExpiry(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	sessions := auth.NewSessionStore(rdb, auth.SessionOptions{TTL: time.Hour, TouchInterval: time.Minute})
+	sid, err := sessions.Create(context.Background(), "user-a", auth.SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(45 * time.Minute)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/research", nil)
+	r.AddCookie(&http.Cookie{Name: auth.SessionCookie, Value: sid})
+	w := httptest.NewRecorder()
+	RequireAuth(sessions, nil)(whoami).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	if ttl := mr.TTL("session:" + sid); ttl != time.Hour {
+		t.Errorf("session TTL = %v after a request, want 1h", ttl)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != sid || cookies[0].MaxAge != int(time.Hour/time.Second) {
+		t.Errorf("cookies = %v, want the session cookie refreshed", cookies)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// MaxBody rejects request bodies larger than limit bytes with a 413. The
+// body is read up front, so handlers never see a truncated body and their
+// JSON decoding can't fail half-way with a generic error. A limit of zero
+// or less disables the check.
+func MaxBody(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > limit {
+				tooLarge(w, limit)
+				return
+			}
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				tooLarge(w, limit)
+				return
+			}
+			if err != nil {
+				apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func tooLarge(w http.ResponseWriter, limit int64) {
+	apierror.Write(w, http.StatusRequestEntityTooLarge, apierror.TooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressTypes are the content types Gzip compresses when no
+// allowlist is configured. PDFs and ZIPs are already compressed and left
+// out on purpose.
+var DefaultCompressTypes = []string{
+	"application/json",
+	"application/x-tex",
+	"application/x-bibtex",
+	"text/*",
+}
+
+var gzipPool = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// Gzip compresses responses for clients that accept gzip when their
+// content type is in types (entries like "text/*" match a whole family)
+// and their body is at least minSize bytes. Responses that are already
+// encoded, partial, or of other types pass through untouched, as do event
+// streams unless listed explicitly.
+func Gzip(minSize int, types []string) func(http.Handler) http.Handler {
+	if len(types) == 0 {
+		types = DefaultCompressTypes
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			gw := &gzipWriter{ResponseWriter: w, minSize: minSize, types: types}
+			defer gw.finish()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding value allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.TrimSpace(name) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter holds back the start of a response until it knows whether to
+// compress it: once minSize bytes are buffered, on Flush, or when the
+// handler returns.
+type gzipWriter struct {
+	http.ResponseWriter
+	minSize int
+	types   []string
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	w.status = code
+	if code != http.StatusOK || !w.compressible() {
+		w.decide(false)
+	}
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// compressible reports whether the response headers allow compression.
+func (w *gzipWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	ct, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, t := range w.types {
+		if t == ct {
+			return true
+		}
+		// Streams must reach the client as they are written, so a
+		// wildcard never covers them.
+		if strings.HasSuffix(t, "/*") && strings.HasPrefix(ct, strings.TrimSuffix(t, "*")) && ct != "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
+// decide writes the held-back header and buffer, compressed or not.
+func (w *gzipWriter) decide(compress bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if compress {
+		h := w.Header()
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		w.gz = gzipPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish flushes whatever the handler left behind once it returns.
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			return
+		}
+		// Too small to be worth compressing.
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		gzipPool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide(w.status == http.StatusOK && w.compressible())
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// clear the write deadline of an event stream.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// respond serves body with the given content type.
+func respond(contentType, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		io.WriteString(w, body)
+	})
+}
+
+func TestGzip(t *testing.T) {
+	large := strings.Repeat(`{"topic":"solar panels"},`, 100)
+	tests := []struct {
+		name, acceptEncoding, contentType, body string
+		gzipped                                 bool
+	}{
+		{"json", "gzip, deflate", "application/json", large, true},
+		{"tex", "gzip", "application/x-tex", large, true},
+		{"text family", "gzip", "text/csv; charset=utf-8", large, true},
+		{"pdf", "gzip", "application/pdf", large, false},
+		{"event stream", "gzip", "text/event-stream", large, false},
+		{"too small", "gzip", "application/json", `{"ok":true}`, false},
+		{"no gzip accepted", "br", "application/json", large, false},
+		{"gzip refused", "gzip;q=0", "application/json", large, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/research", nil)
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			Gzip(256, nil)(respond(tt.contentType, tt.body)).ServeHTTP(w, r)
+
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			body := w.Body.String()
+			if tt.gzipped {
+				if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+					t.Fatalf("Content-Encoding = %q, want gzip", got)
+				}
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, err := io.ReadAll(zr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(data)
+			} else if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if body != tt.body {
+				t.Errorf("body changed: got %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestGzipTypeAllowlist(t *testing.T) {
+	large := strings.Repeat("x", 1024)
+	handler := Gzip(0, []string{"application/pdf"})
+	for contentType, want := range map[string]string{
+		"application/pdf":  "gzip",
+		"application/json": "",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/research", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler(respond(contentType, large)).ServeHTTP(w, r)
+		if got := w.Header().Get("Content-Encoding"); got != want {
+			t.Errorf("%s: Content-Encoding = %q, want %q", contentType, got, want)
+		}
+	}
+}
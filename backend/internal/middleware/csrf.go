@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+)
+
+// CSRF guards cookie-authenticated requests with a double-submit token:
+// unsafe methods must echo the CSRF cookie in the X-CSRF-Token header,
+// which a cross-site page can't read. Requests with a bearer token or no
+// session cookie aren't exposed to CSRF and pass through. Sessions from
+// before a token was issued get one on their next safe request.
+func CSRF(cookies auth.CookieOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auth.BearerToken(r) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, err := r.Cookie(auth.SessionCookie); err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			token := ""
+			if c, err := r.Cookie(auth.CSRFCookie); err == nil {
+				token = c.Value
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				if token == "" {
+					cookies.IssueCSRF(w)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			header := r.Header.Get(auth.CSRFHeader)
+			if token == "" || header == "" || subtle.ConstantTimeCompare([]byte(token), []byte(header)) != 1 {
+				apierror.Write(w, http.StatusForbidden, apierror.CSRFFailed, "missing or invalid CSRF token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
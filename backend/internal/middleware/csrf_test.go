@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+)
+
+func TestCSRF(t *testing.T) {
+	handler := CSRF(auth.CookieOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name          string
+		method        string
+		session       bool
+		cookie        string
+		header        string
+		bearer        bool
+		wantCode      int
+		wantNewCookie bool
+	}{
+		{"matching token", http.MethodPost, true, "token-a", "token-a", false, http.StatusOK, false},
+		{"delete with matching token", http.MethodDelete, true, "token-a", "token-a", false, http.StatusOK, false},
+		{"absent header", http.MethodPost, true, "token-a", "", false, http.StatusForbidden, false},
+		{"absent cookie", http.MethodPost, true, "", "token-a", false, http.StatusForbidden, false},
+		{"mismatched token", http.MethodPost, true, "token-a", "token-b", false, http.StatusForbidden, false},
+		{"safe method", http.MethodGet, true, "token-a", "", false, http.StatusOK, false},
+		{"safe method issues a token", http.MethodGet, true, "", "", false, http.StatusOK, true},
+		{"bearer token", http.MethodPost, true, "", "", true, http.StatusOK, false},
+		{"no session", http.MethodPost, false, "", "", false, http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/research", nil)
+			if tt.session {
+				r.AddCookie(&http.Cookie{Name: auth.SessionCookie, Value: "session"})
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: auth.CSRFCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set(auth.CSRFHeader, tt.header)
+			}
+			if tt.bearer {
+				r.Header.Set("Authorization", "Bearer rk_test")
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantCode)
+			}
+			issued := false
+			for _, c := range w.Result().Cookies() {
+				issued = issued || (c.Name == auth.CSRFCookie && c.Value != "")
+			}
+			if issued != tt.wantNewCookie {
+				t.Errorf("issued a token: %v, want %v", issued, tt.wantNewCookie)
+			}
+		})
+	}
+}
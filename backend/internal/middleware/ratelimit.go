@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+)
+
+// now is the clock used by RateLimit; tests replace it.
+var now = time.Now
+
+// slidingWindowScript records a hit in a sorted set of timestamps (ms) unless
+// limit hits already fall inside the window. It returns {allowed, retry_ms}.
+var slidingWindowScript = redis.NewScript(`
+local key, now, window, limit = KEYS[1], tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+if redis.call("ZCARD", key) < limit then
+	redis.call("ZADD", key, now, ARGV[4])
+	redis.call("PEXPIRE", key, window)
+	return {1, 0}
+end
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+return {0, tonumber(oldest[2]) + window - now}
+`)
+
+// RateLimit allows each user at most limit requests per sliding window to
+// the routes it wraps, answering 429 with Retry-After beyond that. name
+// keeps the counters of separately limited routes apart. It must run after
+// RequireAuth. If Redis is unreachable requests are let through.
+func RateLimit(rdb *redis.Client, name string, limit int, window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := auth.UserIDFromContext(r.Context())
+			ts := now().UnixMilli()
+			member := fmt.Sprintf("%d-%d", ts, rand.Int64())
+			res, err := slidingWindowScript.Run(r.Context(), rdb,
+				[]string{"ratelimit:" + name + ":" + userID},
+				ts, window.Milliseconds(), limit, member,
+			).Int64Slice()
+			if err != nil {
+				log.Printf("rate limit check failed: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if res[0] == 0 {
+				retry := (time.Duration(res[1])*time.Millisecond + time.Second - 1) / time.Second
+				if retry < 1 {
+					retry = 1
+				}
+				w.Header().Set("Retry-After", strconv.FormatInt(int64(retry), 10))
+				apierror.Write(w, http.StatusTooManyRequests, apierror.RateLimited, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
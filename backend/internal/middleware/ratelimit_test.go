@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+)
+
+// fakeClock replaces the rate limiter's clock for the rest of the test.
+func fakeClock(t *testing.T, start time.Time) *time.Time {
+	t.Helper()
+	clock := start
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+	return &clock
+}
+
+func TestRateLimit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	clock := fakeClock(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	handler := RateLimit(rdb, "create", 2, time.Minute)(whoami)
+
+	hit := func(userID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r = r.WithContext(auth.WithUserID(r.Context(), userID))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := hit("user-a"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: got %d, want 200", i+1, w.Code)
+		}
+		*clock = clock.Add(20 * time.Second)
+	}
+	w := hit("user-a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: got %d, want 429", w.Code)
+	}
+	// The first hit was 40s ago, so it leaves the window in 20s.
+	if got := w.Header().Get("Retry-After"); got != "20" {
+		t.Errorf("Retry-After = %q, want 20", got)
+	}
+	if w := hit("user-b"); w.Code != http.StatusOK {
+		t.Errorf("other user: got %d, want 200", w.Code)
+	}
+
+	*clock = clock.Add(21 * time.Second)
+	if w := hit("user-a"); w.Code != http.StatusOK {
+		t.Errorf("after the window slid: got %d, want 200", w.Code)
+	}
+	if w := hit("user-a"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second hit in the new window: got %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitRedisDown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+	mr.Close()
+	handler := RateLimit(rdb, "create", 1, time.Minute)(whoami)
+
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r = r.WithContext(auth.WithUserID(r.Context(), "user-a"))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d with Redis down: got %d, want 200", i+1, w.Code)
+		}
+	}
+}
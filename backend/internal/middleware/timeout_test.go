@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	fast := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Done", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+	w := httptest.NewRecorder()
+	fast.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != http.StatusCreated || w.Body.String() != "created" || w.Header().Get("X-Done") != "yes" {
+		t.Errorf("fast handler: got %d %q %v", w.Code, w.Body, w.Header())
+	}
+
+	// A slow handler is answered with a 503 and sees its context cancelled,
+	// so the calls it makes give up too.
+	cancelled := make(chan error, 1)
+	slow := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		cancelled <- r.Context().Err()
+		w.Write([]byte("too late"))
+	}))
+	w = httptest.NewRecorder()
+	slow.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"unavailable"`) {
+		t.Errorf("slow handler: got %d %s, want 503", w.Code, w.Body)
+	}
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("handler context: %v, want deadline exceeded", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+	if strings.Contains(w.Body.String(), "too late") {
+		t.Error("late write reached the client")
+	}
+}
+
+func TestTimeoutDisabled(t *testing.T) {
+	handler := Timeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("zero timeout set a deadline")
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
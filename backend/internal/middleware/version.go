@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// APIVersionHeader names the API version that served a response.
+const APIVersionHeader = "API-Version"
+
+// APIVersion stamps responses with the API version that served them.
+func APIVersion(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(APIVersionHeader, version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Deprecated marks responses of routes served under prefix as deprecated
+// and links each one to its successor under successor, e.g. /api/research
+// to /api/v1/research.
+func Deprecated(prefix, successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Deprecation", "true")
+			if rest, ok := strings.CutPrefix(r.URL.Path, prefix); ok {
+				h.Add("Link", "<"+successor+rest+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIVersion(t *testing.T) {
+	h := APIVersion("1")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/research", nil))
+	if got := w.Header().Get(APIVersionHeader); got != "1" {
+		t.Errorf("%s = %q, want 1", APIVersionHeader, got)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	h := Deprecated("/api", "/api/v1")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tests := []struct {
+		path     string
+		wantLink string
+	}{
+		{"/api/research/abc", `</api/v1/research/abc>; rel="successor-version"`},
+		{"/other", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if got := w.Header().Get("Deprecation"); got != "true" {
+				t.Errorf("Deprecation = %q, want true", got)
+			}
+			if got := w.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -8,23 +9,77 @@ import (
 
 // Source is a web source cited in the report.
 type Source struct {
-	Title string `json:"title" bson:"title"`
-	Body  string `json:"body"  bson:"body"`
-	Href  string `json:"href"  bson:"href"`
+	ID    string `json:"id,omitempty"    bson:"id,omitempty"`
+	Title string `json:"title"           bson:"title"`
+	Body  string `json:"body"            bson:"body"`
+	Href  string `json:"href"            bson:"href"`
+	Query string `json:"query,omitempty" bson:"query,omitempty"` // search query that surfaced this source, if known
+	// Credibility is a 0–1 domain reputation score.
+	Credibility float64 `json:"credibility" bson:"credibility"`
+	// Author, PublishedDate and SiteName are set when the search service
+	// knows them. PublishedDate is kept as the service reported it.
+	Author        string `json:"author,omitempty"         bson:"author,omitempty"`
+	PublishedDate string `json:"published_date,omitempty" bson:"published_date,omitempty"`
+	SiteName      string `json:"site_name,omitempty"      bson:"site_name,omitempty"`
 }
 
+// Research job statuses.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusComplete  = "complete"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+	// StatusDegraded means the report was written but neither the PDF
+	// nor the .tex file could be compiled; see Document.CompileError.
+	StatusDegraded = "degraded"
+)
+
 // Document is a single research report stored in MongoDB.
 type Document struct {
-	ID            primitive.ObjectID `json:"id"              bson:"_id,omitempty"`
-	UserID        string             `json:"user_id"         bson:"user_id"`
-	Topic         string             `json:"topic"           bson:"topic"`
-	LatexContent  string             `json:"latex_content"   bson:"latex_content"`
-	Sources       []Source           `json:"sources"         bson:"sources"`
-	ModelUsed     string             `json:"model_used"      bson:"model_used"`
-	SearchQueries []string           `json:"search_queries"  bson:"search_queries"`
-	PDFObjectKey  string             `json:"pdf_object_key"  bson:"pdf_object_key"`
-	TexObjectKey  string             `json:"tex_object_key"  bson:"tex_object_key"`
-	CreatedAt     time.Time          `json:"created_at"      bson:"created_at"`
+	ID           primitive.ObjectID `json:"id"              bson:"_id,omitempty"`
+	UserID       string             `json:"user_id"         bson:"user_id"`
+	Topic        string             `json:"topic"           bson:"topic"`
+	LatexContent string             `json:"latex_content"   bson:"latex_content"`
+	Sources      []Source           `json:"sources"         bson:"sources"`
+	ModelUsed    string             `json:"model_used"      bson:"model_used"`
+	// RequestedModel is set when ModelUsed was substituted for a model
+	// that kept timing out.
+	RequestedModel string   `json:"requested_model,omitempty" bson:"requested_model,omitempty"`
+	Provider       string   `json:"provider,omitempty" bson:"provider,omitempty"`
+	SearchQueries  []string `json:"search_queries"  bson:"search_queries"`
+	PDFObjectKey   string   `json:"pdf_object_key"  bson:"pdf_object_key"`
+	TexObjectKey   string   `json:"tex_object_key"  bson:"tex_object_key"`
+	SearchSkipped  bool     `json:"search_skipped"  bson:"search_skipped"`
+	Dedup          string   `json:"dedup,omitempty" bson:"dedup,omitempty"`
+	CitationStyle  string   `json:"citation_style,omitempty" bson:"citation_style,omitempty"`
+	Length         string   `json:"length,omitempty"          bson:"length,omitempty"`
+	TargetWords    int      `json:"target_words,omitempty"    bson:"target_words,omitempty"`
+	TargetSections int      `json:"target_sections,omitempty" bson:"target_sections,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty" bson:"temperature,omitempty"`
+	MaxTokens      int      `json:"max_tokens,omitempty"  bson:"max_tokens,omitempty"`
+	Tags           []string `json:"tags,omitempty"  bson:"tags,omitempty"`
+	Language       string   `json:"language,omitempty" bson:"language,omitempty"`
+	// ParentID links a regenerated version to the document it came from.
+	ParentID  string    `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	Status    string    `json:"status"          bson:"status"`
+	Step      string    `json:"step,omitempty"  bson:"step,omitempty"`
+	Error     string    `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"      bson:"created_at"`
+	// DeletedAt is set while the document is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	// StepTimings is how long each pipeline step of the last run took, in
+	// milliseconds, keyed by step name.
+	StepTimings map[string]int64 `json:"step_timings,omitempty" bson:"step_timings,omitempty"`
+	// CompileError holds the LaTeX service's output when compiling the
+	// PDF or .tex file failed.
+	CompileError string `json:"compile_error,omitempty" bson:"compile_error,omitempty"`
+	// PDFSize and TexSize are the sizes in bytes of the stored files.
+	PDFSize int64 `json:"pdf_size,omitempty" bson:"pdf_size,omitempty"`
+	TexSize int64 `json:"tex_size,omitempty" bson:"tex_size,omitempty"`
+	// Watermark is the text the PDF is watermarked with; empty when it
+	// has none. Recompiles keep it.
+	Watermark string `json:"watermark,omitempty" bson:"watermark,omitempty"`
 }
 
 // CreateRequest is the JSON body for POST /api/research.
@@ -33,4 +88,117 @@ type CreateRequest struct {
 	Model  string `json:"model"`
 	Depth  string `json:"depth"`
 	APIKey string `json:"api_key"`
+	// MaxQueries and ResultsPerQuery override the counts of Depth.
+	MaxQueries      int `json:"max_queries,omitempty"`
+	ResultsPerQuery int `json:"results_per_query,omitempty"`
+	// Provider names the AI provider to use; empty selects the default.
+	Provider string `json:"provider"`
+	// SkipSearch bypasses query generation and web search so the report is
+	// written from the model's own knowledge, with no cited sources.
+	SkipSearch bool `json:"skip_search"`
+	// Dedup picks how aggressively duplicate sources are dropped: none, url
+	// or fuzzy (near-identical titles too). Empty uses the server default.
+	Dedup string `json:"dedup"`
+	// CitationStyle formats the bibliography: apa, mla, ieee (default) or chicago.
+	CitationStyle string `json:"citation_style"`
+	// Length is a report length preset (Brief, Standard, Comprehensive);
+	// TargetWords/TargetSections override its targets.
+	Length         string `json:"length"`
+	TargetWords    int    `json:"target_words"`
+	TargetSections int    `json:"target_sections"`
+	// Temperature and MaxTokens tune the model's sampling; unset leaves
+	// the AI service defaults.
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	// MinCredibility drops sources scoring below it (0–1) before the report
+	// is generated. Zero keeps all sources.
+	MinCredibility float64 `json:"min_credibility"`
+	// AllowedDomains restricts sources to these domains and their
+	// subdomains; BlockedDomains excludes them. Blocked wins.
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	BlockedDomains []string `json:"blocked_domains,omitempty"`
+	// Language is the ISO 639-1 code of the report language.
+	Language string `json:"language"`
+	// CallbackURL, if set, receives a signed POST when the job finishes.
+	CallbackURL string `json:"callback_url,omitempty"`
+	// NoCache skips the cached queries and search results and runs both
+	// afresh.
+	NoCache bool `json:"no_cache"`
+	// Watermark, if set, is printed diagonally across every page of the
+	// PDF, e.g. "DRAFT" or an organisation's name.
+	Watermark string `json:"watermark,omitempty"`
+}
+
+// ErrInvalidCursor is returned when a pagination cursor can't be resolved.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListOptions controls which page of a user's documents is returned.
+type ListOptions struct {
+	Limit  int    // page size; must be > 0
+	Cursor string // ID of the last document of the previous page
+
+	// Optional filters; zero values don't filter.
+	Model  string
+	From   time.Time // created_at >= From
+	To     time.Time // created_at <= To
+	Domain string    // only documents citing this host
+	Tag    string    // only documents carrying this tag
+
+	// Trashed lists soft-deleted documents instead of live ones.
+	Trashed bool
+}
+
+// DomainCount is how often a source domain is cited across a user's reports.
+type DomainCount struct {
+	Domain    string `json:"domain"    bson:"domain"`
+	Citations int    `json:"citations" bson:"citations"`
+	Documents int    `json:"documents" bson:"documents"`
+}
+
+// ModelCount is how many of a user's reports a model wrote.
+type ModelCount struct {
+	Model string `json:"model" bson:"model"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// ResearchStats summarises a user's live (not trashed) reports.
+type ResearchStats struct {
+	Total   int          `json:"total"`
+	ByModel []ModelCount `json:"by_model"`
+	// StorageBytes is the combined size of the reports' PDF and .tex files.
+	StorageBytes int64      `json:"storage_bytes"`
+	LatestAt     *time.Time `json:"latest_at,omitempty"`
+}
+
+// TagCount is how many of a user's reports carry a tag.
+type TagCount struct {
+	Tag   string `json:"tag"   bson:"tag"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// Version is a snapshot of a document's report taken before it was
+// overwritten by an edit, regeneration or restore.
+type Version struct {
+	ID           primitive.ObjectID `json:"-"                       bson:"_id,omitempty"`
+	DocID        string             `json:"doc_id"                  bson:"doc_id"`
+	Version      int                `json:"version"                 bson:"version"`
+	LatexContent string             `json:"latex_content,omitempty" bson:"latex_content"`
+	ModelUsed    string             `json:"model_used"              bson:"model_used"`
+	PDFObjectKey string             `json:"pdf_object_key"          bson:"pdf_object_key"`
+	TexObjectKey string             `json:"tex_object_key"          bson:"tex_object_key"`
+	PDFSize      int64              `json:"pdf_size,omitempty"      bson:"pdf_size,omitempty"`
+	TexSize      int64              `json:"tex_size,omitempty"      bson:"tex_size,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"              bson:"created_at"`
+	// Sources are the document's sources when the version was archived;
+	// unset on versions archived before sources were kept.
+	Sources []Source `json:"sources,omitempty" bson:"sources,omitempty"`
+}
+
+// ObjectInfo describes a stored file.
+type ObjectInfo struct {
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+	// ETag is the quoted entity tag of the file's current contents.
+	ETag string
 }
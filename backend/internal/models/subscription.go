@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrSubscriptionNotFound is returned when a subscription doesn't exist or
+// belongs to another user.
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// Subscription is a topic a user wants re-searched periodically, with a
+// notification when new sources show up.
+type Subscription struct {
+	ID             primitive.ObjectID `json:"id"                   bson:"_id,omitempty"`
+	UserID         string             `json:"user_id"              bson:"user_id"`
+	Topic          string             `json:"topic"                bson:"topic"`
+	Depth          string             `json:"depth"                bson:"depth"`
+	IntervalHours  int                `json:"interval_hours"       bson:"interval_hours"`
+	MinCredibility float64            `json:"min_credibility"      bson:"min_credibility"`
+	NotifyEmail    bool               `json:"notify_email"         bson:"notify_email"`
+	WebhookURL     string             `json:"webhook_url,omitempty" bson:"webhook_url,omitempty"`
+	// AutoReport starts a new research run whenever new sources are found.
+	AutoReport bool `json:"auto_report" bson:"auto_report"`
+	// SeenURLs are the normalized URLs of every source found so far.
+	SeenURLs  []string  `json:"-"                     bson:"seen_urls"`
+	LastRunAt time.Time `json:"last_run_at,omitempty" bson:"last_run_at,omitempty"`
+	NextRunAt time.Time `json:"next_run_at"           bson:"next_run_at"`
+	CreatedAt time.Time `json:"created_at"            bson:"created_at"`
+}
+
+// SubscriptionRequest is the JSON body for POST /api/research/subscriptions.
+type SubscriptionRequest struct {
+	Topic          string  `json:"topic"`
+	Depth          string  `json:"depth"`
+	IntervalHours  int     `json:"interval_hours"`
+	MinCredibility float64 `json:"min_credibility"`
+	NotifyEmail    bool    `json:"notify_email"`
+	WebhookURL     string  `json:"webhook_url"`
+	AutoReport     bool    `json:"auto_report"`
+}
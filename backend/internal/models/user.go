@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // User represents a row in the PostgreSQL users table.
 type User struct {
@@ -9,8 +12,17 @@ type User struct {
 	Email     string    `json:"email"`
 	Password  string    `json:"-"` // never serialize
 	CreatedAt time.Time `json:"created_at"`
+	HasAPIKey bool      `json:"has_api_key"` // the key itself is never exposed
+	// EmailVerified is cleared whenever the email address changes.
+	EmailVerified bool `json:"email_verified"`
+	// Guest marks a visitor without an account, whose data is deleted
+	// after a while.
+	Guest bool `json:"guest,omitempty"`
 }
 
+// ErrUserConflict means a username or email is already taken.
+var ErrUserConflict = errors.New("username or email already in use")
+
 // RegisterRequest is the JSON body for POST /api/auth/register.
 type RegisterRequest struct {
 	Username string `json:"username"`
@@ -23,3 +35,17 @@ type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+
+// AccessToken is the metadata of a personal access token. The token itself
+// is only shown once, when it is created.
+type AccessToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // leading characters, to tell tokens apart
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// ErrTokenNotFound is returned when an access token doesn't exist or
+// belongs to another user.
+var ErrTokenNotFound = errors.New("access token not found")
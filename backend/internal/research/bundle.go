@@ -0,0 +1,77 @@
+package research
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Bundle streams a ZIP of the report's PDF and .tex source together with a
+// sources.json of the cited sources. Files that were never produced are
+// left out.
+func (h *Handler) Bundle(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+
+	// Fetch everything before the headers go out, so a storage failure can
+	// still be reported as an error instead of a truncated archive.
+	var pdf, tex []byte
+	if doc.PDFObjectKey != "" {
+		data, _, err := h.minio.Download(r.Context(), doc.PDFObjectKey)
+		if err != nil {
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "download failed")
+			return
+		}
+		pdf = data
+	}
+	if doc.TexObjectKey != "" {
+		data, _, err := h.minio.Download(r.Context(), doc.TexObjectKey)
+		if err != nil {
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "download failed")
+			return
+		}
+		tex = data
+	} else if doc.LatexContent != "" {
+		tex = []byte(doc.LatexContent)
+	}
+	sources := doc.Sources
+	if sources == nil {
+		sources = []models.Source{}
+	}
+	sourcesJSON, _ := json.MarshalIndent(sources, "", "  ")
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", contentDisposition(doc, "zip"))
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{downloadName(doc, "pdf"), pdf},
+		{downloadName(doc, "tex"), tex},
+		{"sources.json", sourcesJSON},
+	}
+	for _, f := range files {
+		if f.data == nil {
+			continue
+		}
+		fw, err := zw.Create(f.name)
+		if err == nil {
+			_, err = fw.Write(f.data)
+		}
+		if err != nil {
+			log.Printf("bundle %s: %v", doc.ID.Hex(), err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("bundle %s: %v", doc.ID.Hex(), err)
+	}
+}
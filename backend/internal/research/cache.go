@@ -0,0 +1,142 @@
+package research
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// QueryCache remembers generated search queries per (provider, model,
+// topic) and search results per query, so repeating a topic doesn't pay
+// for either again. A zero TTL disables that half of the cache; a nil
+// cache is always empty.
+type QueryCache struct {
+	rdb       *redis.Client
+	queryTTL  time.Duration
+	searchTTL time.Duration
+
+	hits, misses atomic.Int64
+}
+
+func NewQueryCache(rdb *redis.Client, queryTTL, searchTTL time.Duration) *QueryCache {
+	return &QueryCache{rdb: rdb, queryTTL: queryTTL, searchTTL: searchTTL}
+}
+
+// CacheStats counts cache lookups since startup.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// Stats returns the hit and miss counters.
+func (c *QueryCache) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// cacheKey hashes parts into a fixed-length Redis key under prefix.
+func cacheKey(prefix string, parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return prefix + hex.EncodeToString(sum[:])
+}
+
+func queriesKey(provider, model, topic string) string {
+	return cacheKey("cache:queries:", provider, model, strings.ToLower(strings.TrimSpace(topic)))
+}
+
+func searchKey(provider string, queries []string, resultsPerQuery int) string {
+	return cacheKey("cache:search:", append([]string{provider, strconv.Itoa(resultsPerQuery)}, queries...)...)
+}
+
+// get decodes the cached value at key into v, counting the lookup.
+func (c *QueryCache) get(ctx context.Context, key string, v any) bool {
+	data, err := c.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("cache get %s: %v", key, err)
+		}
+		c.misses.Add(1)
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		c.misses.Add(1)
+		return false
+	}
+	c.hits.Add(1)
+	return true
+}
+
+func (c *QueryCache) set(ctx context.Context, key string, v any, ttl time.Duration) {
+	data, _ := json.Marshal(v)
+	if err := c.rdb.Set(ctx, key, data, ttl).Err(); err != nil {
+		log.Printf("cache set %s: %v", key, err)
+	}
+}
+
+// Queries returns the cached queries for a topic, if any.
+func (c *QueryCache) Queries(ctx context.Context, provider, model, topic string) ([]string, bool) {
+	if c == nil || c.queryTTL <= 0 {
+		return nil, false
+	}
+	var queries []string
+	ok := c.get(ctx, queriesKey(provider, model, topic), &queries)
+	return queries, ok && len(queries) > 0
+}
+
+// SaveQueries caches the queries generated for a topic.
+func (c *QueryCache) SaveQueries(ctx context.Context, provider, model, topic string, queries []string) {
+	if c == nil || c.queryTTL <= 0 || len(queries) == 0 {
+		return
+	}
+	c.set(ctx, queriesKey(provider, model, topic), queries, c.queryTTL)
+}
+
+// Searcher wraps s so each Search call is answered from the cache when the
+// same queries were searched recently. Concurrent searches send one query
+// per call, so they are cached per query.
+func (c *QueryCache) Searcher(provider string, s Searcher) Searcher {
+	if c == nil || c.searchTTL <= 0 {
+		return s
+	}
+	return &cachedSearcher{cache: c, provider: provider, next: s}
+}
+
+type cachedSearcher struct {
+	cache    *QueryCache
+	provider string
+	next     Searcher
+}
+
+func (s *cachedSearcher) Search(ctx context.Context, queries []string, resultsPerQuery int) ([]models.Source, error) {
+	key := searchKey(s.provider, queries, resultsPerQuery)
+	var sources []models.Source
+	if s.cache.get(ctx, key, &sources) && len(sources) > 0 {
+		return sources, nil
+	}
+	sources, err := s.next.Search(ctx, queries, resultsPerQuery)
+	if err == nil && len(sources) > 0 {
+		s.cache.set(ctx, key, sources, s.cache.searchTTL)
+	}
+	return sources, err
+}
+
+// CacheStats reports query and search cache hits and misses.
+func (h *Handler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	if h.opts.Cache == nil {
+		apierror.Write(w, http.StatusNotImplemented, apierror.NotImplemented, "cache not enabled")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.opts.Cache.Stats())
+}
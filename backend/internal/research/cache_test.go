@@ -0,0 +1,58 @@
+package research
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestQueryCache(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	cache := NewQueryCache(rdb, time.Hour, 10*time.Minute)
+	env := newTestEnv(t, &Options{Cache: cache})
+	req := models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"}
+
+	first := env.create(t, "user-a", req)
+	searches := env.ai.called("/api/search")
+	if searches == 0 {
+		t.Fatal("first report did not search")
+	}
+	req.Topic = "  Solar Panels "
+	second := env.create(t, "user-a", req)
+	if n := env.ai.called("/api/generate-queries"); n != 1 {
+		t.Errorf("generate-queries called %d times, want the second report to hit the cache", n)
+	}
+	if n := env.ai.called("/api/search"); n != searches {
+		t.Errorf("search called %d times, want %d", n, searches)
+	}
+	if len(second.Sources) != len(first.Sources) || len(second.SearchQueries) != len(first.SearchQueries) {
+		t.Errorf("cached report got %d sources and %d queries, want %d and %d",
+			len(second.Sources), len(second.SearchQueries), len(first.Sources), len(first.SearchQueries))
+	}
+
+	req.NoCache = true
+	env.create(t, "user-a", req)
+	if n := env.ai.called("/api/generate-queries"); n != 2 {
+		t.Errorf("no_cache: generate-queries called %d times, want 2", n)
+	}
+
+	// Search results expire before the queries do.
+	mr.FastForward(11 * time.Minute)
+	req.NoCache = false
+	env.create(t, "user-a", req)
+	if n := env.ai.called("/api/generate-queries"); n != 2 {
+		t.Errorf("generate-queries called %d times, want the queries still cached", n)
+	}
+	if n := env.ai.called("/api/search"); n != 3*searches {
+		t.Errorf("search called %d times, want expired results searched again", n)
+	}
+
+	w := serve(env.h.CacheStats, newRequest(t, http.MethodGet, "/admin/cache", "admin", nil))
+	var stats CacheStats
+	decode(t, w, &stats)
+	if stats != cache.Stats() || stats.Hits == 0 || stats.Misses == 0 {
+		t.Errorf("stats = %+v, want hits and misses counted", stats)
+	}
+}
@@ -0,0 +1,135 @@
+package research
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of a callback body, as
+// "sha256=<hex>", keyed with the server's callback secret.
+const SignatureHeader = "X-Signature"
+
+// maxCallbackURLLen bounds the callback_url of a create request.
+const maxCallbackURLLen = 2048
+
+// callbackTimeout bounds one notification, retries included.
+const callbackTimeout = time.Minute
+
+// CallbackPayload is POSTed to a request's callback_url when its
+// pipeline finishes.
+type CallbackPayload struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Topic     string    `json:"topic"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Callbacks delivers signed completion notifications to integrators.
+type Callbacks struct {
+	secret []byte
+	client *http.Client
+	retry  RetryPolicy
+}
+
+func NewCallbacks(secret string, retry RetryPolicy) *Callbacks {
+	return &Callbacks{secret: []byte(secret), client: newPublicClient(10 * time.Second), retry: retry}
+}
+
+// newPublicClient returns a client for posting to user-supplied URLs. Like
+// the LinkChecker it only connects to public addresses, and it doesn't
+// follow redirects, which could point it back at an internal service.
+func newPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: publicAddrOnly}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Sign returns the signature header value for body.
+func (c *Callbacks) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send POSTs payload to target, retrying connection errors and 5xx/429
+// responses with backoff. Redirects are not followed and count as a
+// failure.
+func (c *Callbacks) Send(ctx context.Context, target string, payload CallbackPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.deliver(ctx, target, body)
+}
+
+// deliver POSTs a signed JSON body to target, as Send does. Subscription
+// webhooks are delivered the same way.
+func (c *Callbacks) deliver(ctx context.Context, target string, body []byte) error {
+	header := http.Header{SignatureHeader: {c.Sign(body)}}
+	resp, err := post(ctx, c.client, target, body, header, c.retry)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("receiver returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// validCallbackURL reports whether s is an absolute http(s) URL.
+func validCallbackURL(s string) bool {
+	if len(s) > maxCallbackURLLen {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// notifyCallback tells the request's callback_url how the job ended. ctx
+// is the pipeline's context and is only checked to tell a cancelled job
+// from one still marked running.
+func (h *Handler) notifyCallback(ctx context.Context, j job) {
+	if j.req.CallbackURL == "" || h.opts.Callbacks == nil {
+		return
+	}
+	sendCtx, cancel := context.WithTimeout(context.Background(), callbackTimeout)
+	defer cancel()
+
+	payload := CallbackPayload{ID: j.docID, Topic: j.doc.Topic, Timestamp: time.Now().UTC()}
+	if doc, err := h.mongo.GetByID(sendCtx, j.docID); err == nil {
+		payload.Status, payload.Error = doc.Status, doc.Error
+	} else {
+		payload.Status, payload.Error = j.doc.Status, j.doc.Error
+	}
+	// Cancel records its status only after stopping the pipeline.
+	if (payload.Status == models.StatusPending || payload.Status == models.StatusRunning) && ctx.Err() != nil {
+		payload.Status = models.StatusCancelled
+	}
+
+	if err := h.opts.Callbacks.Send(sendCtx, j.req.CallbackURL, payload); err != nil {
+		log.Printf("callback %s for %s failed: %v", j.req.CallbackURL, j.docID, err)
+	}
+}
@@ -0,0 +1,137 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// callbackReceiver records the callbacks POSTed to it after answering the
+// first failures attempts with a 503.
+type callbackReceiver struct {
+	mu         sync.Mutex
+	failures   int
+	attempts   int
+	signatures []string
+	payloads   []CallbackPayload
+	bodies     [][]byte
+}
+
+func (c *callbackReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts++
+	if c.attempts <= c.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var p CallbackPayload
+	json.Unmarshal(body, &p)
+	c.signatures = append(c.signatures, r.Header.Get(SignatureHeader))
+	c.payloads = append(c.payloads, p)
+	c.bodies = append(c.bodies, body)
+}
+
+// allowLoopback lets a public-only client reach the test servers.
+func allowLoopback(client *http.Client) {
+	client.Transport.(*http.Transport).DialContext = (&net.Dialer{}).DialContext
+}
+
+func newCallbackEnv(t *testing.T, failures int) (*testEnv, *Callbacks, *callbackReceiver, string) {
+	t.Helper()
+	recv := &callbackReceiver{failures: failures}
+	srv := httptest.NewServer(recv)
+	t.Cleanup(srv.Close)
+	callbacks := NewCallbacks("s3cret", RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	allowLoopback(callbacks.client)
+	env := newTestEnv(t, &Options{Callbacks: callbacks})
+	return env, callbacks, recv, srv.URL + "/hooks/research"
+}
+
+func TestCallbackOnCompletion(t *testing.T) {
+	env, callbacks, recv, url := newCallbackEnv(t, 2)
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", CallbackURL: url})
+	env.ai.fail["/api/generate-report"] = true
+	failed := env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test", CallbackURL: url})
+
+	if len(recv.payloads) != 2 {
+		t.Fatalf("received %d callbacks after %d attempts, want 2", len(recv.payloads), recv.attempts)
+	}
+	if recv.attempts != 4 {
+		t.Errorf("%d attempts, want the first retried twice", recv.attempts)
+	}
+	for i, want := range []CallbackPayload{
+		{ID: doc.ID.Hex(), Status: models.StatusComplete, Topic: "solar panels"},
+		{ID: failed.ID.Hex(), Status: models.StatusFailed, Topic: "wind", Error: failed.Error},
+	} {
+		got := recv.payloads[i]
+		if got.ID != want.ID || got.Status != want.Status || got.Topic != want.Topic || got.Error != want.Error {
+			t.Errorf("callback %d = %+v, want %+v", i, got, want)
+		}
+		if sig := recv.signatures[i]; sig != callbacks.Sign(recv.bodies[i]) {
+			t.Errorf("callback %d signature %q does not match its body", i, sig)
+		}
+	}
+	if NewCallbacks("other", RetryPolicy{}).Sign(recv.bodies[0]) == recv.signatures[0] {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestCallbackURLValidation(t *testing.T) {
+	env, _, _, url := newCallbackEnv(t, 0)
+	for name, callbackURL := range map[string]string{
+		"relative": "/hooks/research",
+		"scheme":   "ftp://example.com/hook",
+		"no host":  "https:///hook",
+	} {
+		w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a",
+			models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", CallbackURL: callbackURL}))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", name, w.Code)
+		}
+	}
+
+	disabled := newTestEnv(t, nil)
+	w := serve(disabled.h.Create, newRequest(t, http.MethodPost, "/research", "user-a",
+		models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", CallbackURL: url}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("callbacks disabled: got %d, want 400", w.Code)
+	}
+}
+
+func TestCallbackRefusesInternalAddresses(t *testing.T) {
+	recv := &callbackReceiver{}
+	srv := httptest.NewServer(recv)
+	t.Cleanup(srv.Close)
+
+	callbacks := NewCallbacks("s3cret", RetryPolicy{MaxAttempts: 1})
+	err := callbacks.Send(context.Background(), srv.URL+"/hooks/research", CallbackPayload{ID: "doc"})
+	if !errors.Is(err, errBlockedAddress) {
+		t.Errorf("loopback callback: err = %v, want the address refused", err)
+	}
+	if recv.attempts != 0 {
+		t.Errorf("loopback receiver got %d requests", recv.attempts)
+	}
+
+	// A public receiver can't redirect the callback inside the network.
+	redirect := httptest.NewServer(http.RedirectHandler(srv.URL+"/hooks/research", http.StatusTemporaryRedirect))
+	t.Cleanup(redirect.Close)
+	allowLoopback(callbacks.client)
+	if err := callbacks.Send(context.Background(), redirect.URL, CallbackPayload{ID: "doc"}); err == nil {
+		t.Error("redirected callback succeeded")
+	}
+	if recv.attempts != 0 {
+		t.Errorf("redirect was followed: receiver got %d requests", recv.attempts)
+	}
+}
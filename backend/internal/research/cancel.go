@@ -0,0 +1,56 @@
+package research
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// runningJob is the registry entry of a pipeline running in this process.
+type runningJob struct {
+	cancel func()
+}
+
+// Cancel stops a running research job and marks it cancelled. Only jobs
+// running on this instance can be cancelled.
+func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	if doc.Status != models.StatusPending && doc.Status != models.StatusRunning {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research is not running")
+		return
+	}
+
+	h.cancelsMu.Lock()
+	running, ok := h.cancels[id]
+	h.cancelsMu.Unlock()
+	if !ok {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research job is not running on this server")
+		return
+	}
+	running.cancel()
+
+	// The pipeline's own writes fail once its context is cancelled, so this
+	// status is the last word, unless the run finished first.
+	cancelled, err := h.mongo.CancelActive(r.Context(), id, doc.Step, "Cancelled by user.")
+	if err != nil {
+		log.Printf("set status %s/cancelled error: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to cancel research")
+		return
+	}
+	if !cancelled {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research is not running")
+		return
+	}
+	h.opts.Progress.Publish(r.Context(), id, ProgressEvent{
+		Status: models.StatusCancelled, Step: doc.Step, Percent: stepPercent[doc.Step], Error: "Cancelled by user.",
+	})
+	writeJSON(w, http.StatusOK, map[string]string{"status": models.StatusCancelled})
+}
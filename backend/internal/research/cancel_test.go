@@ -0,0 +1,46 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestCancel(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id, finish := env.startBlocked(t, "user-a")
+
+	w := serve(env.h.Cancel, newRequest(t, http.MethodPost, "/research/"+id+"/cancel", "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("cancel: got %d %s, want 200", w.Code, w.Body)
+	}
+	doc, _ := env.docs.GetByID(context.Background(), id)
+	if doc.Status != models.StatusCancelled {
+		t.Errorf("status = %q, want cancelled", doc.Status)
+	}
+	finish()
+
+	w = serve(env.h.Cancel, newRequest(t, http.MethodPost, "/research/"+id+"/cancel", "user-a", nil, "id", id))
+	if w.Code != http.StatusConflict {
+		t.Errorf("second cancel: got %d, want 409", w.Code)
+	}
+}
+
+func TestCancelRacingCompletion(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels", Status: models.StatusRunning})
+	// The run saves its result between Cancel's lookup and its write.
+	env.h.cancels[id] = &runningJob{cancel: func() {
+		env.docs.SetStatus(context.Background(), id, models.StatusComplete, "", "")
+	}}
+
+	w := serve(env.h.Cancel, newRequest(t, http.MethodPost, "/research/"+id+"/cancel", "user-a", nil, "id", id))
+	if w.Code != http.StatusConflict {
+		t.Errorf("cancel after completion: got %d %s, want 409", w.Code, w.Body)
+	}
+	if doc, _ := env.docs.GetByID(context.Background(), id); doc.Status != models.StatusComplete {
+		t.Errorf("status = %q, want the completed result kept", doc.Status)
+	}
+}
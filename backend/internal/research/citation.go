@@ -0,0 +1,126 @@
+package research
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Citation styles accepted in create requests.
+const (
+	CitationAPA     = "apa"
+	CitationMLA     = "mla"
+	CitationIEEE    = "ieee"
+	CitationChicago = "chicago"
+)
+
+// DefaultCitationStyle matches the numbered \cite{sourceN} keys the report
+// prompt asks for.
+const DefaultCitationStyle = CitationIEEE
+
+// validCitationStyle reports whether style is on the allow-list.
+func validCitationStyle(style string) bool {
+	switch style {
+	case CitationAPA, CitationMLA, CitationIEEE, CitationChicago:
+		return true
+	}
+	return false
+}
+
+// latexEscaper escapes characters that are special in LaTeX text.
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`&`, `\&`, `%`, `\%`, `$`, `\$`, `#`, `\#`, `_`, `\_`,
+	`{`, `\{`, `}`, `\}`, `~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`,
+)
+
+// sourceSite returns the source's site name, or else the host of its URL
+// without "www.".
+func sourceSite(s models.Source) string {
+	if name := strings.TrimSpace(s.SiteName); name != "" {
+		return name
+	}
+	u, err := url.Parse(s.Href)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// yearPattern finds a plausible publication year in a free-form date.
+var yearPattern = regexp.MustCompile(`\b(1[5-9]|20)\d{2}\b`)
+
+// sourceYear returns the year of a source's publication date, or "" if it
+// has none or it can't be read.
+func sourceYear(s models.Source) string {
+	return yearPattern.FindString(s.PublishedDate)
+}
+
+// formatBibItem renders the text of a \bibitem for s in the given style.
+// Most search results carry no authors or dates, so the site stands in for
+// the publisher and entries without a date are marked undated where the
+// style expects a year.
+func formatBibItem(style string, s models.Source) string {
+	title := latexEscaper.Replace(strings.TrimSpace(s.Title))
+	site := latexEscaper.Replace(sourceSite(s))
+	author := latexEscaper.Replace(strings.TrimSpace(s.Author))
+	year := sourceYear(s)
+	link := `\url{` + bibURLEscaper.Replace(s.Href) + `}`
+	switch style {
+	case CitationAPA:
+		date := "n.d."
+		if year != "" {
+			date = year
+		}
+		if author == "" {
+			return fmt.Sprintf(`\textit{%s}. (%s). %s. %s`, title, date, site, link)
+		}
+		return fmt.Sprintf(`%s (%s). \textit{%s}. %s. %s`, author, date, title, site, link)
+	case CitationMLA:
+		return fmt.Sprintf("%s``%s.'' \\textit{%s}%s, %s.", authorPrefix(author), title, site, yearSuffix(year), link)
+	case CitationChicago:
+		return fmt.Sprintf("%s``%s.'' %s%s. Accessed online. %s.", authorPrefix(author), title, site, yearSuffix(year), link)
+	default:
+		return fmt.Sprintf("%s``%s,'' \\textit{%s}%s. [Online]. Available: %s", authorPrefix(author), title, site, yearSuffix(year), link)
+	}
+}
+
+// authorPrefix starts a citation with its author, if known.
+func authorPrefix(author string) string {
+	if author == "" {
+		return ""
+	}
+	return author + ". "
+}
+
+// yearSuffix follows the site of a citation with its year, if known.
+func yearSuffix(year string) string {
+	if year == "" {
+		return ""
+	}
+	return ", " + year
+}
+
+// bibliography renders a thebibliography environment for sources with the
+// \cite keys source1…sourceN the report prompt uses.
+func bibliography(style string, sources []models.Source) string {
+	var b strings.Builder
+	b.WriteString("\\begin{thebibliography}{99}\n")
+	for i, s := range sources {
+		fmt.Fprintf(&b, "\\bibitem{source%d} %s\n", i+1, formatBibItem(style, s))
+	}
+	b.WriteString("\\end{thebibliography}\n")
+	return b.String()
+}
+
+// ensureBibliography appends a bibliography in the given style when the
+// model left it out of the report.
+func ensureBibliography(latexBody, style string, sources []models.Source) string {
+	if len(sources) == 0 || strings.Contains(latexBody, `\begin{thebibliography}`) {
+		return latexBody
+	}
+	return strings.TrimRight(latexBody, "\n") + "\n\n" + bibliography(style, sources)
+}
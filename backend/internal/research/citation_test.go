@@ -0,0 +1,143 @@
+package research
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestLatexEscaper(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"R&D", `R\&D`},
+		{"50% off", `50\% off`},
+		{"snake_case", `snake\_case`},
+		{"C# tips", `C\# tips`},
+		{"{braces}", `\{braces\}`},
+		{"$5", `\$5`},
+		{`a\b`, `a\textbackslash{}b`},
+		{"~^", `\textasciitilde{}\textasciicircum{}`},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := latexEscaper.Replace(tt.in); got != tt.want {
+			t.Errorf("escape %q = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBibItem(t *testing.T) {
+	src := models.Source{Title: "Solar & wind", Href: "https://www.example.org/energy"}
+	tests := []struct{ style, want string }{
+		{CitationAPA, `\textit{Solar \& wind}. (n.d.). example.org. \url{https://www.example.org/energy}`},
+		{CitationMLA, "``Solar \\& wind.'' \\textit{example.org}, \\url{https://www.example.org/energy}."},
+		{CitationChicago, "``Solar \\& wind.'' example.org. Accessed online. \\url{https://www.example.org/energy}."},
+		{CitationIEEE, "``Solar \\& wind,'' \\textit{example.org}. [Online]. Available: \\url{https://www.example.org/energy}"},
+	}
+	for _, tt := range tests {
+		if got := formatBibItem(tt.style, src); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBibItemWithMetadata(t *testing.T) {
+	src := models.Source{
+		Title: "Solar & wind", Href: "https://www.example.org/energy",
+		Author: "Ada Lovelace", PublishedDate: "March 3, 2021", SiteName: "Energy Weekly",
+	}
+	tests := []struct{ style, want string }{
+		{CitationAPA, `Ada Lovelace (2021). \textit{Solar \& wind}. Energy Weekly. \url{https://www.example.org/energy}`},
+		{CitationMLA, "Ada Lovelace. ``Solar \\& wind.'' \\textit{Energy Weekly}, 2021, \\url{https://www.example.org/energy}."},
+		{CitationChicago, "Ada Lovelace. ``Solar \\& wind.'' Energy Weekly, 2021. Accessed online. \\url{https://www.example.org/energy}."},
+		{CitationIEEE, "Ada Lovelace. ``Solar \\& wind,'' \\textit{Energy Weekly}, 2021. [Online]. Available: \\url{https://www.example.org/energy}"},
+	}
+	for _, tt := range tests {
+		if got := formatBibItem(tt.style, src); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestBibliographyEscapes(t *testing.T) {
+	for _, style := range []string{CitationAPA, CitationMLA, CitationIEEE, CitationChicago} {
+		t.Run(style, func(t *testing.T) {
+			item := formatBibItem(style, awkward)
+			for _, want := range []string{
+				`Costs \& Benefits: 100\% of \{solar\}\_panels \#1`,
+				`\url{https://www.r-and-d.example.org/a_b?x=1&y=2#top%7D}`,
+			} {
+				if !strings.Contains(item, want) {
+					t.Errorf("missing %q in %q", want, item)
+				}
+			}
+			if !balancedBraces(bibliography(style, []models.Source{awkward})) {
+				t.Errorf("unbalanced braces in %q", item)
+			}
+		})
+	}
+}
+
+func TestSourceYear(t *testing.T) {
+	for date, want := range map[string]string{
+		"2021-03-03":         "2021",
+		"March 3, 2021":      "2021",
+		"3 days ago":         "",
+		"":                   "",
+		"Published in 1999.": "1999",
+		"ISBN 12345":         "",
+	} {
+		if got := sourceYear(models.Source{PublishedDate: date}); got != want {
+			t.Errorf("sourceYear(%q) = %q, want %q", date, got, want)
+		}
+	}
+}
+
+func TestEnsureBibliography(t *testing.T) {
+	sources := []models.Source{{Title: "One", Href: "https://example.org/1"}, {Title: "Two", Href: "https://example.org/2"}}
+
+	got := ensureBibliography("\\section{Intro}\n", CitationAPA, sources)
+	for _, want := range []string{`\begin{thebibliography}{99}`, `\bibitem{source1} \textit{One}`, `\bibitem{source2} \textit{Two}`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	existing := "\\section{Intro}\n\\begin{thebibliography}{9}\n\\end{thebibliography}\n"
+	if got := ensureBibliography(existing, CitationAPA, sources); got != existing {
+		t.Errorf("rewrote an existing bibliography:\n%s", got)
+	}
+	if got := ensureBibliography("body", CitationAPA, nil); got != "body" {
+		t.Errorf("added a bibliography without sources: %q", got)
+	}
+}
+
+func TestCreateCitationStyle(t *testing.T) {
+	env := newTestEnv(t, nil)
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", CitationStyle: "MLA"})
+	if doc.CitationStyle != CitationMLA {
+		t.Errorf("stored style %q, want mla", doc.CitationStyle)
+	}
+	if got := env.ai.bodies["/api/generate-report"][0]["citation_style"]; got != CitationMLA {
+		t.Errorf("generation got citation_style %v, want mla", got)
+	}
+	if want := "``Solar panel efficiency.'' \\textit{example.edu}"; !strings.Contains(doc.LatexContent, want) {
+		t.Errorf("bibliography not in MLA style:\n%s", doc.LatexContent)
+	}
+
+	doc = env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	if doc.CitationStyle != DefaultCitationStyle {
+		t.Errorf("default style %q, want %q", doc.CitationStyle, DefaultCitationStyle)
+	}
+	if want := "[Online]. Available:"; !strings.Contains(doc.LatexContent, want) {
+		t.Errorf("bibliography not in IEEE style:\n%s", doc.LatexContent)
+	}
+
+	w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a",
+		models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", CitationStyle: "harvard"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown style: got %d, want 400", w.Code)
+	}
+}
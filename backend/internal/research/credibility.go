@@ -0,0 +1,68 @@
+package research
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// DefaultCredibleDomains are reputable publishers scored above unknown sites.
+var DefaultCredibleDomains = []string{
+	"arxiv.org", "nature.com", "science.org", "springer.com", "sciencedirect.com",
+	"ieee.org", "acm.org", "nih.gov", "who.int", "wikipedia.org", "reuters.com",
+}
+
+// lowCredibilityDomains host mostly self-published content.
+var lowCredibilityDomains = []string{
+	"medium.com", "blogspot.com", "wordpress.com", "substack.com", "tumblr.com", "quora.com",
+}
+
+// scoreSourceCredibility rates a source from 0 to 1 by domain reputation:
+// academic and government domains highest, then the trusted list, then other
+// organisations, with blogging platforms lowest.
+func scoreSourceCredibility(s models.Source, trusted []string) float64 {
+	u, err := url.Parse(s.Href)
+	if err != nil || u.Hostname() == "" {
+		return 0
+	}
+	host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+
+	switch {
+	case strings.HasSuffix(host, ".edu"), strings.HasSuffix(host, ".gov"),
+		strings.Contains(host, ".ac."), strings.HasSuffix(host, ".mil"):
+		return 0.9
+	case matchesDomain(host, trusted):
+		return 0.8
+	case matchesDomain(host, lowCredibilityDomains):
+		return 0.2
+	case strings.HasSuffix(host, ".org"):
+		return 0.6
+	default:
+		return 0.4
+	}
+}
+
+// matchesDomain reports whether host is one of domains or a subdomain of one.
+func matchesDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreSources sets Credibility on each source and drops those scoring below
+// minScore. A zero minScore keeps every source.
+func scoreSources(sources []models.Source, trusted []string, minScore float64) []models.Source {
+	kept := sources[:0]
+	for _, s := range sources {
+		s.Credibility = scoreSourceCredibility(s, trusted)
+		if s.Credibility >= minScore {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
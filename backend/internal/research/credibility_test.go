@@ -0,0 +1,84 @@
+package research
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestScoreSourceCredibility(t *testing.T) {
+	tests := []struct {
+		href string
+		want float64
+	}{
+		{"https://cs.stanford.edu/paper", 0.9},
+		{"https://www.nasa.gov/solar", 0.9},
+		{"https://www.ox.ac.uk/research", 0.9},
+		{"https://arxiv.org/abs/2401.00001", 0.8},
+		{"https://www.nature.com/articles/x", 0.8},
+		{"https://en.wikipedia.org/wiki/Solar_panel", 0.8},
+		{"https://someone.medium.com/post", 0.2},
+		{"https://solar.blogspot.com/", 0.2},
+		{"https://www.seia.org/", 0.6},
+		{"https://example.com/", 0.4},
+		{"not a url", 0},
+	}
+	for _, tt := range tests {
+		if got := scoreSourceCredibility(models.Source{Href: tt.href}, DefaultCredibleDomains); got != tt.want {
+			t.Errorf("score(%s) = %v, want %v", tt.href, got, tt.want)
+		}
+	}
+
+	trusted := []string{"Example.com"}
+	if got := scoreSourceCredibility(models.Source{Href: "https://blog.example.com/"}, trusted); got != 0.8 {
+		t.Errorf("configured domain scored %v, want 0.8", got)
+	}
+}
+
+func TestScoreSourcesFilters(t *testing.T) {
+	sources := func() []models.Source {
+		return []models.Source{
+			{Href: "https://mit.edu/a"},
+			{Href: "https://example.com/b"},
+			{Href: "https://x.medium.com/c"},
+		}
+	}
+
+	all := scoreSources(sources(), DefaultCredibleDomains, 0)
+	if len(all) != 3 {
+		t.Fatalf("zero threshold kept %d sources, want 3", len(all))
+	}
+	if all[0].Credibility != 0.9 || all[2].Credibility != 0.2 {
+		t.Errorf("scores not set: %+v", all)
+	}
+
+	kept := scoreSources(sources(), DefaultCredibleDomains, 0.4)
+	if len(kept) != 2 || kept[0].Href != "https://mit.edu/a" || kept[1].Href != "https://example.com/b" {
+		t.Errorf("threshold 0.4 kept %+v", kept)
+	}
+}
+
+func TestCreateMinCredibility(t *testing.T) {
+	env := newTestEnv(t, nil)
+
+	// example.edu scores 0.9 and example.org 0.6.
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", MinCredibility: 0.7})
+	if len(doc.Sources) != 1 || doc.Sources[0].Href != "https://example.edu/solar" || doc.Sources[0].Credibility != 0.9 {
+		t.Errorf("sources = %+v, want only the .edu one", doc.Sources)
+	}
+
+	doc = env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", MinCredibility: 0.95})
+	if doc.Status != models.StatusFailed || doc.Step != StepSearching {
+		t.Errorf("got status %q at %q, want failed while searching", doc.Status, doc.Step)
+	}
+	if n := env.ai.called("/api/generate-report"); n != 1 {
+		t.Errorf("generate-report called %d times, want only for the first report", n)
+	}
+
+	w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a",
+		models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", MinCredibility: 1.5}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("min_credibility 1.5: got %d, want 400", w.Code)
+	}
+}
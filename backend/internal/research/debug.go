@@ -0,0 +1,183 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+const redacted = "[REDACTED]"
+
+// DebugCapture is the full record of one research run, kept for support
+// when a user reports a problem. It never contains the provider API key.
+type DebugCapture struct {
+	DocID      string               `json:"doc_id"`
+	UserID     string               `json:"user_id"`
+	Request    models.CreateRequest `json:"request"`
+	RawQueries []string             `json:"raw_queries"`
+	Queries    []string             `json:"queries"`
+	RawSources []models.Source      `json:"raw_sources"`
+	LatexBody  string               `json:"latex_body"`
+	Status     string               `json:"status"`
+	Error      string               `json:"error,omitempty"`
+	CapturedAt time.Time            `json:"captured_at"`
+}
+
+// DebugStore keeps opt-in debug captures in Redis. Capture only happens
+// while an admin has enabled it for the user AND the user has consented,
+// and captures expire after a short retention.
+type DebugStore struct {
+	rdb       *redis.Client
+	retention time.Duration
+	maxWindow time.Duration
+}
+
+func NewDebugStore(rdb *redis.Client, retention, maxWindow time.Duration) *DebugStore {
+	return &DebugStore{rdb: rdb, retention: retention, maxWindow: maxWindow}
+}
+
+// Enable turns on capture for userID for d, capped at the configured window.
+func (s *DebugStore) Enable(ctx context.Context, userID string, d time.Duration) (time.Duration, error) {
+	if d <= 0 || d > s.maxWindow {
+		d = s.maxWindow
+	}
+	return d, s.rdb.Set(ctx, "debug_capture:"+userID, "1", d).Err()
+}
+
+// Disable turns off capture for userID.
+func (s *DebugStore) Disable(ctx context.Context, userID string) error {
+	return s.rdb.Del(ctx, "debug_capture:"+userID).Err()
+}
+
+// SetConsent records whether userID agrees to have runs captured.
+func (s *DebugStore) SetConsent(ctx context.Context, userID string, consent bool) error {
+	if !consent {
+		return s.rdb.Del(ctx, "debug_consent:"+userID).Err()
+	}
+	return s.rdb.Set(ctx, "debug_consent:"+userID, "1", 0).Err()
+}
+
+// Active reports whether runs for userID should be captured right now.
+func (s *DebugStore) Active(ctx context.Context, userID string) bool {
+	if s == nil {
+		return false
+	}
+	n, err := s.rdb.Exists(ctx, "debug_capture:"+userID, "debug_consent:"+userID).Result()
+	return err == nil && n == 2
+}
+
+// Save stores a capture with secrets redacted.
+func (s *DebugStore) Save(ctx context.Context, c *DebugCapture) error {
+	apiKey := c.Request.APIKey
+	if apiKey != "" {
+		c.Request.APIKey = redacted
+		c.Error = strings.ReplaceAll(c.Error, apiKey, redacted)
+	}
+	c.CapturedAt = time.Now().UTC()
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, "debug_run:"+c.DocID, payload, s.retention).Err()
+}
+
+// Get returns the capture for a document, or nil if none is retained.
+func (s *DebugStore) Get(ctx context.Context, docID string) (*DebugCapture, error) {
+	payload, err := s.rdb.Get(ctx, "debug_run:"+docID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c DebugCapture
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// saveCapture finalises a capture with the job outcome and stores it.
+func (h *Handler) saveCapture(ctx context.Context, c *DebugCapture) {
+	if doc, err := h.mongo.GetByID(ctx, c.DocID); err == nil {
+		c.Status, c.Error = doc.Status, doc.Error
+	}
+	if err := h.opts.Debug.Save(ctx, c); err != nil {
+		log.Printf("debug capture %s: %v", c.DocID, err)
+	}
+}
+
+// EnableDebug lets an admin turn on debug capture for a user.
+func (h *Handler) EnableDebug(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	var req struct {
+		Duration string `json:"duration"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	d, _ := time.ParseDuration(req.Duration)
+
+	d, err := h.opts.Debug.Enable(r.Context(), userID, d)
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to enable debug capture")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"user_id":    userID,
+		"expires_at": time.Now().Add(d).UTC().Format(time.RFC3339),
+	})
+}
+
+// DisableDebug lets an admin turn off debug capture for a user.
+func (h *Handler) DisableDebug(w http.ResponseWriter, r *http.Request) {
+	if err := h.opts.Debug.Disable(r.Context(), chi.URLParam(r, "userID")); err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to disable debug capture")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"message":"debug capture disabled"}`))
+}
+
+// GetDebugRun returns the retained capture of a research run.
+func (h *Handler) GetDebugRun(w http.ResponseWriter, r *http.Request) {
+	c, err := h.opts.Debug.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "debug store error")
+		return
+	}
+	if c == nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "no capture for this run")
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// SetDebugConsent lets the current user grant or withdraw consent to
+// having their runs captured for debugging.
+func (h *Handler) SetDebugConsent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Consent bool `json:"consent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	if err := h.opts.Debug.SetConsent(r.Context(), userID, req.Consent); err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to save consent")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"consent": req.Consent})
+}
@@ -0,0 +1,120 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func newTestDebugStore(t *testing.T) *DebugStore {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return NewDebugStore(rdb, time.Hour, 24*time.Hour)
+}
+
+func TestDebugCaptureNeedsAdminAndConsent(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name            string
+		enable, consent bool
+		want            bool
+	}{
+		{"neither", false, false, false},
+		{"admin only", true, false, false},
+		{"consent only", false, true, false},
+		{"both", true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debug := newTestDebugStore(t)
+			if tt.enable {
+				if _, err := debug.Enable(ctx, "user-a", time.Hour); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := debug.SetConsent(ctx, "user-a", tt.consent); err != nil {
+				t.Fatal(err)
+			}
+			env := newTestEnv(t, &Options{Debug: debug})
+
+			doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-secret"})
+			c, err := debug.Get(ctx, doc.ID.Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c != nil; got != tt.want {
+				t.Fatalf("captured = %v, want %v", got, tt.want)
+			}
+			if c == nil {
+				return
+			}
+			if c.Request.APIKey != redacted {
+				t.Errorf("api key stored as %q", c.Request.APIKey)
+			}
+			if c.Status != models.StatusComplete || len(c.Queries) != 2 || len(c.RawSources) != 2 || c.LatexBody != env.ai.report {
+				t.Errorf("incomplete capture: %+v", c)
+			}
+		})
+	}
+}
+
+func TestDebugCaptureRedactsErrors(t *testing.T) {
+	ctx := context.Background()
+	debug := newTestDebugStore(t)
+	c := &DebugCapture{
+		DocID:   "doc-1",
+		Request: models.CreateRequest{Topic: "t", APIKey: "sk-secret"},
+		Error:   "upstream rejected key sk-secret",
+	}
+	if err := debug.Save(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	got, err := debug.Get(ctx, "doc-1")
+	if err != nil || got == nil {
+		t.Fatalf("Get: %v, %v", got, err)
+	}
+	if got.Request.APIKey != redacted || strings.Contains(got.Error, "sk-secret") {
+		t.Errorf("secret kept: key %q, error %q", got.Request.APIKey, got.Error)
+	}
+}
+
+func TestDebugEndpoints(t *testing.T) {
+	debug := newTestDebugStore(t)
+	env := newTestEnv(t, &Options{Debug: debug})
+
+	w := serve(env.h.SetDebugConsent, newRequest(t, http.MethodPut, "/user/debug-consent", "user-a", map[string]bool{"consent": true}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("consent: got %d %s", w.Code, w.Body)
+	}
+	w = serve(env.h.EnableDebug, newRequest(t, http.MethodPost, "/admin/debug/users/user-a", "admin", map[string]string{"duration": "1h"}, "userID", "user-a"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("enable: got %d %s", w.Code, w.Body)
+	}
+	if !debug.Active(context.Background(), "user-a") {
+		t.Fatal("capture not active after consent and enable")
+	}
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-secret"})
+	w = serve(env.h.GetDebugRun, newRequest(t, http.MethodGet, "/admin/debug/runs/x", "admin", nil, "id", doc.ID.Hex()))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "sk-secret") {
+		t.Errorf("get run: got %d %s", w.Code, w.Body)
+	}
+
+	w = serve(env.h.DisableDebug, newRequest(t, http.MethodDelete, "/admin/debug/users/user-a", "admin", nil, "userID", "user-a"))
+	if w.Code != http.StatusOK || debug.Active(context.Background(), "user-a") {
+		t.Errorf("disable: got %d, still active = %v", w.Code, debug.Active(context.Background(), "user-a"))
+	}
+	w = serve(env.h.GetDebugRun, newRequest(t, http.MethodGet, "/admin/debug/runs/x", "admin", nil, "id", "000000000000000000000000"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing run: got %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,181 @@
+package research
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Source deduplication modes, from least to most aggressive.
+const (
+	DedupNone  = "none"  // keep every result
+	DedupURL   = "url"   // drop results whose normalized URLs match
+	DedupFuzzy = "fuzzy" // also drop results with near-identical titles
+)
+
+// DefaultDedupThreshold is the title similarity above which fuzzy mode
+// treats two sources as duplicates.
+const DefaultDedupThreshold = 0.85
+
+// validDedupMode reports whether mode is a known deduplication mode.
+func validDedupMode(mode string) bool {
+	switch mode {
+	case DedupNone, DedupURL, DedupFuzzy:
+		return true
+	}
+	return false
+}
+
+// DedupeSources drops sources whose normalized Href was already seen,
+// keeping the first title and body found for each URL. It is the URL
+// dedup mode that collectSources applies between search and report
+// generation by default.
+func DedupeSources(sources []models.Source) []models.Source {
+	return dedupeSources(sources, DedupURL, 0)
+}
+
+// dedupeSources drops duplicate sources according to mode, keeping the
+// first occurrence of each.
+func dedupeSources(sources []models.Source, mode string, threshold float64) []models.Source {
+	if mode == DedupNone {
+		return sources
+	}
+	out := make([]models.Source, 0, len(sources))
+	seen := make(map[string]bool, len(sources))
+	var titles [][]string // bigrams of kept titles, for fuzzy mode
+outer:
+	for _, s := range sources {
+		key := normalizeHref(s.Href)
+		if key != "" && seen[key] {
+			continue
+		}
+		var grams []string
+		if mode == DedupFuzzy {
+			grams = bigrams(s.Title)
+			for _, t := range titles {
+				if diceSimilarity(grams, t) >= threshold {
+					continue outer
+				}
+			}
+		}
+		if key != "" {
+			seen[key] = true
+		}
+		if mode == DedupFuzzy {
+			titles = append(titles, grams)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// queryDupThreshold is the similarity above which DedupeQueries treats
+// two queries as the same search.
+const queryDupThreshold = 0.9
+
+// DedupeQueries trims queries, collapses their inner whitespace, and drops
+// empty ones and those that only differ from an earlier query in case,
+// punctuation, word order or a character or two. The first of each
+// duplicate is kept as written.
+func DedupeQueries(queries []string) []string {
+	out := make([]string, 0, len(queries))
+	seen := make(map[string]bool, len(queries))
+	var kept [][]string // bigrams of kept queries
+outer:
+	for _, q := range queries {
+		q = strings.Join(strings.Fields(q), " ")
+		key := queryKey(q)
+		if key == "" || seen[key] {
+			continue
+		}
+		grams := bigrams(key)
+		for _, k := range kept {
+			if diceSimilarity(grams, k) >= queryDupThreshold {
+				continue outer
+			}
+		}
+		seen[key] = true
+		kept = append(kept, grams)
+		out = append(out, q)
+	}
+	return out
+}
+
+// queryKey reduces a query to its sorted lowercase words without
+// surrounding punctuation.
+func queryKey(q string) string {
+	var words []string
+	for _, w := range strings.Fields(strings.ToLower(q)) {
+		if w = strings.Trim(w, ".,;:!?\"'()[]|-–—"); w != "" {
+			words = append(words, w)
+		}
+	}
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// normalizeHref reduces a URL to a comparison key: the scheme and "www."
+// are dropped, the host is lowercased, tracking parameters, fragments and
+// trailing slashes are removed.
+func normalizeHref(href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(strings.TrimSpace(href), "/")
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	q := u.Query()
+	for k := range q {
+		if isTrackingParam(k) {
+			q.Del(k)
+		}
+	}
+	key := host + strings.TrimRight(u.EscapedPath(), "/")
+	if enc := q.Encode(); enc != "" {
+		key += "?" + enc
+	}
+	return key
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	switch key {
+	case "fbclid", "gclid", "msclkid", "ref", "ref_src":
+		return true
+	}
+	return strings.HasPrefix(key, "utm_")
+}
+
+// bigrams returns the character bigrams of the lowercased words of s.
+func bigrams(s string) []string {
+	var grams []string
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		w = strings.Trim(w, ".,;:!?\"'()[]|-–—")
+		r := []rune(w)
+		for i := 0; i+1 < len(r); i++ {
+			grams = append(grams, string(r[i:i+2]))
+		}
+	}
+	return grams
+}
+
+// diceSimilarity is the Sørensen–Dice coefficient of two bigram multisets,
+// from 0 (nothing shared) to 1 (identical).
+func diceSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	counts := make(map[string]int, len(a))
+	for _, g := range a {
+		counts[g]++
+	}
+	shared := 0
+	for _, g := range b {
+		if counts[g] > 0 {
+			counts[g]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
@@ -0,0 +1,164 @@
+package research
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// nearDuplicates has one exact URL repeat (modulo tracking parameters and
+// "www.") and one near-identical title on a different URL.
+var nearDuplicates = []models.Source{
+	{Title: "Solar panel efficiency in 2024", Body: solarSnippet, Href: "https://example.edu/solar"},
+	{Title: "Solar panel efficiency in 2024", Body: solarSnippet, Href: "https://www.example.edu/solar/?utm_source=feed"},
+	{Title: "Solar Panel Efficiency in 2024 | News", Body: solarSnippet, Href: "https://news.example.com/solar-efficiency"},
+	{Title: "The cost of wind power", Body: "Onshore wind is now among the cheapest sources of new power.", Href: "https://example.org/wind"},
+}
+
+const solarSnippet = "Commercial solar panels now convert over 22% of sunlight into power."
+
+func TestDedupeSources(t *testing.T) {
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{DedupNone, []string{
+			"https://example.edu/solar",
+			"https://www.example.edu/solar/?utm_source=feed",
+			"https://news.example.com/solar-efficiency",
+			"https://example.org/wind",
+		}},
+		{DedupURL, []string{
+			"https://example.edu/solar",
+			"https://news.example.com/solar-efficiency",
+			"https://example.org/wind",
+		}},
+		{DedupFuzzy, []string{
+			"https://example.edu/solar",
+			"https://example.org/wind",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got := dedupeSources(nearDuplicates, tt.mode, DefaultDedupThreshold)
+			var hrefs []string
+			for _, s := range got {
+				hrefs = append(hrefs, s.Href)
+			}
+			if len(hrefs) != len(tt.want) {
+				t.Fatalf("got %v, want %v", hrefs, tt.want)
+			}
+			for i := range hrefs {
+				if hrefs[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", hrefs, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiceSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"Solar panel efficiency", "solar panel efficiency", 1, 1},
+		{"Solar panel efficiency in 2024", "Solar Panel Efficiency in 2024 | News", DefaultDedupThreshold, 1},
+		{"Solar panel efficiency", "The cost of wind power", 0, 0.3},
+		{"", "anything", 0, 0},
+	}
+	for _, tt := range tests {
+		got := diceSimilarity(bigrams(tt.a), bigrams(tt.b))
+		if got < tt.min || got > tt.max {
+			t.Errorf("similarity(%q, %q) = %.2f, want between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestCreateDedupMode(t *testing.T) {
+	env := newTestEnv(t, &Options{DedupMode: DedupFuzzy})
+	env.ai.sources = nearDuplicates
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	if doc.Dedup != DedupFuzzy || len(doc.Sources) != 2 {
+		t.Errorf("default mode: got dedup %q with %d sources, want fuzzy with 2", doc.Dedup, len(doc.Sources))
+	}
+	doc = env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Dedup: DedupNone})
+	if doc.Dedup != DedupNone || len(doc.Sources) != len(nearDuplicates) {
+		t.Errorf("dedup none: got dedup %q with %d sources, want none with %d", doc.Dedup, len(doc.Sources), len(nearDuplicates))
+	}
+
+	w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a",
+		models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Dedup: "exact"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown mode: got %d, want 400", w.Code)
+	}
+}
+
+func TestNormalizeHref(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"https://example.org/page", "https://example.org/page?utm_source=news&utm_medium=email"},
+		{"https://example.org/page", "https://EXAMPLE.org/page/"},
+		{"https://example.org/page", "http://www.example.org/page"},
+		{"https://example.org/page?id=7", "https://example.org/page?utm_campaign=x&id=7"},
+		{"https://example.org/page", "https://example.org/page?fbclid=abc#section"},
+	}
+	for _, tt := range tests {
+		if ka, kb := normalizeHref(tt.a), normalizeHref(tt.b); ka != kb {
+			t.Errorf("%s -> %q but %s -> %q, want the same key", tt.a, ka, tt.b, kb)
+		}
+	}
+	if normalizeHref("https://example.org/page?id=7") == normalizeHref("https://example.org/page?id=8") {
+		t.Error("URLs differing in a real query parameter share a key")
+	}
+}
+
+func TestDedupeSourcesKeepsFirst(t *testing.T) {
+	sources := []models.Source{
+		{Title: "First", Body: "first body", Href: "https://example.org/report?utm_source=google"},
+		{Title: "Other", Body: "other body", Href: "https://example.org/other"},
+		{Title: "Second", Body: "second body", Href: "https://example.org/report?utm_source=bing&utm_medium=cpc"},
+		{Title: "Third", Body: "third body", Href: "https://example.org/report/"},
+	}
+	got := DedupeSources(sources)
+	if len(got) != 2 || got[0].Title != "First" || got[0].Body != "first body" || got[1].Title != "Other" {
+		t.Errorf("got %+v, want First and Other", got)
+	}
+}
+
+func TestDedupeQueries(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []string
+		want    []string
+	}{
+		{"none", nil, []string{}},
+		{"distinct", []string{"solar panel cost", "wind turbine noise"}, []string{"solar panel cost", "wind turbine noise"}},
+		{"exact", []string{"solar panel cost", "solar panel cost"}, []string{"solar panel cost"}},
+		{"case and punctuation", []string{"Solar Panel Cost", "solar panel cost?", "\"solar panel cost\""}, []string{"Solar Panel Cost"}},
+		{"word order", []string{"solar panel cost 2024", "2024 solar panel cost"}, []string{"solar panel cost 2024"}},
+		{"typo", []string{"solar panel efficiency ratings", "solar panel efficiency rating"}, []string{"solar panel efficiency ratings"}},
+		{"whitespace", []string{"", "   ", "\t\n", "  solar   panel\tcost  "}, []string{"solar panel cost"}},
+		{"punctuation only", []string{"?!", "--"}, []string{}},
+		{"similar but different", []string{"solar panel cost in Germany", "solar panel cost in Spain"}, []string{"solar panel cost in Germany", "solar panel cost in Spain"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DedupeQueries(tt.queries); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateDedupesQueries(t *testing.T) {
+	env := newTestEnv(t, nil)
+	env.ai.queries = []string{"solar panel cost", "Solar panel cost", " ", "solar panel efficiency"}
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", MaxQueries: 2})
+	if want := []string{"solar panel cost", "solar panel efficiency"}; !slices.Equal(doc.SearchQueries, want) {
+		t.Errorf("searched %q, want %q", doc.SearchQueries, want)
+	}
+}
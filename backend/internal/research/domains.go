@@ -0,0 +1,61 @@
+package research
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// maxDomainFilters caps each of a request's allowed and blocked lists.
+const maxDomainFilters = 100
+
+const msgNoSourcesAfterDomainFilter = "No sources survived the allowed/blocked domain filter."
+
+// FilterSourcesByDomain keeps the sources whose host is one of allowed (or
+// a subdomain of one) and not one of blocked. An empty allowed list admits
+// every domain; blocked always wins over allowed.
+func FilterSourcesByDomain(sources []models.Source, allowed, blocked []string) []models.Source {
+	if len(allowed) == 0 && len(blocked) == 0 {
+		return sources
+	}
+	kept := make([]models.Source, 0, len(sources))
+	for _, s := range sources {
+		u, err := url.Parse(s.Href)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+		if matchesDomain(host, blocked) {
+			continue
+		}
+		if len(allowed) > 0 && !matchesDomain(host, allowed) {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return kept
+}
+
+// normalizeDomains reduces entries such as "https://www.Example.com/" to
+// bare lowercase hosts, dropping empties and duplicates.
+func normalizeDomains(domains []string) []string {
+	out := make([]string, 0, len(domains))
+	seen := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if i := strings.Index(d, "://"); i >= 0 {
+			d = d[i+3:]
+		}
+		if i := strings.IndexAny(d, "/?#"); i >= 0 {
+			d = d[:i]
+		}
+		d = strings.TrimPrefix(d, "www.")
+		if d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		out = append(out, d)
+	}
+	return out
+}
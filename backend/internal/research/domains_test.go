@@ -0,0 +1,113 @@
+package research
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestDomains(t *testing.T) {
+	env := newTestEnv(t, nil)
+	src := func(href string) models.Source { return models.Source{Title: href, Href: href} }
+	env.insert(t, &models.Document{UserID: "user-a", Topic: "solar", Sources: []models.Source{
+		src("https://example.edu/a"), src("https://www.example.edu/b"), src("https://example.org/c"),
+	}})
+	env.insert(t, &models.Document{UserID: "user-a", Topic: "wind", Sources: []models.Source{
+		src("https://example.org/d"), src("https://example.edu/e"), src("not a url"),
+	}})
+	env.insert(t, &models.Document{UserID: "user-b", Topic: "other", Sources: []models.Source{
+		src("https://example.com/f"),
+	}})
+
+	w := serve(env.h.Domains, newRequest(t, http.MethodGet, "/user/domains", "user-a", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var got []models.DomainCount
+	decode(t, w, &got)
+	want := []models.DomainCount{
+		{Domain: "example.edu", Citations: 3, Documents: 2},
+		{Domain: "example.org", Citations: 2, Documents: 2},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	w = serve(env.h.Domains, newRequest(t, http.MethodGet, "/user/domains", "user-c", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "[]\n" {
+		t.Errorf("user without sources: got %d %s, want an empty list", w.Code, w.Body)
+	}
+}
+
+func TestListFiltersByDomain(t *testing.T) {
+	env := newTestEnv(t, nil)
+	env.insert(t, &models.Document{UserID: "user-a", Topic: "edu", Sources: []models.Source{{Href: "https://www.example.edu/a"}}})
+	env.insert(t, &models.Document{UserID: "user-a", Topic: "org", Sources: []models.Source{{Href: "https://example.org/b"}}})
+
+	w := serve(env.h.List, newRequest(t, http.MethodGet, "/research/?domain=example.edu", "user-a", nil))
+	var resp listResponse
+	decode(t, w, &resp)
+	if len(resp.Documents) != 1 || resp.Documents[0].Topic != "edu" {
+		t.Errorf("listed %+v, want only the document citing example.edu", resp.Documents)
+	}
+}
+
+func TestFilterSourcesByDomain(t *testing.T) {
+	sources := []models.Source{
+		{Href: "https://example.edu/a"},
+		{Href: "https://www.news.example.edu/b"},
+		{Href: "https://example.org/c"},
+		{Href: "https://spam.example.org/d"},
+		{Href: "not a url"},
+	}
+	tests := []struct {
+		name             string
+		allowed, blocked []string
+		want             []string
+	}{
+		{"no filters", nil, nil, []string{"https://example.edu/a", "https://www.news.example.edu/b", "https://example.org/c", "https://spam.example.org/d", "not a url"}},
+		{"allowed with subdomains", []string{"example.edu"}, nil, []string{"https://example.edu/a", "https://www.news.example.edu/b"}},
+		{"blocked subdomain", nil, []string{"spam.example.org"}, []string{"https://example.edu/a", "https://www.news.example.edu/b", "https://example.org/c"}},
+		{"blocked wins over allowed", []string{"example.org"}, []string{"spam.example.org"}, []string{"https://example.org/c"}},
+		{"nothing survives", []string{"example.com"}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, s := range FilterSourcesByDomain(sources, tt.allowed, tt.blocked) {
+				got = append(got, s.Href)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDomains(t *testing.T) {
+	got := normalizeDomains([]string{"https://www.Example.com/path", " example.com ", "", "sub.example.org?x=1"})
+	if want := []string{"example.com", "sub.example.org"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCreateDomainFilter(t *testing.T) {
+	env := newTestEnv(t, &Options{BlockedDomains: []string{"example.org"}})
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	if len(doc.Sources) != 1 || doc.Sources[0].Href != "https://example.edu/solar" {
+		t.Errorf("sources = %+v, want only the example.edu one", doc.Sources)
+	}
+
+	req := models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", AllowedDomains: []string{"example.org"}}
+	doc = env.create(t, "user-a", req)
+	if doc.Status != models.StatusFailed || doc.Error != msgNoSourcesAfterDomainFilter {
+		t.Errorf("got status %q error %q, want failed with the domain filter message", doc.Status, doc.Error)
+	}
+	w := serve(env.h.Preview, newRequest(t, http.MethodPost, "/research/preview", "user-a", req))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("preview: got %d %s, want 422", w.Code, w.Body)
+	}
+}
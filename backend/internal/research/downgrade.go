@@ -0,0 +1,81 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ModelHealth tracks consecutive timeouts per model in Redis and, once a
+// model crosses the threshold within the window, downgrades requests for it
+// to a faster fallback model until a call succeeds again.
+type ModelHealth struct {
+	rdb       *redis.Client
+	fallback  string
+	threshold int64
+	window    time.Duration
+}
+
+func NewModelHealth(rdb *redis.Client, fallback string, threshold int, window time.Duration) *ModelHealth {
+	return &ModelHealth{rdb: rdb, fallback: fallback, threshold: int64(threshold), window: window}
+}
+
+func modelTimeoutKey(model string) string {
+	return "model_timeouts:" + model
+}
+
+// Resolve returns the model to use for a request and whether it was
+// substituted because the requested one keeps timing out.
+func (m *ModelHealth) Resolve(ctx context.Context, model string) (string, bool) {
+	if m == nil || m.fallback == "" || m.fallback == model {
+		return model, false
+	}
+	n, err := m.rdb.Get(ctx, modelTimeoutKey(model)).Int64()
+	if err != nil || n < m.threshold {
+		return model, false
+	}
+	log.Printf("model %s timed out %d times in a row, downgrading to %s", model, n, m.fallback)
+	return m.fallback, true
+}
+
+// Observe records the outcome of an AI call made with model: timeouts bump
+// the counter, successes reset it, and other errors leave it alone.
+func (m *ModelHealth) Observe(ctx context.Context, model string, err error) {
+	if m == nil {
+		return
+	}
+	key := modelTimeoutKey(model)
+	switch {
+	case err == nil:
+		if err := m.rdb.Del(ctx, key).Err(); err != nil {
+			log.Printf("model health reset %s: %v", model, err)
+		}
+	case isTimeout(err):
+		n, err := m.rdb.Incr(ctx, key).Result()
+		if err != nil {
+			log.Printf("model health incr %s: %v", model, err)
+			return
+		}
+		if n == 1 {
+			m.rdb.Expire(ctx, key, m.window)
+		}
+	}
+}
+
+// isTimeout reports whether err is a client-side timeout or an upstream
+// gateway timeout from the AI service.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return strings.Contains(err.Error(), " returned 504")
+}
@@ -0,0 +1,65 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func newTestModelHealth(t *testing.T, threshold int) (*ModelHealth, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return NewModelHealth(rdb, "mistral-small-latest", threshold, time.Minute), mr
+}
+
+func TestModelHealth(t *testing.T) {
+	ctx := context.Background()
+	m, mr := newTestModelHealth(t, 2)
+	timeout := fmt.Errorf("AI service /api/generate-report returned 504: gateway timeout")
+
+	m.Observe(ctx, "mistral-large-latest", timeout)
+	if model, sub := m.Resolve(ctx, "mistral-large-latest"); sub {
+		t.Fatalf("downgraded to %s after one timeout", model)
+	}
+	m.Observe(ctx, "mistral-large-latest", errors.New("AI service /api/search returned 500: boom"))
+	m.Observe(ctx, "mistral-large-latest", context.DeadlineExceeded)
+	if model, sub := m.Resolve(ctx, "mistral-large-latest"); !sub || model != "mistral-small-latest" {
+		t.Fatalf("Resolve = %s, %v after two timeouts; want the fallback", model, sub)
+	}
+	if ttl := mr.TTL(modelTimeoutKey("mistral-large-latest")); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("counter TTL = %v, want within the window", ttl)
+	}
+
+	m.Observe(ctx, "mistral-large-latest", nil)
+	if model, sub := m.Resolve(ctx, "mistral-large-latest"); sub {
+		t.Errorf("still downgraded to %s after a success", model)
+	}
+
+	var disabled *ModelHealth
+	if model, sub := disabled.Resolve(ctx, "mistral-large-latest"); sub || model != "mistral-large-latest" {
+		t.Errorf("nil ModelHealth resolved to %s, %v", model, sub)
+	}
+}
+
+func TestCreateDowngradesModel(t *testing.T) {
+	m, _ := newTestModelHealth(t, 1)
+	m.Observe(context.Background(), "mistral-large-latest", context.DeadlineExceeded)
+	env := newTestEnv(t, &Options{ModelHealth: m})
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Model: "mistral-large-latest"})
+	if doc.ModelUsed != "mistral-small-latest" || doc.RequestedModel != "mistral-large-latest" {
+		t.Errorf("model_used = %q, requested_model = %q; want the fallback recorded", doc.ModelUsed, doc.RequestedModel)
+	}
+	if got := env.ai.bodies["/api/generate-report"][0]["model"]; got != "mistral-small-latest" {
+		t.Errorf("report generated with %v, want the fallback", got)
+	}
+}
@@ -0,0 +1,49 @@
+package research
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// msgShutdown is recorded on jobs cut off by a server shutdown.
+const msgShutdown = "Interrupted by server shutdown. Regenerate the report to try again."
+
+// Drain stops new pipelines from starting; requests that would start one
+// get a 503 from then on. Running pipelines are unaffected.
+func (h *Handler) Drain() {
+	h.draining.Store(true)
+}
+
+// rejectIfDraining writes a 503 and returns true once Drain was called.
+func (h *Handler) rejectIfDraining(w http.ResponseWriter) bool {
+	if !h.draining.Load() {
+		return false
+	}
+	w.Header().Set("Retry-After", "30")
+	apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "server is shutting down, try again shortly")
+	return true
+}
+
+// Abort cancels every pipeline still running in this process and marks its
+// document failed with a shutdown reason. It returns how many were aborted.
+func (h *Handler) Abort(ctx context.Context) int {
+	h.cancelsMu.Lock()
+	running := make(map[string]*runningJob, len(h.cancels))
+	for id, j := range h.cancels {
+		running[id] = j
+	}
+	h.cancelsMu.Unlock()
+
+	for id, j := range running {
+		j.cancel()
+		if err := h.mongo.SetStatus(ctx, id, models.StatusFailed, "", msgShutdown); err != nil {
+			log.Printf("set status %s/failed error: %v", id, err)
+		}
+		h.opts.Progress.Publish(ctx, id, ProgressEvent{Status: models.StatusFailed, Error: msgShutdown})
+	}
+	return len(running)
+}
@@ -0,0 +1,105 @@
+package research
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// UpdateLatex replaces a document's LaTeX body and recompiles its PDF and
+// .tex files without re-running the AI pipeline. Nothing is stored if
+// compilation fails; the compiler's error is returned with a 422.
+func (h *Handler) UpdateLatex(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		LatexContent string `json:"latex_content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.LatexContent) == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "latex_content is required")
+		return
+	}
+	if err := validateLatexBody(req.LatexContent, h.opts.MaxLatexBytes); err != nil {
+		apierror.WriteDetails(w, http.StatusUnprocessableEntity, apierror.Unprocessable, "invalid LaTeX body", map[string]interface{}{
+			"reason": err.Error(),
+		})
+		return
+	}
+
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	if doc.Status == models.StatusPending || doc.Status == models.StatusRunning {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research is still running")
+		return
+	}
+	docID := doc.ID.Hex()
+	release, ok := h.lockDoc(w, r, docID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	pdfBytes, err := h.latexClient.CompilePDF(r.Context(), req.LatexContent, doc.Topic, PDFOptions{Watermark: doc.Watermark})
+	if err != nil {
+		writeCompileError(w, err)
+		return
+	}
+	texSource, err := h.latexClient.CompileTex(r.Context(), req.LatexContent, doc.Topic)
+	if err != nil {
+		writeCompileError(w, err)
+		return
+	}
+
+	if err := h.archiveVersion(r.Context(), doc, docID); err != nil {
+		log.Printf("archive version %s: %v", docID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to archive current version")
+		return
+	}
+
+	// Always write the canonical keys: the current ones may point at an
+	// archived version after a restore.
+	pdfKey, texKey := artifactKeys(doc, docID)
+	uploadedPDF, uploadedTex := pdfKey, texKey
+	h.uploadArtifacts(r.Context(), []artifact{
+		{key: &uploadedPDF, data: pdfBytes, contentType: "application/pdf"},
+		{key: &uploadedTex, data: []byte(texSource), contentType: "application/x-tex"},
+	})
+	if uploadedPDF == "" || uploadedTex == "" {
+		apierror.Write(w, http.StatusBadGateway, apierror.UpstreamFailure, "failed to store compiled files")
+		return
+	}
+
+	doc.LatexContent = req.LatexContent
+	doc.PDFObjectKey, doc.TexObjectKey = pdfKey, texKey
+	doc.PDFSize, doc.TexSize = int64(len(pdfBytes)), int64(len(texSource))
+	if err := h.mongo.Update(r.Context(), docID, doc); err != nil {
+		log.Printf("mongo update error: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to save research")
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// writeCompileError reports a failed compilation: a 422 with the compiler
+// output when the LaTeX service rejected the document, a 502 when it
+// couldn't be reached.
+func writeCompileError(w http.ResponseWriter, err error) {
+	var se *StatusError
+	if errors.As(err, &se) {
+		apierror.WriteDetails(w, http.StatusUnprocessableEntity, apierror.Unprocessable, "LaTeX compilation failed", map[string]interface{}{
+			"output": se.Body,
+		})
+		return
+	}
+	log.Printf("latex service error: %v", err)
+	apierror.Write(w, http.StatusBadGateway, apierror.UpstreamFailure, "latex service unavailable")
+}
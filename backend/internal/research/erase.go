@@ -0,0 +1,41 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// DeleteUserData permanently removes every document of userID, with their
+// files and versions, and the user's subscriptions. Each document is
+// handled independently, so one failure doesn't stop the rest; the
+// failures are returned together.
+func (h *Handler) DeleteUserData(ctx context.Context, userID string) error {
+	var docs []models.Document
+	err := h.mongo.EachByUser(ctx, userID, func(doc *models.Document) error {
+		docs = append(docs, *doc)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list documents: %w", err)
+	}
+
+	var errs []error
+	for i := range docs {
+		id := docs[i].ID.Hex()
+		if err := h.purge(ctx, &docs[i], id); err != nil {
+			log.Printf("delete user %s: document %s: %v", userID, id, err)
+			errs = append(errs, err)
+		}
+	}
+	if h.opts.Subscriptions != nil {
+		if err := h.opts.Subscriptions.DeleteUserSubscriptions(ctx, userID); err != nil {
+			log.Printf("delete user %s: subscriptions: %v", userID, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
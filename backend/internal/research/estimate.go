@@ -0,0 +1,132 @@
+package research
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// ModelPrice is what a model costs in USD per million tokens.
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// Pricing maps model names to their prices.
+type Pricing map[string]ModelPrice
+
+// DefaultPricing is used when Options doesn't configure prices.
+var DefaultPricing = Pricing{
+	"mistral-small-latest":  {Input: 0.1, Output: 0.3},
+	"mistral-medium-latest": {Input: 0.4, Output: 2},
+	"mistral-large-latest":  {Input: 2, Output: 6},
+}
+
+// ParsePricing reads prices written as model=input:output, e.g.
+// "mistral-large-latest=2:6".
+func ParsePricing(entries []string) (Pricing, error) {
+	pricing := make(Pricing, len(entries))
+	for _, e := range entries {
+		model, prices, ok := strings.Cut(e, "=")
+		in, out, ok2 := strings.Cut(prices, ":")
+		if !ok || !ok2 || model == "" {
+			return nil, fmt.Errorf("pricing %q: want model=input:output", e)
+		}
+		var p ModelPrice
+		var err error
+		if p.Input, err = strconv.ParseFloat(in, 64); err != nil {
+			return nil, fmt.Errorf("pricing %q: %w", e, err)
+		}
+		if p.Output, err = strconv.ParseFloat(out, 64); err != nil {
+			return nil, fmt.Errorf("pricing %q: %w", e, err)
+		}
+		pricing[strings.TrimSpace(model)] = p
+	}
+	return pricing, nil
+}
+
+// Rough token counts behind an estimate. They're deliberately on the high
+// side: the estimate exists to warn about expensive runs.
+const (
+	queryPromptTokens  = 400
+	queryOutputTokens  = 40 // per generated query
+	reportPromptTokens = 1500
+	sourceTokens       = 200 // title, snippet and URL of one source
+	tokensPerWord      = 1.4
+)
+
+// UsageEstimate is the projected work and cost of a research run.
+type UsageEstimate struct {
+	Model        string `json:"model"`
+	Queries      int    `json:"queries"`
+	Searches     int    `json:"searches"`
+	Sources      int    `json:"sources"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+	// CostUSD is omitted for models without a price.
+	CostUSD *float64 `json:"cost_usd,omitempty"`
+}
+
+// Estimate projects the queries, searches, tokens and cost of running req
+// without running anything. req should already carry its resolved length
+// targets.
+func Estimate(req models.CreateRequest, pricing Pricing) UsageEstimate {
+	est := UsageEstimate{Model: req.Model}
+	input := reportPromptTokens
+	if !req.SkipSearch {
+		est.Queries, est.Sources = depthCounts(req)
+		est.Sources *= est.Queries
+		est.Searches = est.Queries
+		input += queryPromptTokens + est.Sources*sourceTokens
+		est.OutputTokens = est.Queries * queryOutputTokens
+	}
+	est.InputTokens = input
+	est.OutputTokens += int(math.Ceil(float64(req.TargetWords) * tokensPerWord))
+	if req.MaxTokens > 0 && est.OutputTokens > req.MaxTokens {
+		est.OutputTokens = req.MaxTokens
+	}
+	if price, ok := pricing[req.Model]; ok {
+		cost := (float64(est.InputTokens)*price.Input + float64(est.OutputTokens)*price.Output) / 1e6
+		cost = math.Round(cost*1e4) / 1e4
+		est.CostUSD = &cost
+	}
+	return est
+}
+
+// EstimateUsage answers how much a create request would cost, without
+// running it or needing an API key.
+func (h *Handler) EstimateUsage(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	if req.Topic == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "topic is required")
+		return
+	}
+	if req.Model == "" {
+		req.Model = DefaultModel
+	}
+	if !h.modelAllowed(req.Model) {
+		h.writeModelNotAllowed(w)
+		return
+	}
+	if req.MaxQueries < 0 || req.MaxQueries > maxQueriesLimit ||
+		req.ResultsPerQuery < 0 || req.ResultsPerQuery > resultsPerQueryLimit {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "max_queries must be between 1 and 10 and results_per_query between 1 and 15")
+		return
+	}
+	if req.TargetWords < 0 || req.MaxTokens < 0 {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "target_words and max_tokens must not be negative")
+		return
+	}
+	req.Length, req.TargetWords, req.TargetSections = resolveLength(req.Length, req.TargetWords, req.TargetSections)
+	writeJSON(w, http.StatusOK, Estimate(req, h.opts.Pricing))
+}
@@ -0,0 +1,90 @@
+package research
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestEstimate(t *testing.T) {
+	pricing := Pricing{"priced": {Input: 1, Output: 2}}
+	tests := []struct {
+		name                      string
+		req                       models.CreateRequest
+		queries, sources          int
+		inputTokens, outputTokens int
+		cost                      float64 // negative for no price
+	}{
+		{"standard", models.CreateRequest{Model: "priced", TargetWords: 1000}, 4, 20, 5900, 1560, 0.009},
+		{"deep", models.CreateRequest{Model: "priced", Depth: "Deep", TargetWords: 1000}, 6, 42, 10300, 1640, 0.0136},
+		{"overrides", models.CreateRequest{Model: "priced", MaxQueries: 1, ResultsPerQuery: 2, TargetWords: 100}, 1, 2, 2300, 180, 0.0027},
+		{"skip search", models.CreateRequest{Model: "priced", SkipSearch: true, TargetWords: 1000}, 0, 0, 1500, 1400, 0.0043},
+		{"max tokens", models.CreateRequest{Model: "priced", TargetWords: 5000, MaxTokens: 2000}, 4, 20, 5900, 2000, 0.0099},
+		{"no price", models.CreateRequest{Model: "unpriced", TargetWords: 1000}, 4, 20, 5900, 1560, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est := Estimate(tt.req, pricing)
+			if est.Queries != tt.queries || est.Searches != tt.queries || est.Sources != tt.sources {
+				t.Errorf("got %d queries, %d searches, %d sources, want %d, %d, %d",
+					est.Queries, est.Searches, est.Sources, tt.queries, tt.queries, tt.sources)
+			}
+			if est.InputTokens != tt.inputTokens || est.OutputTokens != tt.outputTokens {
+				t.Errorf("got %d input and %d output tokens, want %d and %d", est.InputTokens, est.OutputTokens, tt.inputTokens, tt.outputTokens)
+			}
+			switch {
+			case tt.cost < 0 && est.CostUSD != nil:
+				t.Errorf("cost = %v for an unpriced model", *est.CostUSD)
+			case tt.cost >= 0 && (est.CostUSD == nil || *est.CostUSD != tt.cost):
+				t.Errorf("cost = %v, want %v", est.CostUSD, tt.cost)
+			}
+		})
+	}
+}
+
+func TestParsePricing(t *testing.T) {
+	pricing, err := ParsePricing([]string{"mistral-large-latest=2:6", " cheap =0.1:0.3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pricing["mistral-large-latest"] != (ModelPrice{2, 6}) || pricing["cheap"] != (ModelPrice{0.1, 0.3}) {
+		t.Errorf("got %v", pricing)
+	}
+	for _, bad := range []string{"mistral", "mistral=2", "=2:6", "mistral=x:6", "mistral=2:y"} {
+		if _, err := ParsePricing([]string{bad}); err == nil {
+			t.Errorf("%q parsed without error", bad)
+		}
+	}
+}
+
+func TestEstimateUsage(t *testing.T) {
+	env := newTestEnv(t, nil)
+	w := serve(env.h.EstimateUsage, newRequest(t, http.MethodPost, "/research/estimate", "user-a",
+		models.CreateRequest{Topic: "solar panels", Depth: "Deep", Model: "mistral-large-latest"}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var est UsageEstimate
+	decode(t, w, &est)
+	if est.Model != "mistral-large-latest" || est.Queries != 6 || est.OutputTokens == 0 || est.CostUSD == nil {
+		t.Errorf("estimate = %+v", est)
+	}
+	for path, n := range env.ai.calls {
+		if n != 0 {
+			t.Errorf("estimating called %s", path)
+		}
+	}
+
+	for name, req := range map[string]models.CreateRequest{
+		"no topic":         {Model: DefaultModel},
+		"unlisted model":   {Topic: "t", Model: "gpt-4o"},
+		"too many queries": {Topic: "t", MaxQueries: maxQueriesLimit + 1},
+		"negative words":   {Topic: "t", TargetWords: -1},
+	} {
+		w := serve(env.h.EstimateUsage, newRequest(t, http.MethodPost, "/research/estimate", "user-a", req))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", name, w.Code)
+		}
+	}
+}
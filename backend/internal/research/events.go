@@ -0,0 +1,92 @@
+package research
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Events streams pipeline progress for a document as Server-Sent Events,
+// closing the stream once the job completes or fails.
+func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if h.opts.Progress == nil {
+		apierror.Write(w, http.StatusNotImplemented, apierror.NotImplemented, "progress events not available")
+		return
+	}
+
+	if _, ok := h.ownedDoc(w, r); !ok {
+		return
+	}
+
+	// Subscribe before re-reading the status so no transition is missed
+	// between the check and the subscription.
+	sub := h.opts.Progress.Subscribe(r.Context(), id)
+	defer sub.Close()
+	doc, err := h.mongo.GetByID(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "not found")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream may outlive the server's WriteTimeout.
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	current := currentEvent(doc)
+	writeEvent(w, current)
+	rc.Flush()
+	if current.Final() {
+		return
+	}
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var ev ProgressEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+				continue
+			}
+			writeEvent(w, ev)
+			rc.Flush()
+			if ev.Final() {
+				return
+			}
+		}
+	}
+}
+
+// currentEvent describes the stored state of doc as a progress event.
+func currentEvent(doc *models.Document) ProgressEvent {
+	ev := ProgressEvent{Status: doc.Status, Step: doc.Step, Error: doc.Error, Timestamp: time.Now().UTC()}
+	switch doc.Status {
+	case "", models.StatusComplete:
+		ev.Status, ev.Step, ev.Percent = models.StatusComplete, "", 100
+	case models.StatusDegraded:
+		ev.Step, ev.Percent = "", 100
+	default:
+		ev.Percent = stepPercent[doc.Step]
+	}
+	return ev
+}
+
+func writeEvent(w http.ResponseWriter, ev ProgressEvent) {
+	payload, _ := json.Marshal(ev)
+	fmt.Fprintf(w, "event: progress\ndata: %s\n\n", payload)
+}
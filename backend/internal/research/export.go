@@ -0,0 +1,79 @@
+package research
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// SourcesToBibTeX renders sources as BibTeX @misc entries. Cite keys are
+// source1…sourceN, matching the \cite keys used in reports, and each entry
+// is stamped with today's date as its access date. Authors and years are
+// included when known.
+func SourcesToBibTeX(sources []models.Source) string {
+	accessed := time.Now().UTC().Format("2006-01-02")
+	var b strings.Builder
+	for i, s := range sources {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "@misc{source%d,\n", i+1)
+		fmt.Fprintf(&b, "  title = {%s},\n", latexEscaper.Replace(strings.TrimSpace(s.Title)))
+		if author := strings.TrimSpace(s.Author); author != "" {
+			fmt.Fprintf(&b, "  author = {%s},\n", latexEscaper.Replace(author))
+		}
+		if year := sourceYear(s); year != "" {
+			fmt.Fprintf(&b, "  year = {%s},\n", year)
+		}
+		if site := sourceSite(s); site != "" {
+			fmt.Fprintf(&b, "  howpublished = {%s},\n", latexEscaper.Replace(site))
+		}
+		// Braces would end the field early; they are rare in URLs and
+		// safe to percent-encode.
+		fmt.Fprintf(&b, "  url = {%s},\n", bibURLEscaper.Replace(s.Href))
+		fmt.Fprintf(&b, "  urldate = {%s},\n", accessed)
+		fmt.Fprintf(&b, "  note = {Accessed: %s}\n", accessed)
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// bibURLEscaper percent-encodes braces in URLs placed in a BibTeX field or
+// \url argument.
+var bibURLEscaper = strings.NewReplacer("{", "%7B", "}", "%7D")
+
+// SourcesCSV writes a document's cited sources as CSV.
+func (h *Handler) SourcesCSV(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=sources.csv")
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"title", "body", "href", "author", "published_date", "site_name"})
+	for _, s := range doc.Sources {
+		cw.Write([]string{s.Title, s.Body, s.Href, s.Author, s.PublishedDate, s.SiteName})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("sources csv %s: %v", doc.ID.Hex(), err)
+	}
+}
+
+// SourcesBibTeX writes a document's cited sources as BibTeX.
+func (h *Handler) SourcesBibTeX(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-bibtex; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=sources.bib")
+	w.Write([]byte(SourcesToBibTeX(doc.Sources)))
+}
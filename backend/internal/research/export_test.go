@@ -0,0 +1,96 @@
+package research
+
+import (
+	"encoding/csv"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// balancedBraces reports whether the unescaped braces in s pair up, so a
+// field isn't closed early.
+func balancedBraces(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// awkward is a source whose title and URL use characters that are special
+// in BibTeX.
+var awkward = models.Source{
+	Title: "Costs & Benefits: 100% of {solar}_panels #1",
+	Href:  "https://www.r-and-d.example.org/a_b?x=1&y=2#top}",
+	Body:  "A long enough body for a source about solar panel costs.",
+}
+
+func TestSourcesToBibTeX(t *testing.T) {
+	bib := SourcesToBibTeX([]models.Source{awkward, {Title: "Plain", Href: "https://example.edu/plain"}})
+	today := time.Now().UTC().Format("2006-01-02")
+
+	for _, want := range []string{
+		"@misc{source1,",
+		`  title = {Costs \& Benefits: 100\% of \{solar\}\_panels \#1},`,
+		`  howpublished = {r-and-d.example.org},`,
+		`  url = {https://www.r-and-d.example.org/a_b?x=1&y=2#top%7D},`,
+		"  urldate = {" + today + "},",
+		"@misc{source2,",
+		`  title = {Plain},`,
+	} {
+		if !strings.Contains(bib, want+"\n") {
+			t.Errorf("missing %q in:\n%s", want, bib)
+		}
+	}
+	if !balancedBraces(bib) {
+		t.Errorf("unbalanced braces in:\n%s", bib)
+	}
+	dated := models.Source{Title: "Dated", Href: "https://example.edu/d", Author: "Smith & Jones", PublishedDate: "2019-06-01"}
+	if bib := SourcesToBibTeX([]models.Source{dated}); !strings.Contains(bib, "  author = {Smith \\& Jones},\n  year = {2019},\n") {
+		t.Errorf("author and year missing in:\n%s", bib)
+	}
+	if strings.Contains(bib, "author = ") || strings.Contains(bib, "year = ") {
+		t.Errorf("author or year written for sources without them:\n%s", bib)
+	}
+	if got := SourcesToBibTeX(nil); got != "" {
+		t.Errorf("no sources gave %q", got)
+	}
+}
+
+func TestSourcesCSV(t *testing.T) {
+	env := newTestEnv(t, nil)
+	dated := models.Source{Title: "Dated", Href: "https://example.edu/d", Author: "Ada Lovelace", PublishedDate: "2021-03-03", SiteName: "Example"}
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "t", Sources: []models.Source{awkward, dated}})
+
+	w := serve(env.h.SourcesCSV, newRequest(t, http.MethodGet, "/research/"+id+"/sources.csv", "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(rows) != 3 || rows[1][0] != awkward.Title || rows[1][1] != awkward.Body || rows[1][2] != awkward.Href {
+		t.Fatalf("rows = %q, want a header and the sources", rows)
+	}
+	if want := []string{"title", "body", "href", "author", "published_date", "site_name"}; !slices.Equal(rows[0], want) {
+		t.Errorf("header = %q, want %q", rows[0], want)
+	}
+	if want := []string{"Dated", "", "https://example.edu/d", "Ada Lovelace", "2021-03-03", "Example"}; !slices.Equal(rows[2], want) {
+		t.Errorf("row = %q, want %q", rows[2], want)
+	}
+}
@@ -0,0 +1,318 @@
+package research
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+	"github.com/ayush/research-ai-agent/backend/internal/storetest"
+)
+
+// fakeAI stands in for the Python AI service with canned answers. It
+// counts the calls made to each endpoint and records their bodies.
+type fakeAI struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	bodies  map[string][]map[string]interface{}
+	queries []string
+	sources []models.Source
+	report  string
+	// gapQueries answers /api/gap-queries.
+	gapQueries []string
+	// fail makes the named endpoints answer 500.
+	fail map[string]bool
+	// block, if set, holds /api/generate-report until it is closed.
+	// writing is closed when the report is first requested.
+	block       chan struct{}
+	writing     chan struct{}
+	writingOnce sync.Once
+	srv         *httptest.Server
+}
+
+// newFakeAI answers with two queries, two sources and a short report.
+func newFakeAI(t *testing.T) *fakeAI {
+	f := &fakeAI{
+		calls:   map[string]int{},
+		bodies:  map[string][]map[string]interface{}{},
+		fail:    map[string]bool{},
+		queries: []string{"solar panels efficiency", "solar panels cost"},
+		sources: []models.Source{
+			{Title: "Solar panel efficiency", Body: "How efficient modern solar panels are at turning sunlight into power.", Href: "https://example.edu/solar"},
+			{Title: "Solar panel cost", Body: "What solar panels cost to buy and install for a typical household.", Href: "https://example.org/cost"},
+		},
+		report: "\\section{Introduction}\nSolar panels turn light into power.\n",
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeAI) serve(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	f.mu.Lock()
+	f.calls[r.URL.Path]++
+	f.bodies[r.URL.Path] = append(f.bodies[r.URL.Path], body)
+	fail := f.fail[r.URL.Path]
+	f.mu.Unlock()
+	if fail {
+		http.Error(w, "upstream failure", http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Path == "/api/generate-report" && f.block != nil {
+		f.writingOnce.Do(func() { close(f.writing) })
+		select {
+		case <-f.block:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	switch r.URL.Path {
+	case "/api/generate-queries":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"queries": f.queries})
+	case "/api/search":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"results": f.sources})
+	case "/api/generate-report":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"latex_body": f.report})
+	case "/api/gap-queries":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"queries": f.gapQueries})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// called returns how many times the endpoint at path was called.
+func (f *fakeAI) called(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[path]
+}
+
+// client returns an AIClient talking to the fake.
+func (f *fakeAI) client() *AIClient {
+	return NewAIClient(f.srv.URL, RetryPolicy{})
+}
+
+// fakeLaTeX stands in for the LaTeX service. It records the request
+// bodies sent to each endpoint.
+type fakeLaTeX struct {
+	pdf []byte
+	tex string
+	// fail makes the named endpoints answer 500.
+	fail   map[string]bool
+	mu     sync.Mutex
+	bodies map[string][]map[string]interface{}
+	srv    *httptest.Server
+}
+
+func newFakeLaTeX(t *testing.T) *fakeLaTeX {
+	f := &fakeLaTeX{
+		pdf:    []byte("%PDF-1.4 fake"),
+		tex:    "\\documentclass{article}",
+		fail:   map[string]bool{},
+		bodies: map[string][]map[string]interface{}{},
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.bodies[r.URL.Path] = append(f.bodies[r.URL.Path], body)
+		f.mu.Unlock()
+		if f.fail[r.URL.Path] {
+			http.Error(w, "! Undefined control sequence.", http.StatusInternalServerError)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/compile-pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write(f.pdf)
+		case "/api/compile-tex":
+			writeJSON(w, http.StatusOK, map[string]string{"tex_source": f.tex})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeLaTeX) client() *LaTeXClient {
+	return NewLaTeXClient(f.srv.URL, RetryPolicy{})
+}
+
+// stubAI is an in-process AIService: it answers with its fields and
+// fails the methods named in errs, skipping HTTP entirely.
+type stubAI struct {
+	queries []string
+	sources []models.Source
+	report  string
+	errs    map[string]error
+}
+
+func (s *stubAI) GenerateQueries(ctx context.Context, apiKey, model, topic string, params GenerationParams) ([]string, error) {
+	return s.queries, s.errs["GenerateQueries"]
+}
+
+func (s *stubAI) Search(ctx context.Context, queries []string, resultsPerQuery int) ([]models.Source, error) {
+	return append([]models.Source(nil), s.sources...), s.errs["Search"]
+}
+
+func (s *stubAI) GenerateReport(ctx context.Context, apiKey, model, topic, ctxStr string, sources []models.Source, opts ReportOptions) (string, error) {
+	return s.report, s.errs["GenerateReport"]
+}
+
+func (s *stubAI) GapQueries(ctx context.Context, apiKey, model, topic, report string) ([]string, error) {
+	return s.queries, s.errs["GapQueries"]
+}
+
+// stubLaTeX is an in-process LaTeXService that fails the methods named in
+// errs.
+type stubLaTeX struct {
+	errs map[string]error
+}
+
+func (s *stubLaTeX) CompilePDF(ctx context.Context, latexBody, title string, opts PDFOptions) ([]byte, error) {
+	if err := s.errs["CompilePDF"]; err != nil {
+		return nil, err
+	}
+	return []byte("%PDF-1.4 stub"), nil
+}
+
+func (s *stubLaTeX) CompileTex(ctx context.Context, latexBody, title string) (string, error) {
+	if err := s.errs["CompileTex"]; err != nil {
+		return "", err
+	}
+	return "\\documentclass{article}\n" + latexBody, nil
+}
+
+// newTestRedis returns a client for a fresh in-memory Redis.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+// testEnv is a Handler wired to in-memory stores and fake services.
+type testEnv struct {
+	h     *Handler
+	docs  *storetest.ResearchStore
+	files *storetest.FileStore
+	ai    *fakeAI
+	latex *fakeLaTeX
+}
+
+func newTestEnv(t *testing.T, opts *Options) *testEnv {
+	t.Helper()
+	env := &testEnv{
+		docs:  storetest.NewResearchStore(),
+		files: storetest.NewFileStore(),
+		ai:    newFakeAI(t),
+		latex: newFakeLaTeX(t),
+	}
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	env.h = NewHandler(env.docs, env.files, env.ai.client(), env.latex.client(), o)
+	return env
+}
+
+// newStubEnv is a testEnv whose handler calls ai and latex in process
+// instead of the fake HTTP services.
+func newStubEnv(t *testing.T, ai AIService, latex LaTeXService) *testEnv {
+	t.Helper()
+	env := &testEnv{docs: storetest.NewResearchStore(), files: storetest.NewFileStore()}
+	env.h = NewHandler(env.docs, env.files, ai, latex, Options{})
+	return env
+}
+
+// startBlocked creates research as userID whose pipeline stops while
+// writing the report. It returns the document ID and a func that lets the
+// pipeline finish and waits for it.
+func (e *testEnv) startBlocked(t *testing.T, userID string) (string, func()) {
+	t.Helper()
+	e.ai.block, e.ai.writing = make(chan struct{}), make(chan struct{})
+	w := serve(e.h.Create, newRequest(t, http.MethodPost, "/research", userID,
+		models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"}))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("create: got %d %s, want 202", w.Code, w.Body)
+	}
+	var accepted models.Document
+	decode(t, w, &accepted)
+	select {
+	case <-e.ai.writing:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipeline never started writing the report")
+	}
+	return accepted.ID.Hex(), func() {
+		close(e.ai.block)
+		e.wait(t)
+	}
+}
+
+// insert stores doc directly and returns its ID.
+func (e *testEnv) insert(t *testing.T, doc *models.Document) string {
+	t.Helper()
+	id, err := e.docs.Insert(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	return id
+}
+
+// newRequest builds a request as userID with body encoded as JSON and
+// params set as chi URL parameters, in name/value pairs.
+func newRequest(t *testing.T, method, target, userID string, body interface{}, params ...string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(method, target, jsonBody(t, body))
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+	if userID != "" {
+		ctx = auth.WithUserID(ctx, userID)
+	}
+	return r.WithContext(ctx)
+}
+
+// jsonBody encodes body as JSON; nil gives an empty body.
+func jsonBody(t *testing.T, body interface{}) io.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+	}
+	return &buf
+}
+
+// serve runs handler on r and returns the recorded response.
+func serve(handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// decode unmarshals the JSON response body into v.
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+}
@@ -0,0 +1,109 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// SuggestionStore keeps AI-suggested follow-up queries for a document in
+// Redis for a short time. They are only suggestions: nothing runs them until
+// the user adds sources explicitly.
+type SuggestionStore struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+func NewSuggestionStore(rdb *redis.Client, ttl time.Duration) *SuggestionStore {
+	return &SuggestionStore{rdb: rdb, ttl: ttl}
+}
+
+// Save replaces the stored suggestions for docID.
+func (s *SuggestionStore) Save(ctx context.Context, docID string, queries []string) error {
+	payload, _ := json.Marshal(queries)
+	return s.rdb.Set(ctx, "gap_queries:"+docID, payload, s.ttl).Err()
+}
+
+// gapQuerier is implemented by providers that can suggest follow-up
+// queries for a report, like the Python AI service.
+type gapQuerier interface {
+	GapQueries(ctx context.Context, apiKey, model, topic, report string) ([]string, error)
+}
+
+// GapQueries asks the document's AI provider which angles the report misses
+// and returns suggested follow-up queries without executing them. Without
+// an api_key the user's stored key is used.
+func (h *Handler) GapQueries(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req struct {
+		APIKey string `json:"api_key"`
+		Model  string `json:"model"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+			return
+		}
+	}
+
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	if doc.LatexContent == "" {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "report is not ready yet")
+		return
+	}
+	if req.APIKey == "" {
+		key, err := h.storedAPIKey(r.Context(), doc.UserID)
+		if err != nil {
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to load stored api key")
+			return
+		}
+		req.APIKey = key
+	}
+	if req.APIKey == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "api_key is required")
+		return
+	}
+	if req.Model == "" {
+		req.Model = doc.ModelUsed
+	} else if !h.modelAllowed(req.Model) {
+		h.writeModelNotAllowed(w)
+		return
+	}
+	provider, ok := h.opts.Providers.Get(doc.Provider)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "unknown provider")
+		return
+	}
+	gaps, ok := provider.(gapQuerier)
+	if !ok {
+		apierror.Write(w, http.StatusNotImplemented, apierror.NotImplemented, "the document's provider can't suggest follow-up queries")
+		return
+	}
+
+	queries, err := gaps.GapQueries(r.Context(), req.APIKey, req.Model, doc.Topic, doc.LatexContent)
+	if err != nil {
+		log.Printf("gap-queries error: %v", err)
+		apierror.Write(w, http.StatusBadGateway, apierror.UpstreamFailure, "failed to generate follow-up queries")
+		return
+	}
+	if queries == nil {
+		queries = []string{}
+	}
+	if h.opts.Suggestions != nil {
+		if err := h.opts.Suggestions.Save(r.Context(), id, queries); err != nil {
+			log.Printf("save gap queries %s: %v", id, err)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"queries": queries})
+}
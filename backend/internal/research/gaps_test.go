@@ -0,0 +1,127 @@
+package research
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestGapQueries(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	env := newTestEnv(t, &Options{Suggestions: NewSuggestionStore(rdb, time.Hour)})
+	env.ai.gapQueries = []string{"solar panel recycling", "grid storage costs"}
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels", ModelUsed: "mistral-small-latest", LatexContent: "\\section{Intro}"})
+
+	w := serve(env.h.GapQueries, newRequest(t, http.MethodPost, "/research/"+id+"/gap-queries", "user-a",
+		map[string]string{"api_key": "sk-test"}, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var resp struct {
+		Queries []string `json:"queries"`
+	}
+	decode(t, w, &resp)
+	if !slices.Equal(resp.Queries, env.ai.gapQueries) {
+		t.Errorf("queries = %v, want %v", resp.Queries, env.ai.gapQueries)
+	}
+
+	body := env.ai.bodies["/api/gap-queries"][0]
+	if body["report"] != "\\section{Intro}" || body["topic"] != "solar panels" || body["model"] != "mistral-small-latest" {
+		t.Errorf("gap-queries request = %v, want the report, topic and document model", body)
+	}
+	if n := env.ai.called("/api/search") + env.ai.called("/api/generate-report"); n != 0 {
+		t.Errorf("suggestions were executed: %d search/report calls", n)
+	}
+	saved, err := mr.Get("gap_queries:" + id)
+	if err != nil {
+		t.Fatalf("suggestions were not saved: %v", err)
+	}
+	var stored []string
+	if err := json.Unmarshal([]byte(saved), &stored); err != nil || !slices.Equal(stored, env.ai.gapQueries) {
+		t.Errorf("saved suggestions = %s, want %v", saved, env.ai.gapQueries)
+	}
+}
+
+func TestGapQueriesErrors(t *testing.T) {
+	env := newTestEnv(t, nil)
+	ready := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels", LatexContent: "\\section{Intro}"})
+	pending := env.insert(t, &models.Document{UserID: "user-a", Topic: "wind", Status: models.StatusRunning})
+
+	tests := []struct {
+		name, id, user string
+		body           interface{}
+		want           int
+	}{
+		{"no api key", ready, "user-a", map[string]string{}, http.StatusBadRequest},
+		{"other user", ready, "user-b", map[string]string{"api_key": "sk-test"}, http.StatusForbidden},
+		{"missing", "000000000000000000000000", "user-a", map[string]string{"api_key": "sk-test"}, http.StatusNotFound},
+		{"not ready", pending, "user-a", map[string]string{"api_key": "sk-test"}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(env.h.GapQueries, newRequest(t, http.MethodPost, "/research/x/gap-queries", tt.user, tt.body, "id", tt.id))
+			if w.Code != tt.want {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+	if n := env.ai.called("/api/gap-queries"); n != 0 {
+		t.Errorf("gap-queries called %d times for rejected requests", n)
+	}
+
+	env.ai.fail["/api/gap-queries"] = true
+	w := serve(env.h.GapQueries, newRequest(t, http.MethodPost, "/research/x/gap-queries", "user-a",
+		map[string]string{"api_key": "sk-test"}, "id", ready))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("upstream failure: got %d, want 502", w.Code)
+	}
+}
+
+func TestGapQueriesUsesStoredKey(t *testing.T) {
+	env := newTestEnv(t, &Options{APIKeys: storedKeys{"user-a": "sk-stored"}})
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels", LatexContent: "\\section{Intro}"})
+
+	w := serve(env.h.GapQueries, newRequest(t, http.MethodPost, "/research/"+id+"/gap-queries", "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	if got := env.ai.bodies["/api/gap-queries"][0]["api_key"]; got != "sk-stored" {
+		t.Errorf("called with key %v, want the stored key", got)
+	}
+
+	// A user without a stored key still has to send one.
+	id = env.insert(t, &models.Document{UserID: "user-b", Topic: "solar panels", LatexContent: "\\section{Intro}"})
+	w = serve(env.h.GapQueries, newRequest(t, http.MethodPost, "/research/"+id+"/gap-queries", "user-b", nil, "id", id))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("no key: got %d %s, want 400", w.Code, w.Body)
+	}
+}
+
+func TestGapQueriesUsesDocumentProvider(t *testing.T) {
+	other := newFakeAI(t)
+	other.gapQueries = []string{"from the other provider"}
+	env := newTestEnv(t, nil)
+	env.h.opts.Providers.Register("other", other.client())
+	env.h.opts.Providers.Register("search-only", searchOnly{Provider: other.client()})
+
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels", Provider: "other", LatexContent: "\\section{Intro}"})
+	w := serve(env.h.GapQueries, newRequest(t, http.MethodPost, "/research/"+id+"/gap-queries", "user-a",
+		map[string]string{"api_key": "sk-test"}, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	if other.called("/api/gap-queries") != 1 || env.ai.called("/api/gap-queries") != 0 {
+		t.Errorf("asked the wrong provider: other %d, default %d", other.called("/api/gap-queries"), env.ai.called("/api/gap-queries"))
+	}
+
+	id = env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels", Provider: "search-only", LatexContent: "\\section{Intro}"})
+	w = serve(env.h.GapQueries, newRequest(t, http.MethodPost, "/research/"+id+"/gap-queries", "user-a",
+		map[string]string{"api_key": "sk-test"}, "id", id))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("provider without gap queries: got %d %s, want 501", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,51 @@
+package research
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// guestSweepBatch caps how many expired guest documents one sweep purges.
+const guestSweepBatch = 100
+
+// isGuest reports whether userID belongs to a guest.
+func (h *Handler) isGuest(userID string) bool {
+	return h.opts.GuestPrefix != "" && strings.HasPrefix(userID, h.opts.GuestPrefix)
+}
+
+// RunGuestSweeper purges guests' documents, with their files and versions,
+// once they are older than retention, checking every interval until ctx is
+// cancelled. It does nothing without a GuestPrefix.
+func (h *Handler) RunGuestSweeper(ctx context.Context, retention, interval time.Duration) {
+	if h.opts.GuestPrefix == "" {
+		return
+	}
+	if interval <= 0 {
+		log.Printf("guest sweep interval %v: guest sweeping disabled", interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.sweepGuests(ctx, time.Now().Add(-retention))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepGuests purges guest documents created before cutoff.
+func (h *Handler) sweepGuests(ctx context.Context, cutoff time.Time) {
+	docs, err := h.mongo.CreatedBefore(ctx, h.opts.GuestPrefix, cutoff, guestSweepBatch)
+	if err != nil {
+		log.Printf("sweep guests: %v", err)
+		return
+	}
+	if purged := h.purgeAll(ctx, docs, "sweep guests"); purged > 0 {
+		log.Printf("sweep guests: purged %d documents", purged)
+	}
+}
@@ -0,0 +1,71 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestSweepGuests(t *testing.T) {
+	env := newTestEnv(t, &Options{GuestPrefix: "guest/"})
+	ctx := context.Background()
+	expired := env.create(t, "guest/abc", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	registered := env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test"})
+	recent := env.create(t, "guest/def", models.CreateRequest{Topic: "tides", APIKey: "sk-test"})
+	for _, doc := range []*models.Document{expired, registered} {
+		doc.CreatedAt = time.Now().Add(-48 * time.Hour)
+		if err := env.docs.Update(ctx, doc.ID.Hex(), doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env.h.sweepGuests(ctx, time.Now().Add(-24*time.Hour))
+
+	if _, err := env.docs.GetByID(ctx, expired.ID.Hex()); err == nil {
+		t.Error("expired guest document was not purged")
+	}
+	for _, doc := range []*models.Document{registered, recent} {
+		if _, err := env.docs.GetByID(ctx, doc.ID.Hex()); err != nil {
+			t.Errorf("%s was purged: %v", doc.Topic, err)
+		}
+	}
+	want := map[string]bool{
+		registered.PDFObjectKey: true, registered.TexObjectKey: true,
+		recent.PDFObjectKey: true, recent.TexObjectKey: true,
+	}
+	for _, key := range env.files.Keys() {
+		if !want[key] {
+			t.Errorf("file %s is left", key)
+		}
+		delete(want, key)
+	}
+	for key := range want {
+		t.Errorf("file %s was removed", key)
+	}
+}
+
+func TestRunGuestSweeperWithoutInterval(t *testing.T) {
+	env := newTestEnv(t, &Options{GuestPrefix: "guest/"})
+	done := make(chan struct{})
+	go func() {
+		env.h.RunGuestSweeper(context.Background(), time.Hour, 0)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunGuestSweeper with a zero interval did not return")
+	}
+}
+
+func TestGuestsCannotSubscribe(t *testing.T) {
+	env := newTestEnv(t, &Options{GuestPrefix: "guest/"})
+	w := serve(env.h.CreateSubscription, newRequest(t, http.MethodPost, "/research/subscriptions", "guest/abc",
+		models.SubscriptionRequest{Topic: "solar panels", IntervalHours: 24}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("got %d %s, want 403", w.Code, w.Body)
+	}
+}
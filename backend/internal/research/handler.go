@@ -3,12 +3,21 @@ package research
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/go-chi/chi/v5"
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
 	"github.com/ayush/research-ai-agent/backend/internal/models"
+	"github.com/go-chi/chi/v5"
 )
 
 // writeJSON writes a JSON response with the given status code.
@@ -21,211 +30,709 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 // ResearchStore defines the interface for research persistence.
 type ResearchStore interface {
 	Insert(ctx context.Context, doc *models.Document) (string, error)
-	ListByUser(ctx context.Context, userID string) ([]models.Document, error)
+	ListByUser(ctx context.Context, userID string, opts models.ListOptions) ([]models.Document, string, error)
+	SearchByUser(ctx context.Context, userID, query string) ([]models.Document, error)
+	DomainsByUser(ctx context.Context, userID string) ([]models.DomainCount, error)
 	GetByID(ctx context.Context, id string) (*models.Document, error)
+	Update(ctx context.Context, id string, doc *models.Document) error
+	SaveResult(ctx context.Context, id string, doc *models.Document) error
+	SetStatus(ctx context.Context, id, status, step, errMsg string) error
+	CancelActive(ctx context.Context, id, step, errMsg string) (bool, error)
+	SetStepTimings(ctx context.Context, id string, timings map[string]int64) error
 	Delete(ctx context.Context, id string) error
+	BulkUpdateTags(ctx context.Context, userID string, ids, add, remove []string) ([]string, error)
+	AppendVersion(ctx context.Context, v *models.Version) error
+	ListVersions(ctx context.Context, docID string) ([]models.Version, error)
+	GetVersion(ctx context.Context, docID string, version int) (*models.Version, error)
+	DeleteVersions(ctx context.Context, docID string) error
+	SetDeleted(ctx context.Context, id string, at *time.Time) error
+	EachByUser(ctx context.Context, userID string, fn func(*models.Document) error) error
+	SetTags(ctx context.Context, id string, tags []string) error
+	SetSources(ctx context.Context, id string, sources []models.Source) error
+	TagsByUser(ctx context.Context, userID string) ([]models.TagCount, error)
+	StatsByUser(ctx context.Context, userID string) (*models.ResearchStats, error)
+	DeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Document, error)
+	CreatedBefore(ctx context.Context, userPrefix string, cutoff time.Time, limit int) ([]models.Document, error)
 }
 
 // FileStore defines the interface for file storage.
 type FileStore interface {
 	Upload(ctx context.Context, key string, data []byte, contentType string) error
 	Download(ctx context.Context, key string) ([]byte, string, error)
+	// DownloadStream opens an object for reading and returns it with its
+	// metadata. The caller must close the reader.
+	DownloadStream(ctx context.Context, key string) (io.ReadSeekCloser, models.ObjectInfo, error)
+	// Stat returns an object's metadata without reading it.
+	Stat(ctx context.Context, key string) (models.ObjectInfo, error)
 	Remove(ctx context.Context, key string) error
 }
 
+// APIKeyLookup returns a user's stored provider API key, or "" if none.
+type APIKeyLookup interface {
+	Get(ctx context.Context, userID string) (string, error)
+}
+
+// Options tunes the research pipeline. Zero values fall back to defaults.
+type Options struct {
+	// UploadConcurrency bounds how many artifacts are uploaded in parallel.
+	UploadConcurrency int
+	// SearchConcurrency is how many queries are searched in parallel, one
+	// request each. Zero sends all queries in a single request.
+	SearchConcurrency int
+	// MaxSources caps the sources collected by a concurrent search; zero
+	// means no cap.
+	MaxSources int
+	// ModelHealth downgrades models that keep timing out. Nil disables it.
+	ModelHealth *ModelHealth
+	// Progress publishes step transitions for the SSE endpoint.
+	Progress *Progress
+	// CredibleDomains are scored as reputable when rating sources.
+	CredibleDomains []string
+	// AllowedDomains is used when a request doesn't restrict domains
+	// itself. BlockedDomains are blocked in addition to a request's own.
+	AllowedDomains []string
+	BlockedDomains []string
+	// DedupMode is the source deduplication mode used when a request
+	// doesn't pick one. DedupThreshold is the fuzzy title similarity cutoff.
+	DedupMode      string
+	DedupThreshold float64
+	// Debug holds opt-in captures of research runs for support.
+	Debug *DebugStore
+	// Suggestions holds follow-up queries proposed by GapQueries.
+	Suggestions *SuggestionStore
+	// Shares maps public share tokens to documents.
+	Shares *ShareStore
+	// APIKeys supplies stored keys when a request omits api_key.
+	APIKeys APIKeyLookup
+	// GuestPrefix starts the user IDs of guests, who have no account: they
+	// can't store API keys or subscribe, and RunGuestSweeper deletes their
+	// documents. Empty means there are no guests.
+	GuestPrefix string
+	// Locks serialises mutating operations per document.
+	Locks *DocLocker
+	// Idempotency deduplicates creates retried with the same
+	// Idempotency-Key. Nil ignores the header.
+	Idempotency *IdempotencyStore
+	// Providers selects the AI provider named by a request. Defaults to
+	// the AI client under DefaultProvider.
+	Providers *Providers
+	// Subscriptions stores topic subscriptions; nil disables the feature.
+	Subscriptions SubscriptionStore
+	// Email and Users deliver subscription notification emails.
+	Email EmailSender
+	Users UserLookup
+	// PublicURL is the externally visible base URL used in share links.
+	// When empty it is derived from the request.
+	PublicURL string
+	// PresignExpiry is how long presigned download URLs stay valid.
+	PresignExpiry time.Duration
+	// Callbacks notifies a request's callback_url when its job finishes.
+	// Nil rejects requests that set one.
+	Callbacks *Callbacks
+	// Cache holds generated queries and search results; nil disables it.
+	Cache *QueryCache
+	// MaxLatexBytes caps the size of a report's LaTeX body.
+	MaxLatexBytes int
+	// AILimit bounds concurrent searches and report generations; nil
+	// doesn't limit them.
+	AILimit *AILimiter
+	// StepTimeouts bound each pipeline step; zero fields use
+	// DefaultStepTimeouts.
+	StepTimeouts StepTimeouts
+	// AllowedModels are the models requests may pick. Defaults to
+	// DefaultAllowedModels; DefaultModel is always allowed.
+	AllowedModels []string
+	// Pricing prices models for usage estimates. Defaults to
+	// DefaultPricing.
+	Pricing Pricing
+	// Quota caps reports created per user per month; nil doesn't.
+	Quota *QuotaStore
+	// SourceLimits caps the sources passed to the report per depth
+	// preset. Defaults to DefaultSourceLimits.
+	SourceLimits map[string]int
+	// LinkCheck probes source URLs. Defaults to a checker with
+	// DefaultLinkCheckTimeout and DefaultLinkCheckConcurrency.
+	LinkCheck *LinkChecker
+	// ListTimeout bounds the query behind List and Trash. Defaults to
+	// DefaultListTimeout.
+	ListTimeout time.Duration
+	// ListCache serves recent pages while the database fails; nil
+	// answers 503 instead.
+	ListCache *ListCache
+}
+
 // Handler holds research HTTP handlers.
 type Handler struct {
 	mongo       ResearchStore
 	minio       FileStore
-	aiClient    *AIClient
-	latexClient *LaTeXClient
+	aiClient    AIService
+	latexClient LaTeXService
+	opts        Options
+
+	// jobs tracks background pipelines so shutdown can wait for them.
+	jobs sync.WaitGroup
+	// cancels holds the cancel func of each pipeline running in this
+	// process, by document ID.
+	cancelsMu sync.Mutex
+	cancels   map[string]*runningJob
+	// draining is set once shutdown begins; see Drain.
+	draining atomic.Bool
+	// started, finished and failed count pipelines since startup.
+	started, finished, failed atomic.Int64
 }
 
-func NewHandler(mongo ResearchStore, minio FileStore, aiClient *AIClient, latexClient *LaTeXClient) *Handler {
-	return &Handler{mongo: mongo, minio: minio, aiClient: aiClient, latexClient: latexClient}
+// JobStats counts the background pipelines run since startup.
+type JobStats struct {
+	Started  int64
+	Finished int64 // completed or failed
+	Failed   int64
 }
 
-// Create runs the full research pipeline and stores results.
-func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(string)
+// Running is the number of pipelines that haven't finished.
+func (s JobStats) Running() int64 { return s.Started - s.Finished }
 
-	var req models.CreateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
-		return
+// Stats returns the current pipeline counters.
+func (h *Handler) Stats() JobStats {
+	// Read finished first so Running is never negative.
+	finished := h.finished.Load()
+	return JobStats{Started: h.started.Load(), Finished: finished, Failed: h.failed.Load()}
+}
+
+func NewHandler(mongo ResearchStore, minio FileStore, aiClient AIService, latexClient LaTeXService, opts Options) *Handler {
+	if opts.UploadConcurrency <= 0 {
+		opts.UploadConcurrency = 4
 	}
-	if req.Topic == "" || req.APIKey == "" {
-		http.Error(w, `{"error":"topic and api_key are required"}`, http.StatusBadRequest)
-		return
+	if opts.Providers == nil {
+		opts.Providers = NewProviders(DefaultProvider, aiClient)
 	}
-	if req.Model == "" {
-		req.Model = "mistral-medium-latest"
+	if opts.CredibleDomains == nil {
+		opts.CredibleDomains = DefaultCredibleDomains
 	}
-	if req.Depth == "" {
-		req.Depth = "Standard"
+	opts.AllowedDomains = normalizeDomains(opts.AllowedDomains)
+	opts.BlockedDomains = normalizeDomains(opts.BlockedDomains)
+	if !validDedupMode(opts.DedupMode) {
+		opts.DedupMode = DedupURL
 	}
-
-	depth, ok := DepthConfig[req.Depth]
-	if !ok {
-		depth = DepthConfig["Standard"]
+	if opts.DedupThreshold <= 0 || opts.DedupThreshold > 1 {
+		opts.DedupThreshold = DefaultDedupThreshold
 	}
-	maxQueries, resultsPerQuery := depth[0], depth[1]
-
-	// Step 1: generate search queries
-	queries, err := h.aiClient.GenerateQueries(req.APIKey, req.Model, req.Topic)
-	if err != nil {
-		log.Printf("generate-queries error: %v", err)
-		writeJSON(w, http.StatusBadGateway, map[string]string{
-			"error": fmt.Sprintf("Failed to generate search queries: %v", err),
-		})
-		return
+	if opts.PresignExpiry <= 0 {
+		opts.PresignExpiry = DefaultPresignExpiry
+	}
+	if opts.MaxLatexBytes <= 0 {
+		opts.MaxLatexBytes = DefaultMaxLatexBytes
+	}
+	opts.StepTimeouts = opts.StepTimeouts.withDefaults()
+	if opts.Pricing == nil {
+		opts.Pricing = DefaultPricing
+	}
+	if opts.SourceLimits == nil {
+		opts.SourceLimits = DefaultSourceLimits
 	}
-	if len(queries) > maxQueries {
-		queries = queries[:maxQueries]
+	if opts.LinkCheck == nil {
+		opts.LinkCheck = NewLinkChecker(DefaultLinkCheckTimeout, DefaultLinkCheckConcurrency)
 	}
+	if opts.ListTimeout <= 0 {
+		opts.ListTimeout = DefaultListTimeout
+	}
+	if len(opts.AllowedModels) == 0 {
+		opts.AllowedModels = DefaultAllowedModels
+	}
+	if !slices.Contains(opts.AllowedModels, DefaultModel) {
+		opts.AllowedModels = append([]string{DefaultModel}, opts.AllowedModels...)
+	}
+	return &Handler{
+		mongo: mongo, minio: minio, aiClient: aiClient, latexClient: latexClient, opts: opts,
+		cancels: make(map[string]*runningJob),
+	}
+}
 
-	// Step 2: web search
-	sources, err := h.aiClient.Search(queries, resultsPerQuery)
-	if err != nil {
-		log.Printf("search error: %v", err)
-		writeJSON(w, http.StatusBadGateway, map[string]string{
-			"error": fmt.Sprintf("Web search failed: %v", err),
-		})
-		return
+// currentUser returns the authenticated user's ID, writing a 401 if the
+// request carries none, e.g. because a route is missing RequireAuth.
+func currentUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		apierror.Write(w, http.StatusUnauthorized, apierror.Unauthorized, "not authenticated")
 	}
+	return userID, ok
+}
 
-	// Build context string
-	ctxStr := ""
-	for _, s := range sources {
-		ctxStr += fmt.Sprintf("- %s: %s (Source: %s)\n", s.Title, s.Body, s.Href)
+// ownedDoc loads the document named by the {id} URL param and checks that it
+// belongs to the authenticated user, writing a 404 or 403 otherwise.
+// Documents in the trash are not found; see ownedDocInTrash.
+func (h *Handler) ownedDoc(w http.ResponseWriter, r *http.Request) (*models.Document, bool) {
+	doc, ok := h.ownedDocInTrash(w, r)
+	if ok && doc.DeletedAt != nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "not found")
+		return nil, false
 	}
+	return doc, ok
+}
 
-	// Step 3: generate report
-	latexBody, err := h.aiClient.GenerateReport(req.APIKey, req.Model, req.Topic, ctxStr, sources)
+// ownedDocInTrash is ownedDoc that also finds trashed documents, for the
+// handlers that manage the trash.
+func (h *Handler) ownedDocInTrash(w http.ResponseWriter, r *http.Request) (*models.Document, bool) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return nil, false
+	}
+	doc, err := h.mongo.GetByID(r.Context(), chi.URLParam(r, "id"))
 	if err != nil {
-		log.Printf("generate-report error: %v", err)
-		writeJSON(w, http.StatusBadGateway, map[string]string{
-			"error": fmt.Sprintf("Report generation failed: %v", err),
-		})
-		return
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "not found")
+		return nil, false
 	}
-	if latexBody == "" {
-		log.Printf("generate-report returned empty body")
-		writeJSON(w, http.StatusBadGateway, map[string]string{
-			"error": "AI service returned an empty report. Try again or use a different model.",
-		})
-		return
+	if doc.UserID != userID {
+		apierror.Write(w, http.StatusForbidden, apierror.Forbidden, "forbidden")
+		return nil, false
 	}
+	return doc, true
+}
 
-	// Step 4: compile PDF (via latex-service)
-	pdfBytes, err := h.latexClient.CompilePDF(latexBody, req.Topic)
+// storedAPIKey returns the user's saved provider key, or "" if none.
+func (h *Handler) storedAPIKey(ctx context.Context, userID string) (string, error) {
+	if h.opts.APIKeys == nil || h.isGuest(userID) {
+		return "", nil
+	}
+	key, err := h.opts.APIKeys.Get(ctx, userID)
 	if err != nil {
-		log.Printf("compile-pdf error (non-fatal): %v", err)
+		log.Printf("load api key %s: %v", userID, err)
 	}
+	return key, err
+}
 
-	// Step 5: compile .tex (via latex-service)
-	texSource, err := h.latexClient.CompileTex(latexBody, req.Topic)
-	if err != nil {
-		log.Printf("compile-tex error (non-fatal): %v", err)
+// Create runs the full research pipeline and stores results.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
 	}
 
-	// Step 6: upload to MinIO
-	topicSlug := req.Topic
-	if len(topicSlug) > 20 {
-		topicSlug = topicSlug[:20]
+	if h.rejectIfDraining(w) {
+		return
+	}
+	req, ok := h.decodeCreateRequest(w, r, userID)
+	if !ok {
+		return
 	}
-	pdfKey := fmt.Sprintf("%s/%s.pdf", userID, topicSlug)
-	texKey := fmt.Sprintf("%s/%s.tex", userID, topicSlug)
 
-	if pdfBytes != nil {
-		if err := h.minio.Upload(r.Context(), pdfKey, pdfBytes, "application/pdf"); err != nil {
-			log.Printf("minio pdf upload error: %v", err)
-			pdfKey = ""
+	idemKey := r.Header.Get(IdempotencyHeader)
+	if idemKey != "" && h.opts.Idempotency != nil {
+		if len(idemKey) > maxIdempotencyKeyLen {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "Idempotency-Key is too long")
+			return
+		}
+		existing, reserved, err := h.opts.Idempotency.Reserve(r.Context(), userID, idemKey)
+		if err != nil {
+			log.Printf("reserve idempotency key for %s: %v", userID, err)
+			apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "idempotency store unavailable")
+			return
+		}
+		if !reserved {
+			h.replayCreate(w, r, existing)
+			return
 		}
 	} else {
-		pdfKey = ""
+		idemKey = ""
 	}
 
-	if texSource != "" {
-		if err := h.minio.Upload(r.Context(), texKey, []byte(texSource), "application/x-tex"); err != nil {
-			log.Printf("minio tex upload error: %v", err)
-			texKey = ""
+	if !h.takeQuota(w, r, userID) {
+		if idemKey != "" {
+			h.opts.Idempotency.Release(r.Context(), userID, idemKey)
 		}
-	} else {
-		texKey = ""
+		return
 	}
 
-	// Step 7: save to MongoDB
 	doc := &models.Document{
-		UserID:        userID,
-		Topic:         req.Topic,
-		LatexContent:  latexBody,
-		Sources:       sources,
-		ModelUsed:     req.Model,
-		SearchQueries: queries,
-		PDFObjectKey:  pdfKey,
-		TexObjectKey:  texKey,
+		UserID:         userID,
+		Topic:          req.Topic,
+		ModelUsed:      req.Model,
+		Provider:       req.Provider,
+		SearchSkipped:  req.SkipSearch,
+		Dedup:          req.Dedup,
+		CitationStyle:  req.CitationStyle,
+		Length:         req.Length,
+		TargetWords:    req.TargetWords,
+		TargetSections: req.TargetSections,
+		Language:       req.Language,
+		Temperature:    req.Temperature,
+		MaxTokens:      req.MaxTokens,
+		Watermark:      req.Watermark,
+		Status:         models.StatusPending,
 	}
 	docID, err := h.mongo.Insert(r.Context(), doc)
 	if err != nil {
 		log.Printf("mongo insert error: %v", err)
-		http.Error(w, `{"error":"failed to save research"}`, http.StatusInternalServerError)
+		if idemKey != "" {
+			h.opts.Idempotency.Release(r.Context(), userID, idemKey)
+		}
+		h.opts.Quota.Refund(r.Context(), userID)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to save research")
 		return
 	}
+	if idemKey != "" {
+		if err := h.opts.Idempotency.Complete(r.Context(), userID, idemKey, docID); err != nil {
+			log.Printf("complete idempotency key for %s: %v", userID, err)
+		}
+	}
 
-	// Re-fetch to get the full object with _id
-	saved, _ := h.mongo.GetByID(r.Context(), docID)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(saved)
+	// Snapshot the response before the pipeline starts mutating doc.
+	accepted := *doc
+
+	h.startPipeline(job{docID: docID, doc: doc, req: req})
+
+	writeJSON(w, http.StatusAccepted, accepted)
+}
+
+// Bounds of the sampling parameters a create request may set.
+const (
+	maxTemperature = 2
+	maxMaxTokens   = 32000
+)
+
+// decodeCreateRequest reads and validates a create request, filling in
+// defaults. It writes the error response and returns false if it can't be used.
+func (h *Handler) decodeCreateRequest(w http.ResponseWriter, r *http.Request, userID string) (models.CreateRequest, bool) {
+	var req models.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return req, false
+	}
+	if req.APIKey == "" {
+		key, err := h.storedAPIKey(r.Context(), userID)
+		if err != nil {
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to load stored api key")
+			return req, false
+		}
+		req.APIKey = key
+	}
+	if req.Topic == "" || req.APIKey == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "topic and api_key are required")
+		return req, false
+	}
+	if req.MinCredibility < 0 || req.MinCredibility > 1 {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "min_credibility must be between 0 and 1")
+		return req, false
+	}
+	if len(req.AllowedDomains) > maxDomainFilters || len(req.BlockedDomains) > maxDomainFilters {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "at most 100 allowed_domains and blocked_domains")
+		return req, false
+	}
+	req.AllowedDomains = normalizeDomains(req.AllowedDomains)
+	req.BlockedDomains = normalizeDomains(req.BlockedDomains)
+	if req.Dedup == "" {
+		req.Dedup = h.opts.DedupMode
+	}
+	if !validDedupMode(req.Dedup) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "dedup must be none, url or fuzzy")
+		return req, false
+	}
+	if req.Provider == "" {
+		req.Provider = h.opts.Providers.Default()
+	}
+	if _, ok := h.opts.Providers.Get(req.Provider); !ok {
+		apierror.WriteDetails(w, http.StatusBadRequest, apierror.InvalidRequest, "unknown provider", map[string]interface{}{
+			"providers": h.opts.Providers.Names(),
+		})
+		return req, false
+	}
+	if req.CitationStyle == "" {
+		req.CitationStyle = DefaultCitationStyle
+	}
+	req.CitationStyle = strings.ToLower(req.CitationStyle)
+	if !validCitationStyle(req.CitationStyle) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "citation_style must be apa, mla, ieee or chicago")
+		return req, false
+	}
+	if req.Language == "" {
+		req.Language = DefaultLanguage
+	}
+	req.Language = strings.ToLower(req.Language)
+	if !validLanguage(req.Language) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "unsupported language")
+		return req, false
+	}
+	if req.CallbackURL != "" {
+		if h.opts.Callbacks == nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "callbacks are not enabled on this server")
+			return req, false
+		}
+		if !validCallbackURL(req.CallbackURL) {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "callback_url must be an http or https URL")
+			return req, false
+		}
+	}
+	if req.Model == "" {
+		req.Model = DefaultModel
+	}
+	if !h.modelAllowed(req.Model) {
+		h.writeModelNotAllowed(w)
+		return req, false
+	}
+	if req.Depth == "" {
+		req.Depth = "Standard"
+	}
+	if req.MaxQueries < 0 || req.MaxQueries > maxQueriesLimit {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "max_queries must be between 1 and 10")
+		return req, false
+	}
+	if req.ResultsPerQuery < 0 || req.ResultsPerQuery > resultsPerQueryLimit {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "results_per_query must be between 1 and 15")
+		return req, false
+	}
+	if req.TargetWords < 0 || req.TargetWords > 20000 {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "target_words must be between 1 and 20000")
+		return req, false
+	}
+	if req.TargetSections < 0 || req.TargetSections > 20 {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "target_sections must be between 1 and 20")
+		return req, false
+	}
+	if req.Temperature != nil && (*req.Temperature < 0 || *req.Temperature > maxTemperature) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "temperature must be between 0 and 2")
+		return req, false
+	}
+	if req.MaxTokens < 0 || req.MaxTokens > maxMaxTokens {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "max_tokens must be between 1 and 32000")
+		return req, false
+	}
+	watermark, err := normalizeWatermark(req.Watermark)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return req, false
+	}
+	req.Watermark = watermark
+	req.Length, req.TargetWords, req.TargetSections = resolveLength(req.Length, req.TargetWords, req.TargetSections)
+	return req, true
+}
+
+// Status reports the pipeline progress of a research document.
+func (h *Handler) Status(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+
+	status := doc.Status
+	if status == "" {
+		// Documents created before async jobs are always finished.
+		status = models.StatusComplete
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"id":            id,
+		"status":        status,
+		"step":          doc.Step,
+		"error":         doc.Error,
+		"compile_error": doc.CompileError,
+	})
 }
 
-// List returns all research for the current user.
+// Page size limits for List.
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// List returns a page of research for the current user, newest first,
+// optionally filtered by ?model=, ?tag=, a cited ?domain=, and an RFC3339
+// ?from=/?to= range. Pass the returned next_cursor as ?cursor= to fetch the
+// following page. Documents in the trash are left out.
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(string)
-	docs, err := h.mongo.ListByUser(r.Context(), userID)
+	h.listDocs(w, r, false)
+}
+
+// Trash lists the current user's soft-deleted research, with the same
+// filters and paging as List.
+func (h *Handler) Trash(w http.ResponseWriter, r *http.Request) {
+	h.listDocs(w, r, true)
+}
+
+func (h *Handler) listDocs(w http.ResponseWriter, r *http.Request, trashed bool) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+
+	opts := models.ListOptions{
+		Limit:  defaultListLimit,
+		Cursor: q.Get("cursor"),
+		Model:  q.Get("model"),
+		Domain: q.Get("domain"),
+
+		Trashed: trashed,
+	}
+	if v := q.Get("tag"); v != "" {
+		tags, err := normalizeTags([]string{v})
+		if err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+			return
+		}
+		if len(tags) > 0 {
+			opts.Tag = tags[0]
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &opts.From}, {"to", &opts.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, p.name+" must be an RFC3339 timestamp")
+				return
+			}
+			*p.dst = t
+		}
+	}
+	if !opts.From.IsZero() && !opts.To.IsZero() && opts.From.After(opts.To) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "from must not be after to")
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "limit must be between 1 and 100")
+			return
+		}
+		opts.Limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.opts.ListTimeout)
+	docs, next, err := h.mongo.ListByUser(ctx, userID, opts)
+	cancel()
+	if errors.Is(err, models.ErrInvalidCursor) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid cursor")
+		return
+	}
+	if err != nil {
+		log.Printf("list research for %s: %v", userID, err)
+		// Serve the last page we got for the same request rather than
+		// nothing, flagged so the client knows it may be out of date.
+		if docs, next, ok := h.opts.ListCache.Load(r.Context(), userID, opts); ok {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"documents":   docs,
+				"next_cursor": next,
+				"stale":       true,
+			})
+			return
+		}
+		w.Header().Set("Retry-After", "5")
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "research list is temporarily unavailable, please retry")
+		return
+	}
+	if docs == nil {
+		docs = []models.Document{}
+	}
+	h.opts.ListCache.Save(r.Context(), userID, opts, docs, next)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"documents":   docs,
+		"next_cursor": next,
+	})
+}
+
+// Search finds the current user's research matching ?q= by topic and content.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "q is required")
+		return
+	}
+
+	docs, err := h.mongo.SearchByUser(r.Context(), userID, q)
 	if err != nil {
-		http.Error(w, `{"error":"database error"}`, http.StatusInternalServerError)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "database error")
 		return
 	}
 	if docs == nil {
 		docs = []models.Document{}
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(docs)
+	writeJSON(w, http.StatusOK, docs)
+}
+
+// Domains returns how often each source domain is cited across the current
+// user's research, most cited first.
+func (h *Handler) Domains(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	counts, err := h.mongo.DomainsByUser(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "database error")
+		return
+	}
+	if counts == nil {
+		counts = []models.DomainCount{}
+	}
+	writeJSON(w, http.StatusOK, counts)
 }
 
 // Get returns a single research document.
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	doc, err := h.mongo.GetByID(r.Context(), id)
-	if err != nil {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(doc)
 }
 
-// Delete removes a research document and its files.
+// Sources returns only the cited sources of a research document.
+func (h *Handler) Sources(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+
+	sources := doc.Sources
+	if sources == nil {
+		sources = []models.Source{}
+	}
+	assignSourceIDs(sources)
+	writeJSON(w, http.StatusOK, sources)
+}
+
+// Delete moves a research document to the trash, or with ?permanent=true
+// removes it and its files for good, whether or not it is in the trash.
+// Running research must be cancelled first.
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
-	doc, err := h.mongo.GetByID(r.Context(), id)
-	if err != nil {
-		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+	doc, ok := h.ownedDocInTrash(w, r)
+	if !ok {
 		return
 	}
-
-	// Clean up MinIO
-	if doc.PDFObjectKey != "" {
-		h.minio.Remove(r.Context(), doc.PDFObjectKey)
+	permanent := r.URL.Query().Get("permanent") == "true"
+	if !permanent && doc.DeletedAt != nil {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "document is already in the trash")
+		return
 	}
-	if doc.TexObjectKey != "" {
-		h.minio.Remove(r.Context(), doc.TexObjectKey)
+	if doc.Status == models.StatusPending || doc.Status == models.StatusRunning {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research is still running")
+		return
+	}
+	release, ok := h.lockDoc(w, r, id)
+	if !ok {
+		return
+	}
+	defer release()
+
+	if !permanent {
+		now := time.Now().UTC()
+		if err := h.mongo.SetDeleted(r.Context(), id, &now); err != nil {
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "delete failed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"message": "moved to trash", "deleted_at": now})
+		return
 	}
 
-	if err := h.mongo.Delete(r.Context(), id); err != nil {
-		http.Error(w, `{"error":"delete failed"}`, http.StatusInternalServerError)
+	if err := h.purge(r.Context(), doc, id); err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "delete failed")
 		return
 	}
 
@@ -233,40 +740,108 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"message":"deleted"}`))
 }
 
+// purge permanently removes a document, its files and its versions.
+func (h *Handler) purge(ctx context.Context, doc *models.Document, id string) error {
+	// Clean up MinIO
+	if doc.PDFObjectKey != "" {
+		h.minio.Remove(ctx, doc.PDFObjectKey)
+	}
+	if doc.TexObjectKey != "" {
+		h.minio.Remove(ctx, doc.TexObjectKey)
+	}
+	// After a version restore the document points at archived files, and
+	// the canonical ones are left over.
+	if pdfKey, texKey := artifactKeys(doc, id); doc.PDFObjectKey != pdfKey || doc.TexObjectKey != texKey {
+		h.minio.Remove(ctx, pdfKey)
+		h.minio.Remove(ctx, texKey)
+	}
+
+	if err := h.mongo.Delete(ctx, id); err != nil {
+		return err
+	}
+	h.deleteVersions(ctx, id)
+	return nil
+}
+
 // DownloadPDF streams the PDF from MinIO.
 func (h *Handler) DownloadPDF(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	doc, err := h.mongo.GetByID(r.Context(), id)
-	if err != nil || doc.PDFObjectKey == "" {
-		http.Error(w, `{"error":"pdf not available"}`, http.StatusNotFound)
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
 		return
 	}
+	h.servePDF(w, r, doc)
+}
 
-	data, ct, err := h.minio.Download(r.Context(), doc.PDFObjectKey)
-	if err != nil {
-		http.Error(w, `{"error":"download failed"}`, http.StatusInternalServerError)
+// servePDF writes the stored PDF of doc as a download.
+func (h *Handler) servePDF(w http.ResponseWriter, r *http.Request, doc *models.Document) {
+	if doc.PDFObjectKey == "" {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "pdf not available")
 		return
 	}
-	w.Header().Set("Content-Type", ct)
-	w.Header().Set("Content-Disposition", "attachment; filename=report.pdf")
-	w.Write(data)
+
+	h.serveObject(w, r, doc.PDFObjectKey, "", contentDisposition(doc, "pdf"))
 }
 
 // DownloadTex streams the .tex source from MinIO.
 func (h *Handler) DownloadTex(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	doc, err := h.mongo.GetByID(r.Context(), id)
-	if err != nil || doc.TexObjectKey == "" {
-		http.Error(w, `{"error":"tex not available"}`, http.StatusNotFound)
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
 		return
 	}
+	if doc.TexObjectKey == "" {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "tex not available")
+		return
+	}
+
+	h.serveObject(w, r, doc.TexObjectKey, "application/x-tex", contentDisposition(doc, "tex"))
+}
 
-	data, _, err := h.minio.Download(r.Context(), doc.TexObjectKey)
+// serveObject streams the stored object at key to the client without
+// buffering it, answering Range, If-None-Match and If-Modified-Since
+// requests. contentType overrides the stored content type if set.
+func (h *Handler) serveObject(w http.ResponseWriter, r *http.Request, key, contentType, disposition string) {
+	// Check the ETag first so an unchanged file is never opened.
+	info, err := h.minio.Stat(r.Context(), key)
 	if err != nil {
-		http.Error(w, `{"error":"download failed"}`, http.StatusInternalServerError)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "download failed")
 		return
 	}
-	w.Header().Set("Content-Type", "application/x-tex")
-	w.Header().Set("Content-Disposition", "attachment; filename=report.tex")
-	w.Write(data)
+	if info.ETag != "" {
+		w.Header().Set("ETag", info.ETag)
+		if etagMatches(r.Header.Get("If-None-Match"), info.ETag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	body, info, err := h.minio.DownloadStream(r.Context(), key)
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "download failed")
+		return
+	}
+	// Closing also ends the object read if the client goes away mid-copy.
+	defer body.Close()
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", disposition)
+	http.ServeContent(w, r, "", info.ModTime, body)
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison RFC 9110 prescribes for it.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
 }
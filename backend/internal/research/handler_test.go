@@ -0,0 +1,390 @@
+package research
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+type listResponse struct {
+	Documents  []models.Document `json:"documents"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+func TestListPages(t *testing.T) {
+	env := newTestEnv(t, nil)
+	for _, topic := range []string{"first", "second", "third"} {
+		env.insert(t, &models.Document{UserID: "user-a", Topic: topic})
+	}
+	env.insert(t, &models.Document{UserID: "user-b", Topic: "not mine"})
+
+	list := func(target string) *httptest.ResponseRecorder {
+		return serve(env.h.List, newRequest(t, http.MethodGet, target, "user-a", nil))
+	}
+	var topics []string
+	target := "/research/?limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages == 3 {
+			t.Fatal("paging did not end")
+		}
+		w := list(target)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: got %d %s", w.Code, w.Body)
+		}
+		var resp listResponse
+		decode(t, w, &resp)
+		for _, d := range resp.Documents {
+			topics = append(topics, d.Topic)
+		}
+		target = ""
+		if resp.NextCursor != "" {
+			target = "/research/?limit=2&cursor=" + resp.NextCursor
+		}
+	}
+	if !slices.Equal(topics, []string{"third", "second", "first"}) {
+		t.Errorf("listed %v, want newest first", topics)
+	}
+
+	for _, bad := range []string{"/research/?cursor=nope", "/research/?limit=0", "/research/?limit=101"} {
+		if w := list(bad); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", bad, w.Code)
+		}
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	env := newTestEnv(t, nil)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+	for _, d := range []models.Document{
+		{UserID: "user-a", Topic: "small-1", ModelUsed: "mistral-small-latest", CreatedAt: day(1)},
+		{UserID: "user-a", Topic: "large-2", ModelUsed: "mistral-large-latest", CreatedAt: day(2)},
+		{UserID: "user-a", Topic: "small-3", ModelUsed: "mistral-small-latest", CreatedAt: day(3)},
+		{UserID: "user-b", Topic: "other", ModelUsed: "mistral-small-latest", CreatedAt: day(2)},
+	} {
+		// Insert stamps the current time, as MongoDB does; backdate after.
+		createdAt := d.CreatedAt
+		id := env.insert(t, &d)
+		d.CreatedAt = createdAt
+		if err := env.docs.Update(context.Background(), id, &d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"none", "", []string{"small-3", "large-2", "small-1"}},
+		{"model", "?model=mistral-small-latest", []string{"small-3", "small-1"}},
+		{"from", "?from=2026-03-02T00:00:00Z", []string{"small-3", "large-2"}},
+		{"to", "?to=2026-03-02T12:00:00Z", []string{"large-2", "small-1"}},
+		{"range", "?from=2026-03-02T00:00:00Z&to=2026-03-02T23:59:59Z", []string{"large-2"}},
+		{"combined", "?model=mistral-small-latest&from=2026-03-02T00:00:00Z", []string{"small-3"}},
+		{"no match", "?model=gpt-4o", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(env.h.List, newRequest(t, http.MethodGet, "/research/"+tt.query, "user-a", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("got %d %s", w.Code, w.Body)
+			}
+			var resp listResponse
+			decode(t, w, &resp)
+			var topics []string
+			for _, d := range resp.Documents {
+				topics = append(topics, d.Topic)
+			}
+			if !slices.Equal(topics, tt.want) {
+				t.Errorf("listed %v, want %v", topics, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"?from=yesterday", "?to=2026-03-02", "?from=2026-03-03T00:00:00Z&to=2026-03-01T00:00:00Z"} {
+		if w := serve(env.h.List, newRequest(t, http.MethodGet, "/research/"+bad, "user-a", nil)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", bad, w.Code)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels"})
+
+	w := serve(env.h.Get, newRequest(t, http.MethodGet, "/research/"+id, "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: got %d %s, want 200", w.Code, w.Body)
+	}
+	var doc models.Document
+	decode(t, w, &doc)
+	if doc.ID.Hex() != id || doc.Topic != "solar panels" {
+		t.Errorf("got %s %q", doc.ID.Hex(), doc.Topic)
+	}
+
+	for _, missing := range []string{"000000000000000000000000", "not-an-id"} {
+		w := serve(env.h.Get, newRequest(t, http.MethodGet, "/research/"+missing, "user-a", nil, "id", missing))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("get %s: got %d, want 404", missing, w.Code)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	if len(env.files.Keys()) == 0 {
+		t.Fatal("create stored no files")
+	}
+
+	w := serve(env.h.Delete, newRequest(t, http.MethodDelete, "/research/"+id+"?permanent=true", "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: got %d %s, want 200", w.Code, w.Body)
+	}
+	if _, err := env.docs.GetByID(context.Background(), id); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("document still stored: %v", err)
+	}
+	if keys := env.files.Keys(); len(keys) != 0 {
+		t.Errorf("files left after delete: %v", keys)
+	}
+}
+
+func TestOtherUsersDocumentsAreForbidden(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	env := newTestEnv(t, &Options{Progress: NewProgress(rdb)})
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	keys := env.files.Keys()
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"get":         env.h.Get,
+		"status":      env.h.Status,
+		"events":      env.h.Events,
+		"sources":     env.h.Sources,
+		"pdf":         env.h.DownloadPDF,
+		"tex":         env.h.DownloadTex,
+		"gap queries": env.h.GapQueries,
+		"regenerate":  env.h.Regenerate,
+		"edit latex":  env.h.UpdateLatex,
+		"versions":    env.h.ListVersions,
+		"sources csv": env.h.SourcesCSV,
+		"sources bib": env.h.SourcesBibTeX,
+		"bundle":      env.h.Bundle,
+		"add source":  env.h.AddSource,
+		"refresh":     env.h.RefreshSources,
+		"delete":      env.h.Delete,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(handler, newRequest(t, http.MethodPost, "/research/"+id, "user-b",
+				map[string]string{"api_key": "sk-test", "latex_content": "\\section{Mine}", "href": "https://example.net/x"}, "id", id))
+			if w.Code != http.StatusForbidden {
+				t.Errorf("got %d %s, want 403", w.Code, w.Body)
+			}
+		})
+	}
+
+	after, err := env.docs.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("user-a's document is gone: %v", err)
+	}
+	if after.LatexContent != doc.LatexContent || after.Status != models.StatusComplete {
+		t.Errorf("user-a's document changed: %+v", after)
+	}
+	if !slices.Equal(env.files.Keys(), keys) {
+		t.Errorf("files = %v, want %v", env.files.Keys(), keys)
+	}
+	if n := env.ai.called("/api/gap-queries"); n != 0 {
+		t.Errorf("gap-queries called %d times for another user's document", n)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "t", Status: models.StatusComplete})
+	missing := "000000000000000000000000"
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		userID, id string
+		body       interface{}
+		wantStatus int
+		wantCode   apierror.Code
+	}{
+		{"missing document", env.h.Get, "user-a", missing, nil, http.StatusNotFound, apierror.NotFound},
+		{"other user", env.h.Get, "user-b", id, nil, http.StatusForbidden, apierror.Forbidden},
+		{"no user", env.h.Get, "", id, nil, http.StatusUnauthorized, apierror.Unauthorized},
+		{"missing topic", env.h.Create, "user-a", "", models.CreateRequest{APIKey: "sk-test"}, http.StatusBadRequest, apierror.InvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.handler, newRequest(t, http.MethodPost, "/research/"+tt.id, tt.userID, tt.body, "id", tt.id))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+			var body apierror.Body
+			decode(t, w, &body)
+			if body.Error.Code != tt.wantCode || body.Error.Message == "" {
+				t.Errorf("got %+v, want code %q with a message", body.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandlersWithoutUserAreUnauthorized(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "t", Status: models.StatusComplete})
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"create":   env.h.Create,
+		"preview":  env.h.Preview,
+		"list":     env.h.List,
+		"search":   env.h.Search,
+		"domains":  env.h.Domains,
+		"get":      env.h.Get,
+		"status":   env.h.Status,
+		"sources":  env.h.Sources,
+		"pdf":      env.h.DownloadPDF,
+		"share":    env.h.CreateShare,
+		"bulk tag": env.h.BulkTag,
+		"delete":   env.h.Delete,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(handler, newRequest(t, http.MethodPost, "/research/"+id, "",
+				map[string]string{"topic": "solar panels", "api_key": "sk-test"}, "id", id))
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("got %d %s, want 401", w.Code, w.Body)
+			}
+		})
+	}
+	if _, err := env.docs.GetByID(context.Background(), id); err != nil {
+		t.Errorf("document is gone: %v", err)
+	}
+}
+
+func TestSimilarTopicsGetDistinctFiles(t *testing.T) {
+	env := newTestEnv(t, nil)
+	// The first 20 characters of these topics are identical.
+	a := env.create(t, "user-a", models.CreateRequest{Topic: "Renewable energy in Germany", APIKey: "sk-test"})
+	env.ai.report = "\\section{Spain}\n"
+	b := env.create(t, "user-a", models.CreateRequest{Topic: "Renewable energy in Spain", APIKey: "sk-test"})
+
+	if a.PDFObjectKey == b.PDFObjectKey || a.TexObjectKey == b.TexObjectKey {
+		t.Fatalf("documents share files: %s %s", a.PDFObjectKey, b.PDFObjectKey)
+	}
+	for _, doc := range []*models.Document{a, b} {
+		if want := "user-a/" + doc.ID.Hex() + ".pdf"; doc.PDFObjectKey != want {
+			t.Errorf("pdf key = %q, want %q", doc.PDFObjectKey, want)
+		}
+	}
+	if len(env.files.Keys()) != 4 {
+		t.Errorf("files = %v, want two per document", env.files.Keys())
+	}
+
+	w := serve(env.h.DownloadTex, newRequest(t, http.MethodGet, "/research/"+a.ID.Hex()+"/tex", "user-a", nil, "id", a.ID.Hex()))
+	if got, want := w.Header().Get("Content-Disposition"), `attachment; filename="renewable-energy-in-germany.tex"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+}
+
+func TestDownloadName(t *testing.T) {
+	tests := []struct {
+		topic, lang, want string
+	}{
+		{"Solar Panels: Cost & Efficiency!", "en", "solar-panels-cost-efficiency.pdf"},
+		{"  --Wind--  ", "en", "wind.pdf"},
+		{"太陽光", "en", "太陽光.pdf"},
+		{"🎉 🎉", "en", "report.pdf"},
+	}
+	for _, tt := range tests {
+		if got := downloadName(&models.Document{Topic: tt.topic, Language: tt.lang}, "pdf"); got != tt.want {
+			t.Errorf("downloadName(%q) = %q, want %q", tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadRange(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	pdf := bytes.Repeat([]byte("0123456789"), 50)
+	env.files.Upload(context.Background(), doc.PDFObjectKey, pdf, "application/pdf")
+
+	id := doc.ID.Hex()
+	r := newRequest(t, http.MethodGet, "/research/"+id+"/pdf", "user-a", nil, "id", id)
+	r.Header.Set("Range", "bytes=0-99")
+	w := serve(env.h.DownloadPDF, r)
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("got %d, want 206", w.Code)
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes 0-99/500" {
+		t.Errorf("Content-Range = %q, want bytes 0-99/500", got)
+	}
+	if !bytes.Equal(w.Body.Bytes(), pdf[:100]) {
+		t.Errorf("got %d bytes, want the first 100", w.Body.Len())
+	}
+
+	r = newRequest(t, http.MethodGet, "/research/"+id+"/pdf", "user-a", nil, "id", id)
+	if w := serve(env.h.DownloadPDF, r); w.Code != http.StatusOK || w.Body.Len() != len(pdf) {
+		t.Errorf("full download: got %d with %d bytes, want 200 with %d", w.Code, w.Body.Len(), len(pdf))
+	}
+}
+
+func TestDownloadETag(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	download := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := newRequest(t, http.MethodGet, "/research/"+id+"/pdf", "user-a", nil, "id", id)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		return serve(env.h.DownloadPDF, r)
+	}
+
+	w := download("")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("got %d with ETag %q, want 200 with an ETag", w.Code, etag)
+	}
+
+	tests := []struct {
+		name, header string
+		code         int
+	}{
+		{"matching", etag, http.StatusNotModified},
+		{"weak matching", "W/" + etag, http.StatusNotModified},
+		{"in a list", `"stale", ` + etag, http.StatusNotModified},
+		{"wildcard", "*", http.StatusNotModified},
+		{"stale", `"stale"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := download(tt.header)
+			if w.Code != tt.code {
+				t.Fatalf("got %d, want %d", w.Code, tt.code)
+			}
+			if tt.code == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 carried a %d byte body", w.Body.Len())
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+		})
+	}
+
+	env.files.Upload(context.Background(), doc.PDFObjectKey, []byte("%PDF-1.7 recompiled"), "application/pdf")
+	if w := download(etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("after the file changed: got %d with ETag %q, want 200 with a new ETag", w.Code, w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,94 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// IdempotencyHeader lets clients retry a create without starting a second
+// pipeline.
+const IdempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLen bounds client-chosen keys.
+const maxIdempotencyKeyLen = 255
+
+// idempotencyPending marks a key whose create is still inserting its
+// document.
+const idempotencyPending = "pending"
+
+// IdempotencyStore maps per-user Idempotency-Key values to the document the
+// first request with that key created.
+type IdempotencyStore struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+func NewIdempotencyStore(rdb *redis.Client, ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{rdb: rdb, ttl: ttl}
+}
+
+func idempotencyKey(userID, key string) string {
+	return "idempotency:" + userID + ":" + key
+}
+
+// Reserve claims key for a new create. If the key was already used it
+// returns reserved=false and the stored value: a document ID, or
+// idempotencyPending while the first request is still inserting it.
+func (s *IdempotencyStore) Reserve(ctx context.Context, userID, key string) (existing string, reserved bool, err error) {
+	k := idempotencyKey(userID, key)
+	reserved, err = s.rdb.SetNX(ctx, k, idempotencyPending, s.ttl).Result()
+	if err != nil || reserved {
+		return "", reserved, err
+	}
+	existing, err = s.rdb.Get(ctx, k).Result()
+	if errors.Is(err, redis.Nil) {
+		// Expired between the two calls; treat as still in flight so the
+		// client retries rather than racing a second reservation.
+		return idempotencyPending, false, nil
+	}
+	return existing, false, err
+}
+
+// Complete records the document created under a reserved key.
+func (s *IdempotencyStore) Complete(ctx context.Context, userID, key, docID string) error {
+	return s.rdb.Set(ctx, idempotencyKey(userID, key), docID, s.ttl).Err()
+}
+
+// Release frees a reserved key after a create failed, so it can be retried.
+func (s *IdempotencyStore) Release(ctx context.Context, userID, key string) {
+	if err := s.rdb.Del(ctx, idempotencyKey(userID, key)).Err(); err != nil {
+		log.Printf("release idempotency key for %s: %v", userID, err)
+	}
+}
+
+// replayCreate answers a create whose Idempotency-Key was already used:
+// the existing document once its run has finished, or a 409 while it is
+// still in progress.
+func (h *Handler) replayCreate(w http.ResponseWriter, r *http.Request, existing string) {
+	if existing == idempotencyPending {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "a request with this Idempotency-Key is in progress")
+		return
+	}
+	doc, err := h.mongo.GetByID(r.Context(), existing)
+	if err != nil {
+		// The document was deleted since; the key stays spent until it
+		// expires.
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "the document created with this Idempotency-Key no longer exists")
+		return
+	}
+	switch doc.Status {
+	case models.StatusPending, models.StatusRunning:
+		apierror.WriteDetails(w, http.StatusConflict, apierror.Conflict, "a request with this Idempotency-Key is still running",
+			map[string]interface{}{"id": existing})
+	default:
+		writeJSON(w, http.StatusOK, doc)
+	}
+}
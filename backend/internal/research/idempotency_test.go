@@ -0,0 +1,63 @@
+package research
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestIdempotentCreate(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	env := newTestEnv(t, &Options{Idempotency: NewIdempotencyStore(rdb, time.Hour)})
+	body := models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"}
+	create := func(userID, key string) *httptest.ResponseRecorder {
+		r := newRequest(t, http.MethodPost, "/research", userID, body)
+		r.Header.Set(IdempotencyHeader, key)
+		return serve(env.h.Create, r)
+	}
+
+	const n = 8
+	codes := make(map[int]int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := create("user-a", "retry-1")
+			mu.Lock()
+			codes[w.Code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	env.wait(t)
+	if codes[http.StatusAccepted] != 1 || codes[http.StatusConflict] != n-1 {
+		t.Fatalf("status counts %v, want one 202 and %d 409s", codes, n-1)
+	}
+	if got := env.ai.called("/api/generate-report"); got != 1 {
+		t.Errorf("generated %d reports, want 1", got)
+	}
+
+	w := create("user-a", "retry-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("replay after the run: got %d %s, want 200", w.Code, w.Body)
+	}
+	var replayed models.Document
+	decode(t, w, &replayed)
+	if replayed.Status != models.StatusComplete || replayed.LatexContent == "" {
+		t.Errorf("replay returned status %q, want the finished document", replayed.Status)
+	}
+
+	if w := create("user-b", "retry-1"); w.Code != http.StatusAccepted {
+		t.Errorf("same key for another user: got %d, want 202", w.Code)
+	}
+	if w := create("user-a", "retry-2"); w.Code != http.StatusAccepted {
+		t.Errorf("new key: got %d, want 202", w.Code)
+	}
+	env.wait(t)
+}
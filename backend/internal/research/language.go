@@ -0,0 +1,41 @@
+package research
+
+// DefaultLanguage is the report language when a request doesn't pick one.
+const DefaultLanguage = "en"
+
+// supportedLanguages maps the ISO 639-1 codes reports can be written in to
+// the ASCII word used for "report" in download filenames.
+var supportedLanguages = map[string]string{
+	"en": "report",
+	"es": "informe",
+	"fr": "rapport",
+	"de": "bericht",
+	"it": "relazione",
+	"pt": "relatorio",
+	"nl": "rapport",
+	"pl": "raport",
+	"sv": "rapport",
+	"tr": "rapor",
+	"ru": "otchet",
+	"zh": "baogao",
+	"ja": "hokokusho",
+	"ko": "bogoseo",
+	"ar": "taqrir",
+	"hi": "report",
+}
+
+// validLanguage reports whether reports can be written in lang.
+func validLanguage(lang string) bool {
+	_, ok := supportedLanguages[lang]
+	return ok
+}
+
+// reportFilename returns the download filename of a report in lang with
+// the given extension, e.g. "rapport.pdf".
+func reportFilename(lang, ext string) string {
+	name, ok := supportedLanguages[lang]
+	if !ok {
+		name = supportedLanguages[DefaultLanguage]
+	}
+	return name + "." + ext
+}
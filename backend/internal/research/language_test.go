@@ -0,0 +1,28 @@
+package research
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestCreateLanguage(t *testing.T) {
+	env := newTestEnv(t, nil)
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Language: "DE"})
+	if doc.Language != "de" {
+		t.Errorf("language = %q, want de", doc.Language)
+	}
+	env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	bodies := env.ai.bodies["/api/generate-report"]
+	if bodies[0]["language"] != "de" || bodies[1]["language"] != DefaultLanguage {
+		t.Errorf("generate-report languages = %v, %v, want de then %s", bodies[0]["language"], bodies[1]["language"], DefaultLanguage)
+	}
+
+	w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a",
+		models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Language: "xx"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unsupported language: got %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package research
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultMaxLatexBytes bounds a report's LaTeX body when Options doesn't.
+const DefaultMaxLatexBytes = 1 << 20
+
+// Reasons a LaTeX body is rejected before it reaches the compiler.
+var (
+	errLatexEmpty      = errors.New("the LaTeX body has no text")
+	errLatexPreamble   = errors.New(`the LaTeX body must not contain \documentclass or \begin{document}; the LaTeX service adds them`)
+	errLatexUnbalanced = errors.New(`the LaTeX body has unmatched \begin and \end`)
+)
+
+// latexTooLargeError means a body exceeds the configured size limit.
+type latexTooLargeError struct {
+	size, limit int
+}
+
+func (e *latexTooLargeError) Error() string {
+	return fmt.Sprintf("the LaTeX body is %d bytes, over the %d byte limit", e.size, e.limit)
+}
+
+// validateLatexBody rejects bodies that are too large for the compiler and
+// storage, or so malformed that compiling them can only fail. Bodies are
+// what goes between \begin{document} and \end{document}: the LaTeX
+// service wraps them in its own preamble and title page.
+func validateLatexBody(body string, maxBytes int) error {
+	if maxBytes > 0 && len(body) > maxBytes {
+		return &latexTooLargeError{size: len(body), limit: maxBytes}
+	}
+	if !hasText(body) {
+		return errLatexEmpty
+	}
+	if strings.Contains(body, `\documentclass`) || strings.Contains(body, `\begin{document}`) {
+		return errLatexPreamble
+	}
+	if strings.Count(body, `\begin{`) != strings.Count(body, `\end{`) {
+		return errLatexUnbalanced
+	}
+	return nil
+}
+
+// hasText reports whether body has any letters outside of command names
+// and comments.
+func hasText(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		inCommand := false
+		for i, c := range line {
+			if c == '%' && (i == 0 || line[i-1] != '\\') {
+				break // the rest of the line is a comment
+			}
+			switch {
+			case c == '\\':
+				inCommand = true
+			case unicode.IsLetter(c):
+				if !inCommand {
+					return true
+				}
+			default:
+				inCommand = false
+			}
+		}
+	}
+	return false
+}
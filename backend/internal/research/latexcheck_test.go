@@ -0,0 +1,93 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestValidateLatexBody(t *testing.T) {
+	var tooLarge *latexTooLargeError
+	tests := []struct {
+		name string
+		body string
+		want func(error) bool
+	}{
+		{"report", "\\section{Intro}\nSolar panels turn light into power.\n", nil},
+		{"escaped percent", "Efficiency rose by 20\\% in a decade.", nil},
+		{"empty", "", is(errLatexEmpty)},
+		{"whitespace", " \n\t\n", is(errLatexEmpty)},
+		{"commands only", "\\newpage\n\\tableofcontents\n", is(errLatexEmpty)},
+		{"comments only", "% TODO: write the report\n%% more\n", is(errLatexEmpty)},
+		{"full document", "\\documentclass{article}\n\\begin{document}\nHi\n\\end{document}", is(errLatexPreamble)},
+		{"unbalanced", "\\begin{itemize}\n\\item Solar\n", is(errLatexUnbalanced)},
+		{"oversized", "\\section{Intro}\n" + strings.Repeat("Solar panels. ", 100), func(err error) bool { return errors.As(err, &tooLarge) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLatexBody(tt.body, 1000)
+			if tt.want == nil && err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+			if tt.want != nil && !tt.want(err) {
+				t.Errorf("got %v", err)
+			}
+		})
+	}
+	if tooLarge == nil || tooLarge.limit != 1000 || tooLarge.size <= 1000 {
+		t.Errorf("too large error = %+v", tooLarge)
+	}
+}
+
+func is(target error) func(error) bool {
+	return func(err error) bool { return errors.Is(err, target) }
+}
+
+func TestCreateRejectsUnusableReport(t *testing.T) {
+	tests := []struct {
+		name, report, wantErr string
+	}{
+		{"oversized", "\\section{Intro}\n" + strings.Repeat("Solar panels. ", 100), "over the 512 byte limit"},
+		{"garbage", "\\relax\n% nothing here\n", "has no text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, &Options{MaxLatexBytes: 512})
+			env.ai.report = tt.report
+			doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+
+			if doc.Status != models.StatusFailed || doc.Step != StepWritingReport || !strings.Contains(doc.Error, tt.wantErr) {
+				t.Errorf("got status %q step %q error %q, want failed with %q", doc.Status, doc.Step, doc.Error, tt.wantErr)
+			}
+			if doc.PDFObjectKey != "" || len(env.files.Keys()) != 0 {
+				t.Errorf("unusable report was compiled and uploaded: %v", env.files.Keys())
+			}
+		})
+	}
+}
+
+func TestUpdateLatexRejectsInvalidBody(t *testing.T) {
+	env := newTestEnv(t, &Options{MaxLatexBytes: 512})
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+
+	for name, latex := range map[string]string{
+		"oversized":     "\\section{Intro}\n" + strings.Repeat("Solar panels. ", 100),
+		"full document": "\\documentclass{article}\n\\begin{document}\nHi\n\\end{document}",
+		"garbage":       "\\relax\n",
+	} {
+		w := serve(env.h.UpdateLatex, newRequest(t, http.MethodPut, "/research/"+id+"/latex", "user-a",
+			map[string]string{"latex_content": latex}, "id", id))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: got %d %s, want 422", name, w.Code, w.Body)
+		}
+	}
+	after, _ := env.docs.GetByID(context.Background(), id)
+	if after.LatexContent != doc.LatexContent {
+		t.Errorf("latex content changed to %q", after.LatexContent)
+	}
+}
@@ -0,0 +1,24 @@
+package research
+
+import "strings"
+
+// resolveLength picks the length preset named by name (case-insensitive,
+// falling back to Standard) and applies explicit word/section overrides.
+// It returns the canonical preset name and the resolved targets.
+func resolveLength(name string, words, sections int) (string, int, int) {
+	preset := "Standard"
+	for k := range LengthConfig {
+		if strings.EqualFold(k, name) {
+			preset = k
+			break
+		}
+	}
+	targets := LengthConfig[preset]
+	if words > 0 {
+		targets[0] = words
+	}
+	if sections > 0 {
+		targets[1] = sections
+	}
+	return preset, targets[0], targets[1]
+}
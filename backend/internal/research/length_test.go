@@ -0,0 +1,68 @@
+package research
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestResolveLength(t *testing.T) {
+	tests := []struct {
+		name            string
+		words, sections int
+		wantPreset      string
+		wantWords       int
+		wantSections    int
+	}{
+		{"", 0, 0, "Standard", 3000, 6},
+		{"Brief", 0, 0, "Brief", 1500, 4},
+		{"comprehensive", 0, 0, "Comprehensive", 6000, 10},
+		{"epic", 0, 0, "Standard", 3000, 6},
+		{"Brief", 800, 0, "Brief", 800, 4},
+		{"Comprehensive", 0, 3, "Comprehensive", 6000, 3},
+		{"", 2000, 5, "Standard", 2000, 5},
+	}
+	for _, tt := range tests {
+		preset, words, sections := resolveLength(tt.name, tt.words, tt.sections)
+		if preset != tt.wantPreset || words != tt.wantWords || sections != tt.wantSections {
+			t.Errorf("resolveLength(%q, %d, %d) = %s, %d, %d; want %s, %d, %d",
+				tt.name, tt.words, tt.sections, preset, words, sections, tt.wantPreset, tt.wantWords, tt.wantSections)
+		}
+	}
+}
+
+func TestCreateForwardsLengthTargets(t *testing.T) {
+	env := newTestEnv(t, nil)
+	brief := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Length: "brief"})
+	long := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Length: "Comprehensive", TargetSections: 12})
+
+	if brief.Length != "Brief" || brief.TargetWords != 1500 || brief.TargetSections != 4 {
+		t.Errorf("brief stored as %s %d/%d", brief.Length, brief.TargetWords, brief.TargetSections)
+	}
+	if long.Length != "Comprehensive" || long.TargetWords != 6000 || long.TargetSections != 12 {
+		t.Errorf("comprehensive stored as %s %d/%d", long.Length, long.TargetWords, long.TargetSections)
+	}
+
+	bodies := env.ai.bodies["/api/generate-report"]
+	if len(bodies) != 2 {
+		t.Fatalf("got %d report requests, want 2", len(bodies))
+	}
+	// JSON numbers decode as float64.
+	if bodies[0]["target_words"] != 1500.0 || bodies[0]["target_sections"] != 4.0 {
+		t.Errorf("brief sent targets %v/%v", bodies[0]["target_words"], bodies[0]["target_sections"])
+	}
+	if bodies[1]["target_words"] != 6000.0 || bodies[1]["target_sections"] != 12.0 {
+		t.Errorf("comprehensive sent targets %v/%v", bodies[1]["target_words"], bodies[1]["target_sections"])
+	}
+
+	for _, req := range []models.CreateRequest{
+		{Topic: "t", APIKey: "sk-test", TargetWords: -1},
+		{Topic: "t", APIKey: "sk-test", TargetWords: 20001},
+		{Topic: "t", APIKey: "sk-test", TargetSections: 21},
+	} {
+		if w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a", req)); w.Code != http.StatusBadRequest {
+			t.Errorf("%d words/%d sections: got %d, want 400", req.TargetWords, req.TargetSections, w.Code)
+		}
+	}
+}
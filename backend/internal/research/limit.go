@@ -0,0 +1,90 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// ErrAIBusy is returned when no AI call slot frees up in time.
+var ErrAIBusy = errors.New("AI service is busy")
+
+const msgAIBusy = "The AI service is busy. Try again in a minute."
+
+// AILimiter bounds how many report generations and searches run at once
+// across all pipelines, so a burst of research runs queues here instead
+// of overwhelming the AI service. Calls wait up to a timeout for a slot.
+// A nil limiter doesn't limit.
+type AILimiter struct {
+	slots chan struct{}
+	wait  time.Duration
+}
+
+// NewAILimiter allows limit concurrent calls, each waiting at most wait
+// for a slot. It returns nil, no limit, if limit is not positive.
+func NewAILimiter(limit int, wait time.Duration) *AILimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &AILimiter{slots: make(chan struct{}, limit), wait: wait}
+}
+
+// acquire takes a slot, returning ErrAIBusy if none frees up within the
+// wait, and the func that gives it back.
+func (l *AILimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-timer.C:
+		return nil, ErrAIBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Wrap returns p with its Search and GenerateReport calls limited.
+func (l *AILimiter) Wrap(p Provider) Provider {
+	if l == nil {
+		return p
+	}
+	return &limitedProvider{Provider: p, limiter: l}
+}
+
+type limitedProvider struct {
+	Provider
+	limiter *AILimiter
+}
+
+func (p *limitedProvider) Search(ctx context.Context, queries []string, resultsPerQuery int) ([]models.Source, error) {
+	release, err := p.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.Provider.Search(ctx, queries, resultsPerQuery)
+}
+
+func (p *limitedProvider) GenerateReport(ctx context.Context, apiKey, model, topic, ctxStr string, sources []models.Source, opts ReportOptions) (string, error) {
+	release, err := p.limiter.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return p.Provider.GenerateReport(ctx, apiKey, model, topic, ctxStr, sources, opts)
+}
+
+// provider returns the named provider with the AI call limit applied.
+func (h *Handler) provider(name string) (Provider, bool) {
+	p, ok := h.opts.Providers.Get(name)
+	if !ok {
+		return nil, false
+	}
+	return h.opts.AILimit.Wrap(p), true
+}
@@ -0,0 +1,78 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// searchOnly is a Provider that only searches, through a countingSearcher.
+type searchOnly struct {
+	Provider
+	s *countingSearcher
+}
+
+func (p searchOnly) Search(ctx context.Context, queries []string, resultsPerQuery int) ([]models.Source, error) {
+	return p.s.Search(ctx, queries, resultsPerQuery)
+}
+
+func TestAILimiterBoundsConcurrency(t *testing.T) {
+	s := &countingSearcher{}
+	p := NewAILimiter(3, 5*time.Second).Wrap(searchOnly{s: s})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.Search(context.Background(), []string{fmt.Sprint(i)}, 2)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("queued search failed: %v", err)
+		}
+	}
+	if s.peak > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", s.peak)
+	}
+}
+
+func TestAILimiterTimesOut(t *testing.T) {
+	l := NewAILimiter(1, 20*time.Millisecond)
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := l.Wrap(searchOnly{s: &countingSearcher{}})
+	if _, err := p.Search(context.Background(), []string{"a"}, 2); !errors.Is(err, ErrAIBusy) {
+		t.Errorf("got %v, want ErrAIBusy", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Search(ctx, []string{"a"}, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: got %v, want context.Canceled", err)
+	}
+
+	release()
+	if _, err := p.Search(context.Background(), []string{"a"}, 2); err != nil {
+		t.Errorf("after release: %v", err)
+	}
+}
+
+func TestNoAILimit(t *testing.T) {
+	p := searchOnly{s: &countingSearcher{}}
+	if l := NewAILimiter(0, time.Second); l != nil || l.Wrap(p) != Provider(p) {
+		t.Error("a zero limit still limits")
+	}
+}
@@ -0,0 +1,187 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Link check defaults.
+const (
+	DefaultLinkCheckTimeout     = 5 * time.Second
+	DefaultLinkCheckConcurrency = 8
+	maxLinkRedirects            = 3
+)
+
+var (
+	errTooManyRedirects = errors.New("too many redirects")
+	errBlockedAddress   = errors.New("address not allowed")
+)
+
+// LinkStatus is the outcome of checking one source URL. Status is the
+// final HTTP status, if a response arrived at all.
+type LinkStatus struct {
+	URL       string `json:"url"`
+	Status    int    `json:"status,omitempty"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// LinkChecker probes source URLs. It only connects to public addresses, so
+// user-supplied sources can't be used to reach internal services.
+type LinkChecker struct {
+	client      *http.Client
+	concurrency int
+}
+
+// NewLinkChecker gives each URL timeout to answer, redirects included, and
+// checks at most concurrency URLs at a time.
+func NewLinkChecker(timeout time.Duration, concurrency int) *LinkChecker {
+	if timeout <= 0 {
+		timeout = DefaultLinkCheckTimeout
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultLinkCheckConcurrency
+	}
+	dialer := &net.Dialer{Timeout: timeout, Control: publicAddrOnly}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConnsPerHost:   2,
+	}
+	return &LinkChecker{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxLinkRedirects {
+					return errTooManyRedirects
+				}
+				return nil
+			},
+		},
+		concurrency: concurrency,
+	}
+}
+
+// publicAddrOnly refuses connections to loopback, private and other
+// non-public addresses.
+func publicAddrOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+// Check probes urls and returns their statuses in the same order.
+func (c *LinkChecker) Check(ctx context.Context, urls []string) []LinkStatus {
+	results := make([]LinkStatus, len(urls))
+	var g errgroup.Group
+	g.SetLimit(c.concurrency)
+	for i, u := range urls {
+		i, u := i, u
+		g.Go(func() error {
+			results[i] = c.check(ctx, u)
+			return nil
+		})
+	}
+	g.Wait()
+	return results
+}
+
+// check probes one URL with HEAD, falling back to GET for servers that
+// don't support it. Any status below 400 counts as reachable.
+func (c *LinkChecker) check(ctx context.Context, url string) LinkStatus {
+	res := LinkStatus{URL: url}
+	if !validSourceURL(url) {
+		res.Error = "invalid url"
+		return res
+	}
+	status, err := c.probe(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.probe(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		res.Error = linkError(err)
+		return res
+	}
+	res.Status = status
+	res.Reachable = status < 400
+	return res
+}
+
+func (c *LinkChecker) probe(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "research-ai-agent-linkcheck/1.0")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Read a little so the connection can be reused for small bodies.
+	io.CopyN(io.Discard, resp.Body, 4<<10)
+	return resp.StatusCode, nil
+}
+
+// linkError describes why a URL couldn't be reached.
+func linkError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, errTooManyRedirects):
+		return fmt.Sprintf("more than %d redirects", maxLinkRedirects)
+	case errors.Is(err, errBlockedAddress):
+		return "address not allowed"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	}
+	return "unreachable"
+}
+
+// CheckLinks reports whether each of a document's source URLs still
+// answers. Unreachable links, timeouts included, are listed rather than
+// failing the request.
+func (h *Handler) CheckLinks(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	urls := make([]string, len(doc.Sources))
+	for i, s := range doc.Sources {
+		urls[i] = s.Href
+	}
+	links := h.opts.LinkCheck.Check(r.Context(), urls)
+	unreachable := 0
+	for _, l := range links {
+		if !l.Reachable {
+			unreachable++
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"links":       links,
+		"checked":     len(links),
+		"unreachable": unreachable,
+	})
+}
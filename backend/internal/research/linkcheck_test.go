@@ -0,0 +1,116 @@
+package research
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// newLinkServer serves a mix of healthy, missing, slow and looping links.
+func newLinkServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/gone", http.NotFound)
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// loopbackLinkChecker is a LinkChecker allowed to reach the test server.
+func loopbackLinkChecker(timeout time.Duration) *LinkChecker {
+	c := NewLinkChecker(timeout, 4)
+	c.client.Transport.(*http.Transport).DialContext = (&net.Dialer{Timeout: timeout}).DialContext
+	return c
+}
+
+func TestLinkCheck(t *testing.T) {
+	srv := newLinkServer(t)
+	c := loopbackLinkChecker(200 * time.Millisecond)
+
+	want := []LinkStatus{
+		{URL: srv.URL + "/ok", Status: 200, Reachable: true},
+		{URL: srv.URL + "/gone", Status: 404},
+		{URL: srv.URL + "/slow", Error: "timeout"},
+		{URL: srv.URL + "/get-only", Status: 200, Reachable: true},
+		{URL: srv.URL + "/moved", Status: 200, Reachable: true},
+		{URL: srv.URL + "/loop", Error: "more than 3 redirects"},
+		{URL: "javascript:alert(1)", Error: "invalid url"},
+	}
+	urls := make([]string, len(want))
+	for i, w := range want {
+		urls[i] = w.URL
+	}
+	start := time.Now()
+	got := c.Check(context.Background(), urls)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("check took %v", elapsed)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %+v, want %+v", got[i], want[i])
+		}
+	}
+}
+
+func TestLinkCheckBlocksPrivateAddresses(t *testing.T) {
+	srv := newLinkServer(t)
+	c := NewLinkChecker(time.Second, 1)
+	for _, url := range []string{srv.URL + "/ok", "http://10.0.0.1/", "http://[::1]/", "http://169.254.169.254/latest/meta-data"} {
+		got := c.Check(context.Background(), []string{url})[0]
+		if got.Reachable || got.Error != "address not allowed" {
+			t.Errorf("%s: got %+v, want address not allowed", url, got)
+		}
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	srv := newLinkServer(t)
+	env := newTestEnv(t, &Options{LinkCheck: loopbackLinkChecker(200 * time.Millisecond)})
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "t", Status: models.StatusComplete, Sources: []models.Source{
+		{Title: "ok", Href: srv.URL + "/ok"},
+		{Title: "gone", Href: srv.URL + "/gone"},
+		{Title: "slow", Href: srv.URL + "/slow"},
+	}})
+
+	w := serve(env.h.CheckLinks, newRequest(t, http.MethodPost, "/research/"+id+"/check-links", "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var got struct {
+		Links       []LinkStatus `json:"links"`
+		Checked     int          `json:"checked"`
+		Unreachable int          `json:"unreachable"`
+	}
+	decode(t, w, &got)
+	if got.Checked != 3 || got.Unreachable != 2 || len(got.Links) != 3 || !got.Links[0].Reachable {
+		t.Errorf("got %+v, want 3 checked with the last 2 unreachable", got)
+	}
+
+	w = serve(env.h.CheckLinks, newRequest(t, http.MethodPost, "/research/"+id+"/check-links", "user-b", nil, "id", id))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("other user: got %d, want 403", w.Code)
+	}
+}
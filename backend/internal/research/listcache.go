@@ -0,0 +1,83 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// DefaultListTimeout bounds the database query behind List and Trash.
+const DefaultListTimeout = 5 * time.Second
+
+// ListCache keeps the last successful page of each list request for a
+// short time, so List can answer from it while MongoDB is slow or down. It
+// is only read as a fallback, never in place of the database. A nil cache
+// is always empty.
+type ListCache struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// NewListCache keeps pages for ttl; it returns nil, no cache, if ttl is
+// not positive.
+func NewListCache(rdb *redis.Client, ttl time.Duration) *ListCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &ListCache{rdb: rdb, ttl: ttl}
+}
+
+// cachedList is a stored page of List.
+type cachedList struct {
+	Documents  []models.Document `json:"documents"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+func listKey(userID string, opts models.ListOptions) string {
+	return cacheKey("cache:list:", userID, strconv.Itoa(opts.Limit), opts.Cursor,
+		opts.Model, opts.From.Format(time.RFC3339), opts.To.Format(time.RFC3339),
+		strings.ToLower(opts.Domain), opts.Tag, strconv.FormatBool(opts.Trashed))
+}
+
+// Save remembers a page returned for userID and opts. Failures are only
+// logged: the cache is a fallback.
+func (c *ListCache) Save(ctx context.Context, userID string, opts models.ListOptions, docs []models.Document, next string) {
+	if c == nil {
+		return
+	}
+	payload, err := json.Marshal(cachedList{Documents: docs, NextCursor: next})
+	if err != nil {
+		return
+	}
+	if err := c.rdb.Set(ctx, listKey(userID, opts), payload, c.ttl).Err(); err != nil {
+		log.Printf("list cache save %s: %v", userID, err)
+	}
+}
+
+// Load returns the page last saved for userID and opts, if it hasn't
+// expired.
+func (c *ListCache) Load(ctx context.Context, userID string, opts models.ListOptions) ([]models.Document, string, bool) {
+	if c == nil {
+		return nil, "", false
+	}
+	data, err := c.rdb.Get(ctx, listKey(userID, opts)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("list cache load %s: %v", userID, err)
+		}
+		return nil, "", false
+	}
+	var page cachedList
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, "", false
+	}
+	return page.Documents, page.NextCursor, true
+}
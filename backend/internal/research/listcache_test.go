@@ -0,0 +1,103 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+	"github.com/ayush/research-ai-agent/backend/internal/storetest"
+)
+
+// flakyStore is a ResearchStore whose ListByUser hangs until its context
+// is done while hang is set, and fails while down is set.
+type flakyStore struct {
+	*storetest.ResearchStore
+	hang, down atomic.Bool
+}
+
+func (s *flakyStore) ListByUser(ctx context.Context, userID string, opts models.ListOptions) ([]models.Document, string, error) {
+	if s.hang.Load() {
+		<-ctx.Done()
+		return nil, "", ctx.Err()
+	}
+	if s.down.Load() {
+		return nil, "", errors.New("connection refused")
+	}
+	return s.ResearchStore.ListByUser(ctx, userID, opts)
+}
+
+// newFlakyEnv is a testEnv whose documents are kept in a flakyStore.
+func newFlakyEnv(t *testing.T, opts Options) (*testEnv, *flakyStore) {
+	t.Helper()
+	env := newTestEnv(t, nil)
+	store := &flakyStore{ResearchStore: env.docs}
+	env.h = NewHandler(store, env.files, env.ai.client(), env.latex.client(), opts)
+	return env, store
+}
+
+func TestListTimeout(t *testing.T) {
+	env, store := newFlakyEnv(t, Options{ListTimeout: 20 * time.Millisecond})
+	env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels"})
+	store.hang.Store(true)
+
+	start := time.Now()
+	w := serve(env.h.List, newRequest(t, http.MethodGet, "/research", "user-a", nil))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("list took %v with a 20ms timeout", elapsed)
+	}
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("got %d %s (Retry-After %q), want 503 with a retry hint", w.Code, w.Body, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestListCacheFallback(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	env, store := newFlakyEnv(t, Options{ListCache: NewListCache(rdb, time.Minute)})
+	env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels"})
+	list := func(target string) (int, listResponse, bool) {
+		t.Helper()
+		w := serve(env.h.List, newRequest(t, http.MethodGet, target, "user-a", nil))
+		var resp struct {
+			listResponse
+			Stale bool `json:"stale"`
+		}
+		if w.Code == http.StatusOK {
+			decode(t, w, &resp)
+		}
+		return w.Code, resp.listResponse, resp.Stale
+	}
+
+	if code, resp, stale := list("/research"); code != http.StatusOK || len(resp.Documents) != 1 || stale {
+		t.Fatalf("healthy list: got %d %+v stale=%v", code, resp, stale)
+	}
+
+	store.down.Store(true)
+	code, resp, stale := list("/research")
+	if code != http.StatusOK || !stale || len(resp.Documents) != 1 || resp.Documents[0].Topic != "solar panels" {
+		t.Errorf("database down: got %d %+v stale=%v, want the cached page flagged stale", code, resp, stale)
+	}
+	if code, _, _ := list("/research?tag=energy"); code != http.StatusServiceUnavailable {
+		t.Errorf("uncached query: got %d, want 503", code)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if code, _, _ := list("/research"); code != http.StatusServiceUnavailable {
+		t.Errorf("expired cache: got %d, want 503", code)
+	}
+}
+
+func TestNoListCache(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	if c := NewListCache(rdb, 0); c != nil {
+		t.Error("a zero TTL still caches")
+	}
+	var c *ListCache
+	c.Save(context.Background(), "user-a", models.ListOptions{}, nil, "")
+	if _, _, ok := c.Load(context.Background(), "user-a", models.ListOptions{}); ok {
+		t.Error("a nil cache returned a page")
+	}
+}
@@ -0,0 +1,123 @@
+package research
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// releaseScript deletes the lock only if it still holds our token, so an
+// expired lock re-acquired by someone else is never released by us.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewScript extends the lock only if it still holds our token.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// DocLocker serialises mutating operations on a document (regenerate, edit,
+// recompile, delete) with a Redis lock per document ID. Held locks are
+// renewed until released, however long the operation runs, so the TTL
+// only bounds how long a crashed holder can block others.
+type DocLocker struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// DefaultDocLockTTL is the lock TTL used when NewDocLocker is given one
+// Redis can't expire a key by, i.e. under a millisecond.
+const DefaultDocLockTTL = time.Minute
+
+func NewDocLocker(rdb *redis.Client, ttl time.Duration) *DocLocker {
+	if ttl < time.Millisecond {
+		ttl = DefaultDocLockTTL
+	}
+	return &DocLocker{rdb: rdb, ttl: ttl}
+}
+
+// TryLock acquires the lock for docID without waiting. It returns a release
+// func, or ok=false if another operation holds the lock.
+func (l *DocLocker) TryLock(ctx context.Context, docID string) (release func(), ok bool, err error) {
+	key := "doc_lock:" + docID
+	token := uuid.New().String()
+	ok, err = l.rdb.SetNX(ctx, key, token, l.ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go l.renew(key, token, stop, stopped)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-stopped
+			// Release even if the request context was cancelled.
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := releaseScript.Run(ctx, l.rdb, []string{key}, token).Err(); err != nil {
+				log.Printf("release lock %s: %v", docID, err)
+			}
+		})
+	}, true, nil
+}
+
+// renew extends the lock at key every third of the TTL until stop is
+// closed, so that a holder running longer than the TTL, like a regenerate
+// job, keeps it. It gives up if the lock turns out to be lost.
+func (l *DocLocker) renew(key, token string, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		held, err := renewScript.Run(ctx, l.rdb, []string{key}, token, l.ttl.Milliseconds()).Int()
+		cancel()
+		if err != nil {
+			log.Printf("renew lock %s: %v", key, err)
+			continue
+		}
+		if held == 0 {
+			log.Printf("lock %s expired before it was renewed", key)
+			return
+		}
+	}
+}
+
+// lockDoc takes the per-document lock for the duration of a handler,
+// writing a 409 if another operation is in progress. Callers must defer the
+// returned release. Without a locker configured it is a no-op.
+func (h *Handler) lockDoc(w http.ResponseWriter, r *http.Request, docID string) (func(), bool) {
+	if h.opts.Locks == nil {
+		return func() {}, true
+	}
+	release, ok, err := h.opts.Locks.TryLock(r.Context(), docID)
+	if err != nil {
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "lock service unavailable")
+		return nil, false
+	}
+	if !ok {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "operation in progress")
+		return nil, false
+	}
+	return release, true
+}
@@ -0,0 +1,160 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestDocLock(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	locks := NewDocLocker(rdb, time.Minute)
+	ctx := context.Background()
+
+	release, ok, err := locks.TryLock(ctx, "doc")
+	if err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	if _, ok, _ := locks.TryLock(ctx, "doc"); ok {
+		t.Fatal("second TryLock succeeded while the lock is held")
+	}
+	if other, ok, _ := locks.TryLock(ctx, "other-doc"); !ok {
+		t.Error("locking one document blocked another")
+	} else {
+		other()
+	}
+
+	release()
+	if mr.Exists("doc_lock:doc") {
+		t.Error("lock still set after release")
+	}
+
+	// A holder whose lock expired must not release the next holder's.
+	stale, _, _ := locks.TryLock(ctx, "doc")
+	mr.FastForward(2 * time.Minute)
+	current, ok, _ := locks.TryLock(ctx, "doc")
+	if !ok {
+		t.Fatal("TryLock after expiry failed")
+	}
+	stale()
+	if !mr.Exists("doc_lock:doc") {
+		t.Error("stale release dropped the current holder's lock")
+	}
+	current()
+}
+
+func TestDocLockTTLDefault(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	for _, ttl := range []time.Duration{0, -time.Second, 2 * time.Nanosecond} {
+		locks := NewDocLocker(rdb, ttl)
+		release, ok, err := locks.TryLock(context.Background(), "doc")
+		if err != nil || !ok {
+			t.Fatalf("ttl %v: TryLock = %v, %v", ttl, ok, err)
+		}
+		if got := mr.TTL("doc_lock:doc"); got != DefaultDocLockTTL {
+			t.Errorf("ttl %v: lock expires in %v, want %v", ttl, got, DefaultDocLockTTL)
+		}
+		release()
+	}
+}
+
+func TestDeleteWhileLocked(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	locks := NewDocLocker(rdb, time.Minute)
+	env := newTestEnv(t, &Options{Locks: locks})
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels"})
+
+	release, ok, err := locks.TryLock(context.Background(), id)
+	if err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	del := func() int {
+		return serve(env.h.Delete, newRequest(t, http.MethodDelete, "/research/"+id, "user-a", nil, "id", id)).Code
+	}
+	if got := del(); got != http.StatusConflict {
+		t.Errorf("delete while locked: got %d, want 409", got)
+	}
+	if _, err := env.docs.GetByID(context.Background(), id); err != nil {
+		t.Errorf("document deleted while locked: %v", err)
+	}
+
+	release()
+	if got := del(); got != http.StatusOK {
+		t.Errorf("delete after release: got %d, want 200", got)
+	}
+}
+
+func TestConcurrentRegenerates(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	env := newTestEnv(t, &Options{Locks: NewDocLocker(rdb, time.Minute)})
+	id := env.insert(t, &models.Document{
+		UserID:  "user-a",
+		Topic:   "solar panels",
+		Sources: env.ai.sources,
+	})
+	env.ai.block, env.ai.writing = make(chan struct{}), make(chan struct{})
+
+	regenerate := func() int {
+		w := serve(env.h.Regenerate, newRequest(t, http.MethodPost, "/research/"+id+"/regenerate", "user-a",
+			map[string]interface{}{"api_key": "sk-test", "overwrite": true}, "id", id))
+		return w.Code
+	}
+	codes := make([]int, 2)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = regenerate()
+		}()
+	}
+	wg.Wait()
+	slices.Sort(codes)
+	if want := []int{http.StatusAccepted, http.StatusConflict}; !slices.Equal(codes, want) {
+		t.Fatalf("got %v, want one 202 and one 409", codes)
+	}
+
+	close(env.ai.block)
+	env.wait(t)
+	if got := regenerate(); got != http.StatusAccepted {
+		t.Errorf("regenerate after the first finished: got %d, want 202", got)
+	}
+	env.wait(t)
+}
+
+func TestDocLockRenewedWhileHeld(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	const ttl = 300 * time.Millisecond
+	locks := NewDocLocker(rdb, ttl)
+	ctx := context.Background()
+
+	release, ok, err := locks.TryLock(ctx, "doc")
+	if err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	// Use up most of the TTL, then give the renewal a chance to run.
+	mr.FastForward(250 * time.Millisecond)
+	time.Sleep(ttl / 2)
+	if left := mr.TTL("doc_lock:doc"); left <= ttl/2 {
+		t.Errorf("lock TTL = %v after a renewal, want about %v", left, ttl)
+	}
+	if _, ok, _ := locks.TryLock(ctx, "doc"); ok {
+		t.Fatal("second TryLock succeeded while the lock is held")
+	}
+
+	release()
+	release() // releasing twice is harmless
+	if mr.Exists("doc_lock:doc") {
+		t.Error("lock still set after release")
+	}
+	if release, ok, err := locks.TryLock(ctx, "doc"); err != nil || !ok {
+		t.Errorf("TryLock after release = %v, %v", ok, err)
+	} else {
+		release()
+	}
+}
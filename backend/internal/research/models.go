@@ -0,0 +1,35 @@
+package research
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// DefaultModel is used when a create request doesn't name a model.
+const DefaultModel = "mistral-medium-latest"
+
+// DefaultAllowedModels are the models requests may pick when Options
+// doesn't configure an allowlist.
+var DefaultAllowedModels = []string{"mistral-medium-latest", "mistral-small-latest", "mistral-large-latest"}
+
+// modelAllowed reports whether requests may use model.
+func (h *Handler) modelAllowed(model string) bool {
+	return slices.Contains(h.opts.AllowedModels, model)
+}
+
+// writeModelNotAllowed rejects a request for a model outside the allowlist.
+func (h *Handler) writeModelNotAllowed(w http.ResponseWriter) {
+	apierror.WriteDetails(w, http.StatusBadRequest, apierror.InvalidRequest, "model not allowed", map[string]interface{}{
+		"models": h.opts.AllowedModels,
+	})
+}
+
+// Models lists the models requests may use, default first.
+func (h *Handler) Models(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"default": DefaultModel,
+		"models":  h.opts.AllowedModels,
+	})
+}
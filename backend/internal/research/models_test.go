@@ -0,0 +1,55 @@
+package research
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestModelAllowlist(t *testing.T) {
+	env := newTestEnv(t, &Options{AllowedModels: []string{"mistral-small-latest"}})
+
+	w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a",
+		models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Model: "gpt-4o"}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unlisted model: got %d, want 400", w.Code)
+	}
+	var rejected struct {
+		Error struct {
+			Code    apierror.Code `json:"code"`
+			Details struct {
+				Models []string `json:"models"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	decode(t, w, &rejected)
+	if rejected.Error.Code != apierror.InvalidRequest {
+		t.Errorf("code = %q, want %q", rejected.Error.Code, apierror.InvalidRequest)
+	}
+	want := []string{DefaultModel, "mistral-small-latest"}
+	if !slices.Equal(rejected.Error.Details.Models, want) {
+		t.Errorf("400 lists %v, want %v", rejected.Error.Details.Models, want)
+	}
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Model: "mistral-small-latest"})
+	if doc.ModelUsed != "mistral-small-latest" {
+		t.Errorf("model = %q, want mistral-small-latest", doc.ModelUsed)
+	}
+	doc = env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test"})
+	if doc.ModelUsed != DefaultModel {
+		t.Errorf("model = %q, want the default %q", doc.ModelUsed, DefaultModel)
+	}
+
+	w = serve(env.h.Models, newRequest(t, http.MethodGet, "/api/models", "", nil))
+	var listed struct {
+		Default string   `json:"default"`
+		Models  []string `json:"models"`
+	}
+	decode(t, w, &listed)
+	if listed.Default != DefaultModel || !slices.Equal(listed.Models, want) {
+		t.Errorf("GET /api/models = %+v, want default %q and %v", listed, DefaultModel, want)
+	}
+}
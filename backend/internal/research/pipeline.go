@@ -0,0 +1,486 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Pipeline steps reported by the status endpoint while a job is running.
+const (
+	StepGeneratingQueries = "generating_queries"
+	StepSearching         = "searching"
+	StepWritingReport     = "writing_report"
+	StepCompilingPDF      = "compiling_pdf"
+	StepCompilingTex      = "compiling_tex"
+	StepUploading         = "uploading"
+	StepSaving            = "saving"
+)
+
+// job is a single background research run for an already-inserted document.
+type job struct {
+	docID string
+	doc   *models.Document
+	req   models.CreateRequest
+	// reuseSources skips query generation and search, writing the report
+	// from the queries and sources already on doc.
+	reuseSources bool
+	// release, if set, is called when the job ends, e.g. to drop a
+	// document lock taken by the request that started it.
+	release func()
+	// timings measures each step as the job runs.
+	timings *stepTimer
+}
+
+// stepTimer accumulates how long each pipeline step took.
+type stepTimer struct {
+	step    string
+	started time.Time
+	elapsed map[string]int64
+}
+
+func newStepTimer() *stepTimer {
+	return &stepTimer{elapsed: make(map[string]int64)}
+}
+
+// start ends the current step, if any, and begins timing step.
+func (t *stepTimer) start(step string) {
+	t.stop()
+	t.step, t.started = step, time.Now()
+}
+
+// stop ends the current step and returns the durations so far in
+// milliseconds.
+func (t *stepTimer) stop() map[string]int64 {
+	if t.step != "" {
+		t.elapsed[t.step] += time.Since(t.started).Milliseconds()
+		t.step = ""
+	}
+	return t.elapsed
+}
+
+// startPipeline runs j in the background. The pipeline outlives the
+// request, so it must not use the request's context; its own context is
+// cancelled by Cancel.
+func (h *Handler) startPipeline(j job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	running := &runningJob{cancel: cancel}
+	j.timings = newStepTimer()
+	h.cancelsMu.Lock()
+	h.cancels[j.docID] = running
+	h.cancelsMu.Unlock()
+
+	h.jobs.Add(1)
+	h.started.Add(1)
+	go func() {
+		defer func() {
+			h.cancelsMu.Lock()
+			// A later run of the same document may have replaced us.
+			if h.cancels[j.docID] == running {
+				delete(h.cancels, j.docID)
+			}
+			h.cancelsMu.Unlock()
+			cancel()
+		}()
+		h.runPipeline(ctx, j)
+	}()
+}
+
+// runPipeline executes generate-queries → search → report → compile → upload
+// for a pending document and records the outcome on it.
+func (h *Handler) runPipeline(ctx context.Context, j job) {
+	defer h.jobs.Done()
+	defer h.finished.Add(1)
+	if j.release != nil {
+		defer j.release()
+	}
+	defer h.notifyCallback(ctx, j)
+
+	req, doc := j.req, j.doc
+
+	if model, substituted := h.opts.ModelHealth.Resolve(ctx, req.Model); substituted {
+		doc.RequestedModel = req.Model
+		doc.ModelUsed = model
+		req.Model = model
+	}
+
+	capture := &DebugCapture{DocID: j.docID, UserID: doc.UserID, Request: req}
+	if h.opts.Debug.Active(ctx, doc.UserID) {
+		defer h.saveCapture(ctx, capture)
+	}
+
+	queries, sources := doc.SearchQueries, doc.Sources
+	if !j.reuseSources {
+		var serr *stepError
+		queries, sources, serr = h.collectSources(ctx, req, capture, func(step string) {
+			h.setStep(ctx, j, step)
+		})
+		if serr != nil {
+			h.fail(ctx, j, serr.step, serr.msg)
+			return
+		}
+	}
+	if sources == nil {
+		sources = []models.Source{}
+	}
+
+	ctxStr := sourceContext(sources)
+
+	// Step 3: generate report
+	h.setStep(ctx, j, StepWritingReport)
+	provider, ok := h.provider(req.Provider)
+	if !ok {
+		h.fail(ctx, j, StepWritingReport, fmt.Sprintf("Unknown provider %q.", req.Provider))
+		return
+	}
+	stepCtx, cancel := h.stepContext(ctx, StepWritingReport)
+	latexBody, err := provider.GenerateReport(stepCtx, req.APIKey, req.Model, req.Topic, ctxStr, sources, reportOptions(req))
+	cancel()
+	h.opts.ModelHealth.Observe(ctx, req.Model, err)
+	capture.LatexBody = latexBody
+	if stepTimedOut(ctx, err) {
+		h.fail(ctx, j, StepWritingReport, h.timeoutMsg(StepWritingReport))
+		return
+	}
+	if errors.Is(err, ErrAIBusy) {
+		h.fail(ctx, j, StepWritingReport, msgAIBusy)
+		return
+	}
+	if err != nil {
+		log.Printf("generate-report error: %v", err)
+		h.fail(ctx, j, StepWritingReport, fmt.Sprintf("Report generation failed: %v", err))
+		return
+	}
+	if latexBody == "" {
+		log.Printf("generate-report returned empty body")
+		h.fail(ctx, j, StepWritingReport, "AI service returned an empty report. Try again or use a different model.")
+		return
+	}
+	// Check the report as written: the appended bibliography would
+	// otherwise pass a body with no text of its own.
+	if err := validateLatexBody(latexBody, h.opts.MaxLatexBytes); err != nil {
+		log.Printf("generate-report returned an unusable body: %v", err)
+		h.fail(ctx, j, StepWritingReport, fmt.Sprintf("AI service returned an unusable report: %v.", err))
+		return
+	}
+	latexBody = ensureBibliography(latexBody, req.CitationStyle, sources)
+
+	// Step 4: compile PDF (via latex-service)
+	h.setStep(ctx, j, StepCompilingPDF)
+	var compileErrs []string
+	stepCtx, cancel = h.stepContext(ctx, StepCompilingPDF)
+	pdfBytes, err := h.latexClient.CompilePDF(stepCtx, latexBody, req.Topic, PDFOptions{Watermark: req.Watermark})
+	cancel()
+	if err != nil {
+		log.Printf("compile-pdf error (non-fatal): %v", err)
+		if stepTimedOut(ctx, err) {
+			compileErrs = append(compileErrs, "pdf: "+h.timeoutMsg(StepCompilingPDF))
+		} else {
+			compileErrs = append(compileErrs, "pdf: "+compileOutput(err))
+		}
+	}
+
+	// Step 5: compile .tex (via latex-service)
+	h.setStep(ctx, j, StepCompilingTex)
+	stepCtx, cancel = h.stepContext(ctx, StepCompilingTex)
+	texSource, err := h.latexClient.CompileTex(stepCtx, latexBody, req.Topic)
+	cancel()
+	if err != nil {
+		log.Printf("compile-tex error (non-fatal): %v", err)
+		if stepTimedOut(ctx, err) {
+			compileErrs = append(compileErrs, "tex: "+h.timeoutMsg(StepCompilingTex))
+		} else {
+			compileErrs = append(compileErrs, "tex: "+compileOutput(err))
+		}
+	}
+
+	// Step 6: upload to MinIO
+	h.setStep(ctx, j, StepUploading)
+	pdfKey, texKey := artifactKeys(doc, j.docID)
+
+	var uploads []artifact
+	if pdfBytes != nil {
+		uploads = append(uploads, artifact{key: &pdfKey, data: pdfBytes, contentType: "application/pdf"})
+	} else {
+		pdfKey = ""
+	}
+	if texSource != "" {
+		uploads = append(uploads, artifact{key: &texKey, data: []byte(texSource), contentType: "application/x-tex"})
+	} else {
+		texKey = ""
+	}
+	h.uploadArtifacts(ctx, uploads)
+
+	// Step 7: save to MongoDB
+	h.setStep(ctx, j, StepSaving)
+	doc.LatexContent = latexBody
+	doc.Sources = sources
+	doc.SearchQueries = queries
+	doc.PDFObjectKey = pdfKey
+	doc.TexObjectKey = texKey
+	doc.PDFSize, doc.TexSize = storedSize(pdfKey, pdfBytes), storedSize(texKey, []byte(texSource))
+	doc.Status = models.StatusComplete
+	if pdfKey == "" && texKey == "" && len(compileErrs) > 0 {
+		// Nothing the user can download came out of this run.
+		doc.Status = models.StatusDegraded
+	}
+	doc.CompileError = strings.Join(compileErrs, "\n")
+	doc.Step = ""
+	doc.StepTimings = j.timings.stop()
+	// Only the fields the run owns are written, so tags set or a trash
+	// move made while it ran are kept.
+	if err := h.mongo.SaveResult(ctx, j.docID, doc); err != nil {
+		log.Printf("mongo update error: %v", err)
+		h.fail(ctx, j, StepSaving, "failed to save research")
+		return
+	}
+	h.opts.Progress.Publish(ctx, j.docID, ProgressEvent{Status: doc.Status, Percent: 100, Error: doc.CompileError})
+}
+
+// storedSize is the size of data if it was stored under key, else zero.
+func storedSize(key string, data []byte) int64 {
+	if key == "" {
+		return 0
+	}
+	return int64(len(data))
+}
+
+// maxCompileErrorLen caps the compiler output kept per file.
+const maxCompileErrorLen = 4000
+
+// compileOutput extracts what the LaTeX service said about a failed
+// compile: the response body if it answered, else the error itself.
+func compileOutput(err error) string {
+	msg := err.Error()
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Body != "" {
+		msg = statusErr.Body
+	}
+	if len(msg) > maxCompileErrorLen {
+		msg = strings.ToValidUTF8(msg[:maxCompileErrorLen], "")
+	}
+	return msg
+}
+
+const msgNoCredibleSources = "No sources met the minimum credibility threshold."
+
+// artifactKeys returns the object keys of a document's PDF and .tex files.
+// They are named after the document ID, so no two documents share one.
+// Documents stored before that keep their topic-based keys until they are
+// next written; those old objects may be shared by documents with similar
+// topics and are left in place.
+func artifactKeys(doc *models.Document, docID string) (pdfKey, texKey string) {
+	return fmt.Sprintf("%s/%s.pdf", doc.UserID, docID), fmt.Sprintf("%s/%s.tex", doc.UserID, docID)
+}
+
+// stepError is a pipeline failure with the step it happened in and the
+// message shown to the user.
+type stepError struct {
+	step string
+	msg  string
+}
+
+// write responds with e, for the handlers that run pipeline steps inline.
+// Unusable search results are the request's fault and get a 422; anything
+// else is an upstream failure.
+func (e *stepError) write(w http.ResponseWriter) {
+	status, code := http.StatusBadGateway, apierror.UpstreamFailure
+	switch e.msg {
+	case msgNoCredibleSources, msgNoSourcesAfterDomainFilter, msgNoUsableSources:
+		status, code = http.StatusUnprocessableEntity, apierror.Unprocessable
+	case msgAIBusy:
+		status, code = http.StatusServiceUnavailable, apierror.Unavailable
+		w.Header().Set("Retry-After", "30")
+	}
+	apierror.WriteDetails(w, status, code, e.msg, map[string]interface{}{"step": e.step})
+}
+
+// collectSources generates queries and runs the search for req, then
+// dedupes and scores the results — everything the report is grounded on.
+// onStep is called as each step starts. With SkipSearch it returns no
+// queries and an empty source list.
+func (h *Handler) collectSources(ctx context.Context, req models.CreateRequest, capture *DebugCapture, onStep func(step string)) ([]string, []models.Source, *stepError) {
+	sources := []models.Source{}
+	if req.SkipSearch {
+		return nil, sources, nil
+	}
+	maxQueries, resultsPerQuery := depthCounts(req)
+	provider, ok := h.provider(req.Provider)
+	if !ok {
+		return nil, nil, &stepError{StepGeneratingQueries, fmt.Sprintf("Unknown provider %q.", req.Provider)}
+	}
+
+	cache := h.opts.Cache
+	if req.NoCache {
+		cache = nil
+	}
+
+	// Step 1: generate search queries
+	onStep(StepGeneratingQueries)
+	var err error
+	queries, cached := cache.Queries(ctx, req.Provider, req.Model, req.Topic)
+	if !cached {
+		stepCtx, cancel := h.stepContext(ctx, StepGeneratingQueries)
+		queries, err = provider.GenerateQueries(stepCtx, req.APIKey, req.Model, req.Topic, generationParams(req))
+		cancel()
+		h.opts.ModelHealth.Observe(ctx, req.Model, err)
+		if stepTimedOut(ctx, err) {
+			return nil, nil, &stepError{StepGeneratingQueries, h.timeoutMsg(StepGeneratingQueries)}
+		}
+		if err != nil {
+			log.Printf("generate-queries error: %v", err)
+			return nil, nil, &stepError{StepGeneratingQueries, fmt.Sprintf("Failed to generate search queries: %v", err)}
+		}
+		cache.SaveQueries(ctx, req.Provider, req.Model, req.Topic, queries)
+	}
+	capture.RawQueries = queries
+	queries = DedupeQueries(queries)
+	if len(queries) > maxQueries {
+		queries = queries[:maxQueries]
+	}
+
+	// Step 2: web search
+	onStep(StepSearching)
+	sources, serr := h.searchSources(ctx, req, provider, cache, queries, resultsPerQuery, capture)
+	if serr != nil {
+		return nil, nil, serr
+	}
+	return queries, sources, nil
+}
+
+// searchSources searches the web for queries and filters, deduplicates,
+// scores and ranks the results according to req.
+func (h *Handler) searchSources(ctx context.Context, req models.CreateRequest, provider Provider, cache *QueryCache,
+	queries []string, resultsPerQuery int, capture *DebugCapture) ([]models.Source, *stepError) {
+	var sources []models.Source
+	var err error
+	searcher := cache.Searcher(req.Provider, provider)
+	searchCtx, cancel := h.stepContext(ctx, StepSearching)
+	if h.opts.SearchConcurrency > 0 {
+		sources, err = searchConcurrent(searchCtx, searcher, queries, resultsPerQuery, h.opts.SearchConcurrency, h.opts.MaxSources)
+	} else {
+		sources, err = searcher.Search(searchCtx, queries, resultsPerQuery)
+	}
+	cancel()
+	if stepTimedOut(ctx, err) {
+		return nil, &stepError{StepSearching, h.timeoutMsg(StepSearching)}
+	}
+	if errors.Is(err, ErrAIBusy) {
+		return nil, &stepError{StepSearching, msgAIBusy}
+	}
+	if err != nil {
+		log.Printf("search error: %v", err)
+		return nil, &stepError{StepSearching, fmt.Sprintf("Web search failed: %v", err)}
+	}
+	assignSourceIDs(sources)
+	capture.Queries = queries
+	capture.RawSources = append([]models.Source(nil), sources...)
+
+	allowed, blocked := req.AllowedDomains, req.BlockedDomains
+	if len(allowed) == 0 {
+		allowed = h.opts.AllowedDomains
+	}
+	blocked = append(blocked[:len(blocked):len(blocked)], h.opts.BlockedDomains...)
+	sources = FilterSourcesByDomain(sources, allowed, blocked)
+	if len(sources) == 0 && (len(allowed) > 0 || len(blocked) > 0) {
+		return nil, &stepError{StepSearching, msgNoSourcesAfterDomainFilter}
+	}
+	sources = dedupeSources(sources, req.Dedup, h.opts.DedupThreshold)
+	sources = scoreSources(sources, h.opts.CredibleDomains, req.MinCredibility)
+	if len(sources) == 0 {
+		return nil, &stepError{StepSearching, msgNoCredibleSources}
+	}
+	sources = RankSources(sources, req.Topic, h.sourceLimit(req.Depth))
+	if len(sources) == 0 {
+		return nil, &stepError{StepSearching, msgNoUsableSources}
+	}
+	return sources, nil
+}
+
+// sourceContext lists sources for the report prompt, one per line, with
+// whatever publication details are known.
+func sourceContext(sources []models.Source) string {
+	var b strings.Builder
+	for _, s := range sources {
+		var details []string
+		if s.Author != "" {
+			details = append(details, "Author: "+s.Author)
+		}
+		if s.PublishedDate != "" {
+			details = append(details, "Published: "+s.PublishedDate)
+		}
+		if s.SiteName != "" {
+			details = append(details, "Site: "+s.SiteName)
+		}
+		details = append(details, "Source: "+s.Href)
+		fmt.Fprintf(&b, "- %s: %s (%s)\n", s.Title, s.Body, strings.Join(details, "; "))
+	}
+	return b.String()
+}
+
+// reportOptions extracts the generation parameters of a create request.
+func reportOptions(req models.CreateRequest) ReportOptions {
+	opts := ReportOptions{
+		TargetWords:    req.TargetWords,
+		TargetSections: req.TargetSections,
+		CitationStyle:  req.CitationStyle,
+		Language:       req.Language,
+	}
+	opts.GenerationParams = generationParams(req)
+	return opts
+}
+
+// generationParams extracts the sampling parameters of a create request.
+func generationParams(req models.CreateRequest) GenerationParams {
+	return GenerationParams{Temperature: req.Temperature, MaxTokens: req.MaxTokens}
+}
+
+// setStep marks the job as running the given step.
+func (h *Handler) setStep(ctx context.Context, j job, step string) {
+	j.timings.start(step)
+	docID := j.docID
+	if err := h.mongo.SetStatus(ctx, docID, models.StatusRunning, step, ""); err != nil {
+		log.Printf("set status %s/%s error: %v", docID, step, err)
+	}
+	h.opts.Progress.Publish(ctx, docID, ProgressEvent{
+		Status: models.StatusRunning, Step: step, Percent: stepPercent[step],
+	})
+}
+
+// fail marks the job as failed at the given step, keeping the timings of
+// the steps it got through, the failed one included.
+func (h *Handler) fail(ctx context.Context, j job, step, msg string) {
+	h.failed.Add(1)
+	docID := j.docID
+	if err := h.mongo.SetStatus(ctx, docID, models.StatusFailed, step, msg); err != nil {
+		log.Printf("set status %s/failed error: %v", docID, err)
+	}
+	if err := h.mongo.SetStepTimings(ctx, docID, j.timings.stop()); err != nil {
+		log.Printf("set step timings %s error: %v", docID, err)
+	}
+	h.opts.Progress.Publish(ctx, docID, ProgressEvent{
+		Status: models.StatusFailed, Step: step, Percent: stepPercent[step], Error: msg,
+	})
+}
+
+// Wait blocks until all background pipelines have finished or ctx is done.
+func (h *Handler) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
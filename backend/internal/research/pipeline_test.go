@@ -0,0 +1,402 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// create posts a create request as userID, waits for its pipeline to
+// finish and returns the stored document.
+func (e *testEnv) create(t *testing.T, userID string, req models.CreateRequest) *models.Document {
+	t.Helper()
+	w := serve(e.h.Create, newRequest(t, http.MethodPost, "/research", userID, req))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("create: got %d %s, want 202", w.Code, w.Body)
+	}
+	var accepted models.Document
+	decode(t, w, &accepted)
+	e.wait(t)
+	doc, err := e.docs.GetByID(context.Background(), accepted.ID.Hex())
+	if err != nil {
+		t.Fatalf("get %s: %v", accepted.ID.Hex(), err)
+	}
+	return doc
+}
+
+// wait blocks until the handler's background pipelines have finished.
+func (e *testEnv) wait(t *testing.T) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.h.Wait(ctx); err != nil {
+		t.Fatalf("wait for pipelines: %v", err)
+	}
+}
+
+func TestCreateSearches(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+
+	for _, path := range []string{"/api/generate-queries", "/api/search", "/api/generate-report"} {
+		if n := env.ai.called(path); n != 1 {
+			t.Errorf("%s called %d times, want 1", path, n)
+		}
+	}
+	if doc.SearchSkipped {
+		t.Error("search_skipped set on a searched report")
+	}
+	if len(doc.Sources) != 2 || len(doc.SearchQueries) != 2 {
+		t.Errorf("got %d sources and %d queries, want 2 of each", len(doc.Sources), len(doc.SearchQueries))
+	}
+}
+
+func TestCreateSkipSearch(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", SkipSearch: true})
+
+	for _, path := range []string{"/api/generate-queries", "/api/search"} {
+		if n := env.ai.called(path); n != 0 {
+			t.Errorf("%s called %d times with skip_search", path, n)
+		}
+	}
+	if n := env.ai.called("/api/generate-report"); n != 1 {
+		t.Errorf("generate-report called %d times, want 1", n)
+	}
+	if !doc.SearchSkipped {
+		t.Error("search_skipped not recorded")
+	}
+	if len(doc.Sources) != 0 || len(doc.SearchQueries) != 0 {
+		t.Errorf("got %d sources and %d queries, want none", len(doc.Sources), len(doc.SearchQueries))
+	}
+	if doc.LatexContent != env.ai.report {
+		t.Errorf("latex content = %q, want the generated report", doc.LatexContent)
+	}
+}
+
+func TestCreateRecordsStatus(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	if doc.Status != models.StatusComplete || doc.Error != "" {
+		t.Errorf("status = %q (error %q), want complete", doc.Status, doc.Error)
+	}
+
+	env.ai.fail["/api/generate-report"] = true
+	doc = env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test"})
+	if doc.Status != models.StatusFailed || doc.Step != StepWritingReport || doc.Error == "" {
+		t.Errorf("got status %q step %q error %q, want failed while writing", doc.Status, doc.Step, doc.Error)
+	}
+	if got, want := env.h.Stats(), (JobStats{Started: 2, Finished: 2, Failed: 1}); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestCreateCompileFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		fail       []string
+		wantStatus string
+		wantPDF    bool
+		wantTex    bool
+	}{
+		{"pdf fails", []string{"/api/compile-pdf"}, models.StatusComplete, false, true},
+		{"both fail", []string{"/api/compile-pdf", "/api/compile-tex"}, models.StatusDegraded, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			for _, path := range tt.fail {
+				env.latex.fail[path] = true
+			}
+			doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+
+			if doc.Status != tt.wantStatus {
+				t.Fatalf("status = %q (%s), want %q", doc.Status, doc.Error, tt.wantStatus)
+			}
+			if !strings.Contains(doc.CompileError, "pdf: ! Undefined control sequence.") {
+				t.Errorf("compile error = %q, want the compiler output", doc.CompileError)
+			}
+			if doc.LatexContent == "" {
+				t.Error("report not kept after a compile failure")
+			}
+			if got := doc.PDFObjectKey != ""; got != tt.wantPDF {
+				t.Errorf("has pdf = %v, want %v", got, tt.wantPDF)
+			}
+			if got := doc.TexObjectKey != ""; got != tt.wantTex {
+				t.Errorf("has tex = %v, want %v", got, tt.wantTex)
+			}
+
+			id := doc.ID.Hex()
+			w := serve(env.h.Status, newRequest(t, http.MethodGet, "/research/"+id+"/status", "user-a", nil, "id", id))
+			var status map[string]string
+			decode(t, w, &status)
+			if status["status"] != tt.wantStatus || status["compile_error"] != doc.CompileError {
+				t.Errorf("status endpoint = %v, want status %q with the compile error", status, tt.wantStatus)
+			}
+			w = serve(env.h.Get, newRequest(t, http.MethodGet, "/research/"+id, "user-a", nil, "id", id))
+			var got models.Document
+			decode(t, w, &got)
+			if got.CompileError != doc.CompileError {
+				t.Errorf("get: compile error = %q, want %q", got.CompileError, doc.CompileError)
+			}
+		})
+	}
+}
+
+func TestCreateWithInProcessServices(t *testing.T) {
+	stub := func() *stubAI {
+		return &stubAI{
+			queries: []string{"solar panels efficiency"},
+			sources: []models.Source{{Title: "Solar panel efficiency", Body: "How efficient modern solar panels are at turning sunlight into power.", Href: "https://example.edu/solar"}},
+			report:  "\\section{Introduction}\nSolar panels turn light into power.\n",
+			errs:    map[string]error{},
+		}
+	}
+	tests := []struct {
+		name       string
+		prepare    func(*stubAI, *stubLaTeX)
+		wantStatus string
+		wantStep   string // where a failed pipeline stopped
+		wantError  string
+		wantFiles  int
+	}{
+		{"happy path", func(*stubAI, *stubLaTeX) {}, models.StatusComplete, "", "", 2},
+		{"search fails", func(ai *stubAI, _ *stubLaTeX) { ai.errs["Search"] = errors.New("search engine down") },
+			models.StatusFailed, StepSearching, "Web search failed: search engine down", 0},
+		{"empty report", func(ai *stubAI, _ *stubLaTeX) { ai.report = "" },
+			models.StatusFailed, StepWritingReport, "AI service returned an empty report", 0},
+		{"compile fails", func(_ *stubAI, l *stubLaTeX) {
+			l.errs["CompilePDF"] = &StatusError{Code: 500, Body: "! Emergency stop."}
+			l.errs["CompileTex"] = &StatusError{Code: 500, Body: "! Emergency stop."}
+		}, models.StatusDegraded, "", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ai, latex := stub(), &stubLaTeX{errs: map[string]error{}}
+			tt.prepare(ai, latex)
+			env := newStubEnv(t, ai, latex)
+			doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+
+			if doc.Status != tt.wantStatus {
+				t.Fatalf("status = %s (%s), want %s", doc.Status, doc.Error, tt.wantStatus)
+			}
+			if tt.wantStep != "" && doc.Step != tt.wantStep {
+				t.Errorf("failed at %q, want %q", doc.Step, tt.wantStep)
+			}
+			if !strings.HasPrefix(doc.Error, tt.wantError) || (tt.wantError == "") != (doc.Error == "") {
+				t.Errorf("error = %q, want %q", doc.Error, tt.wantError)
+			}
+			if n := len(env.files.Keys()); n != tt.wantFiles {
+				t.Errorf("stored %d files, want %d", n, tt.wantFiles)
+			}
+			if tt.wantStatus == models.StatusComplete && (len(doc.Sources) != 1 || !strings.Contains(doc.LatexContent, "Solar panels turn light")) {
+				t.Errorf("sources %v, report %q", doc.Sources, doc.LatexContent)
+			}
+		})
+	}
+}
+
+// timedSteps returns the steps doc has timings for, sorted.
+func timedSteps(doc *models.Document) []string {
+	var steps []string
+	for step := range doc.StepTimings {
+		steps = append(steps, step)
+	}
+	slices.Sort(steps)
+	return steps
+}
+
+func TestCreateRecordsStepTimings(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	want := []string{StepCompilingPDF, StepCompilingTex, StepGeneratingQueries, StepSaving, StepSearching, StepUploading, StepWritingReport}
+	if got := timedSteps(doc); !slices.Equal(got, want) {
+		t.Errorf("timed steps %v, want %v", got, want)
+	}
+
+	env.ai.fail["/api/generate-report"] = true
+	doc = env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test"})
+	want = []string{StepGeneratingQueries, StepSearching, StepWritingReport}
+	if got := timedSteps(doc); !slices.Equal(got, want) {
+		t.Errorf("failed run timed steps %v, want %v", got, want)
+	}
+	for step, ms := range doc.StepTimings {
+		if ms < 0 {
+			t.Errorf("%s took %dms", step, ms)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "t", Status: models.StatusRunning, Step: StepSearching})
+	legacy := env.insert(t, &models.Document{UserID: "user-a", Topic: "old"})
+
+	tests := []struct {
+		name, id, user string
+		code           int
+		status, step   string
+	}{
+		{"running", id, "user-a", http.StatusOK, models.StatusRunning, StepSearching},
+		{"legacy document", legacy, "user-a", http.StatusOK, models.StatusComplete, ""},
+		{"other user", id, "user-b", http.StatusForbidden, "", ""},
+		{"missing", "000000000000000000000000", "user-a", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(env.h.Status, newRequest(t, http.MethodGet, "/research/"+tt.id+"/status", tt.user, nil, "id", tt.id))
+			if w.Code != tt.code {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var got map[string]string
+			decode(t, w, &got)
+			if got["status"] != tt.status || got["step"] != tt.step {
+				t.Errorf("got status %q step %q, want %q %q", got["status"], got["step"], tt.status, tt.step)
+			}
+		})
+	}
+}
+
+// storedKeys is an APIKeyLookup over a fixed map of user IDs to keys.
+type storedKeys map[string]string
+
+func (k storedKeys) Get(ctx context.Context, userID string) (string, error) {
+	return k[userID], nil
+}
+
+func TestCreateUsesStoredAPIKey(t *testing.T) {
+	env := newTestEnv(t, &Options{APIKeys: storedKeys{"user-a": "sk-stored"}})
+
+	env.create(t, "user-a", models.CreateRequest{Topic: "solar panels"})
+	env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-request"})
+	var keys []interface{}
+	for _, body := range env.ai.bodies["/api/generate-report"] {
+		keys = append(keys, body["api_key"])
+	}
+	if len(keys) != 2 || keys[0] != "sk-stored" || keys[1] != "sk-request" {
+		t.Errorf("reports generated with keys %v, want the stored key then the request's", keys)
+	}
+
+	w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-b", models.CreateRequest{Topic: "solar panels"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("no stored key: got %d, want 400", w.Code)
+	}
+}
+
+func TestCreateForwardsGenerationParams(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Temperature: ptr(0.0), MaxTokens: 4000})
+	env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test"})
+
+	for _, path := range []string{"/api/generate-queries", "/api/generate-report"} {
+		bodies := env.ai.bodies[path]
+		if len(bodies) != 2 {
+			t.Fatalf("%s called %d times, want 2", path, len(bodies))
+		}
+		if bodies[0]["temperature"] != 0.0 || bodies[0]["max_tokens"] != 4000.0 {
+			t.Errorf("%s body = %v, want temperature 0 and max_tokens 4000", path, bodies[0])
+		}
+		for _, key := range []string{"temperature", "max_tokens"} {
+			if v, ok := bodies[1][key]; ok {
+				t.Errorf("%s sent %s = %v without it being set", path, key, v)
+			}
+		}
+	}
+	if doc.Temperature == nil || *doc.Temperature != 0 || doc.MaxTokens != 4000 {
+		t.Errorf("document recorded temperature %v and max_tokens %d", doc.Temperature, doc.MaxTokens)
+	}
+
+	for name, req := range map[string]models.CreateRequest{
+		"negative temperature": {Topic: "t", APIKey: "sk-test", Temperature: ptr(-0.1)},
+		"high temperature":     {Topic: "t", APIKey: "sk-test", Temperature: ptr(2.5)},
+		"negative max tokens":  {Topic: "t", APIKey: "sk-test", MaxTokens: -1},
+		"high max tokens":      {Topic: "t", APIKey: "sk-test", MaxTokens: maxMaxTokens + 1},
+	} {
+		w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a", req))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", name, w.Code)
+		}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCreateDepthOverrides(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Depth: "Deep", MaxQueries: 1, ResultsPerQuery: 9})
+	if len(doc.SearchQueries) != 1 {
+		t.Errorf("searched %d queries, want 1", len(doc.SearchQueries))
+	}
+	bodies := env.ai.bodies["/api/search"]
+	if len(bodies) != 1 || bodies[0]["results_per_query"] != 9.0 {
+		t.Errorf("search bodies = %v, want one asking for 9 results", bodies)
+	}
+
+	for name, req := range map[string]models.CreateRequest{
+		"negative queries": {Topic: "t", APIKey: "sk-test", MaxQueries: -1},
+		"too many queries": {Topic: "t", APIKey: "sk-test", MaxQueries: maxQueriesLimit + 1},
+		"negative results": {Topic: "t", APIKey: "sk-test", ResultsPerQuery: -1},
+		"too many results": {Topic: "t", APIKey: "sk-test", ResultsPerQuery: resultsPerQueryLimit + 1},
+	} {
+		w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a", req))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", name, w.Code)
+		}
+	}
+}
+
+func TestSourceContext(t *testing.T) {
+	got := sourceContext([]models.Source{
+		{Title: "Plain", Body: "About solar.", Href: "https://example.org/plain"},
+		{Title: "Dated", Body: "About wind.", Href: "https://example.org/dated", Author: "Ada Lovelace", PublishedDate: "2021-03-03", SiteName: "Example"},
+	})
+	want := "- Plain: About solar. (Source: https://example.org/plain)\n" +
+		"- Dated: About wind. (Author: Ada Lovelace; Published: 2021-03-03; Site: Example; Source: https://example.org/dated)\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStepErrorStatus(t *testing.T) {
+	tests := []struct {
+		msg        string
+		wantStatus int
+		wantCode   apierror.Code
+	}{
+		{msgNoCredibleSources, http.StatusUnprocessableEntity, apierror.Unprocessable},
+		{msgNoSourcesAfterDomainFilter, http.StatusUnprocessableEntity, apierror.Unprocessable},
+		{msgNoUsableSources, http.StatusUnprocessableEntity, apierror.Unprocessable},
+		{msgAIBusy, http.StatusServiceUnavailable, apierror.Unavailable},
+		{"Web search failed: timeout", http.StatusBadGateway, apierror.UpstreamFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&stepError{StepSearching, tt.msg}).write(w)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body apierror.Body
+			decode(t, w, &body)
+			if body.Error.Code != tt.wantCode || body.Error.Message != tt.msg || body.Error.Details["step"] != StepSearching {
+				t.Errorf("body = %+v", body.Error)
+			}
+			if busy := tt.msg == msgAIBusy; busy != (w.Header().Get("Retry-After") != "") {
+				t.Errorf("Retry-After = %q", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
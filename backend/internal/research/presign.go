@@ -0,0 +1,48 @@
+package research
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// DefaultPresignExpiry is how long a presigned download URL stays valid
+// when Options doesn't say.
+const DefaultPresignExpiry = 15 * time.Minute
+
+// Presigner is implemented by file stores that can hand out direct,
+// time-limited download URLs, so large files skip the backend.
+type Presigner interface {
+	PresignedGetURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// PresignedPDFURL returns a presigned URL for the document's PDF. The URL
+// itself carries no credentials, so the ownership check here is the only
+// thing guarding it; keep the expiry short.
+func (h *Handler) PresignedPDFURL(w http.ResponseWriter, r *http.Request) {
+	presigner, ok := h.minio.(Presigner)
+	if !ok {
+		apierror.Write(w, http.StatusNotImplemented, apierror.NotImplemented, "direct downloads not available")
+		return
+	}
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	if doc.PDFObjectKey == "" {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "pdf not available")
+		return
+	}
+
+	expires := time.Now().Add(h.opts.PresignExpiry).UTC()
+	url, err := presigner.PresignedGetURL(r.Context(), doc.PDFObjectKey, h.opts.PresignExpiry)
+	if err != nil {
+		log.Printf("presign %s: %v", doc.PDFObjectKey, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to create download url")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"url": url, "expires_at": expires})
+}
@@ -0,0 +1,75 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+	"github.com/ayush/research-ai-agent/backend/internal/storetest"
+)
+
+// presigningFiles is a storetest.FileStore that can presign downloads.
+type presigningFiles struct {
+	*storetest.FileStore
+	expiries []time.Duration
+}
+
+func (p *presigningFiles) PresignedGetURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	p.expiries = append(p.expiries, expiry)
+	return "https://files.example.com/" + key + "?X-Amz-Signature=sig", nil
+}
+
+func TestPresignedPDFURL(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	pending := env.insert(t, &models.Document{UserID: "user-a", Topic: "t", Status: models.StatusRunning})
+	id := doc.ID.Hex()
+	get := func(userID, id string) (int, map[string]string) {
+		t.Helper()
+		w := serve(env.h.PresignedPDFURL, newRequest(t, http.MethodGet, "/research/"+id+"/pdf/url", userID, nil, "id", id))
+		var body map[string]string
+		if w.Code == http.StatusOK {
+			decode(t, w, &body)
+		}
+		return w.Code, body
+	}
+
+	if code, _ := get("user-a", id); code != http.StatusNotImplemented {
+		t.Errorf("store without presigning: got %d, want 501", code)
+	}
+
+	files := &presigningFiles{FileStore: env.files}
+	env.h.minio = files
+	code, body := get("user-a", id)
+	if code != http.StatusOK {
+		t.Fatalf("got %d, want 200", code)
+	}
+	if want := "https://files.example.com/" + doc.PDFObjectKey + "?X-Amz-Signature=sig"; body["url"] != want {
+		t.Errorf("url = %q, want %q", body["url"], want)
+	}
+	expires, err := time.Parse(time.RFC3339, body["expires_at"])
+	if err != nil || time.Until(expires) > DefaultPresignExpiry || time.Until(expires) < DefaultPresignExpiry-time.Minute {
+		t.Errorf("expires_at = %q, want about %v from now", body["expires_at"], DefaultPresignExpiry)
+	}
+	if len(files.expiries) != 1 || files.expiries[0] != DefaultPresignExpiry {
+		t.Errorf("presigned with expiries %v, want [%v]", files.expiries, DefaultPresignExpiry)
+	}
+
+	for name, tt := range map[string]struct {
+		user, id string
+		code     int
+	}{
+		"no user":    {"", id, http.StatusUnauthorized},
+		"other user": {"user-b", id, http.StatusForbidden},
+		"no pdf yet": {"user-a", pending, http.StatusNotFound},
+	} {
+		if code, _ := get(tt.user, tt.id); code != tt.code {
+			t.Errorf("%s: got %d, want %d", name, code, tt.code)
+		}
+	}
+	if len(files.expiries) != 1 {
+		t.Errorf("presigned %d URLs, want only the owner's", len(files.expiries))
+	}
+}
@@ -0,0 +1,44 @@
+package research
+
+import (
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Preview runs query generation and search for a create request without
+// writing a report, returning the queries and the deduped, scored sources
+// exactly as the create path would send them to the model.
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeCreateRequest(w, r, userID)
+	if !ok {
+		return
+	}
+	if req.SkipSearch {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "nothing to preview when skip_search is set")
+		return
+	}
+	// Previews spend AI calls too, so they stop once creating would.
+	if !h.quotaLeft(w, r, userID) {
+		return
+	}
+	if model, substituted := h.opts.ModelHealth.Resolve(r.Context(), req.Model); substituted {
+		req.Model = model
+	}
+
+	queries, sources, serr := h.collectSources(r.Context(), req, &DebugCapture{}, func(string) {})
+	if serr != nil {
+		serr.write(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Queries []string        `json:"queries"`
+		Sources []models.Source `json:"sources"`
+	}{queries, sources})
+}
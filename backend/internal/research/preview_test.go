@@ -0,0 +1,62 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestPreviewMatchesCreate(t *testing.T) {
+	env := newTestEnv(t, nil)
+	// A duplicate and a short snippet, so post-processing has something to drop.
+	env.ai.sources = append(env.ai.sources, env.ai.sources[0], models.Source{Title: "Stub", Href: "https://example.net/stub", Body: "Too short."})
+	req := models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"}
+
+	w := serve(env.h.Preview, newRequest(t, http.MethodPost, "/research/preview", "user-a", req))
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: got %d %s, want 200", w.Code, w.Body)
+	}
+	var preview struct {
+		Queries []string        `json:"queries"`
+		Sources []models.Source `json:"sources"`
+	}
+	decode(t, w, &preview)
+	if env.ai.called("/api/generate-report") != 0 {
+		t.Error("preview wrote a report")
+	}
+
+	doc := env.create(t, "user-a", req)
+	if !reflect.DeepEqual(preview.Queries, doc.SearchQueries) {
+		t.Errorf("previewed queries %v, create used %v", preview.Queries, doc.SearchQueries)
+	}
+	if !reflect.DeepEqual(preview.Sources, doc.Sources) {
+		t.Errorf("previewed sources %+v, create used %+v", preview.Sources, doc.Sources)
+	}
+}
+
+func TestPreviewStopsAtQuota(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	quota := NewQuotaStore(rdb, 1)
+	env := newTestEnv(t, &Options{Quota: quota})
+	req := models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"}
+
+	if w := serve(env.h.Preview, newRequest(t, http.MethodPost, "/research/preview", "user-a", req)); w.Code != http.StatusOK {
+		t.Fatalf("preview: got %d %s, want 200", w.Code, w.Body)
+	}
+	if used, _ := quota.Used(context.Background(), "user-a"); used != 0 {
+		t.Errorf("preview counted against the quota: used = %d", used)
+	}
+
+	env.create(t, "user-a", req)
+	searches := env.ai.called("/api/search")
+	w := serve(env.h.Preview, newRequest(t, http.MethodPost, "/research/preview", "user-a", req))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("preview over quota: got %d %s, want 429", w.Code, w.Body)
+	}
+	if env.ai.called("/api/search") != searches {
+		t.Error("preview over quota still searched")
+	}
+}
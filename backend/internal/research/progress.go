@@ -0,0 +1,74 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// stepPercent is the rough completion percentage reported when a step starts.
+var stepPercent = map[string]int{
+	StepGeneratingQueries: 10,
+	StepSearching:         25,
+	StepWritingReport:     45,
+	StepCompilingPDF:      75,
+	StepCompilingTex:      85,
+	StepUploading:         90,
+	StepSaving:            95,
+}
+
+// ProgressEvent is a single pipeline progress update relayed over SSE.
+type ProgressEvent struct {
+	Status    string    `json:"status"`
+	Step      string    `json:"step,omitempty"`
+	Percent   int       `json:"percent"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Final reports whether the event ends the job.
+func (e ProgressEvent) Final() bool {
+	switch e.Status {
+	case models.StatusComplete, models.StatusDegraded, models.StatusFailed, models.StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Progress publishes pipeline step transitions on a Redis pub/sub channel
+// per document so any backend instance can stream them to the client.
+type Progress struct {
+	rdb *redis.Client
+}
+
+func NewProgress(rdb *redis.Client) *Progress {
+	return &Progress{rdb: rdb}
+}
+
+func progressChannel(docID string) string {
+	return "research_progress:" + docID
+}
+
+// Publish broadcasts ev for docID. Failures are logged, never fatal.
+func (p *Progress) Publish(ctx context.Context, docID string, ev ProgressEvent) {
+	if p == nil {
+		return
+	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now().UTC()
+	}
+	payload, _ := json.Marshal(ev)
+	if err := p.rdb.Publish(ctx, progressChannel(docID), payload).Err(); err != nil {
+		log.Printf("progress publish %s: %v", docID, err)
+	}
+}
+
+// Subscribe listens for events on docID. The caller must Close it.
+func (p *Progress) Subscribe(ctx context.Context, docID string) *redis.PubSub {
+	return p.rdb.Subscribe(ctx, progressChannel(docID))
+}
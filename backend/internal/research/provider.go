@@ -0,0 +1,60 @@
+package research
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// DefaultProvider names the Python AI service, which is always registered.
+const DefaultProvider = "python"
+
+// Provider generates queries, searches the web and writes reports. The
+// Python AI service client is one implementation; native clients for other
+// vendors can be registered alongside it.
+type Provider interface {
+	GenerateQueries(ctx context.Context, apiKey, model, topic string, params GenerationParams) ([]string, error)
+	Search(ctx context.Context, queries []string, resultsPerQuery int) ([]models.Source, error)
+	GenerateReport(ctx context.Context, apiKey, model, topic, ctxStr string, sources []models.Source, opts ReportOptions) (string, error)
+}
+
+// Providers is a registry of AI providers by name.
+type Providers struct {
+	byName map[string]Provider
+	def    string
+}
+
+// NewProviders returns a registry whose default provider is p under name.
+func NewProviders(name string, p Provider) *Providers {
+	return &Providers{byName: map[string]Provider{name: p}, def: name}
+}
+
+// Register adds or replaces the provider under name.
+func (r *Providers) Register(name string, p Provider) {
+	r.byName[name] = p
+}
+
+// Get returns the provider registered under name; "" selects the default.
+func (r *Providers) Get(name string) (Provider, bool) {
+	if name == "" {
+		name = r.def
+	}
+	p, ok := r.byName[name]
+	return p, ok
+}
+
+// Default is the name of the provider used when a request names none.
+func (r *Providers) Default() string {
+	return r.def
+}
+
+// Names lists the registered providers, sorted.
+func (r *Providers) Names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
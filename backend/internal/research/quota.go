@@ -0,0 +1,171 @@
+package research
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// QuotaStore caps how many reports each user can create per calendar
+// month (UTC). Counters are keyed by month, so they reset on their own
+// and expire once the month is long over. A nil store doesn't limit.
+type QuotaStore struct {
+	rdb   *redis.Client
+	limit int
+	now   func() time.Time
+}
+
+// NewQuotaStore allows limit creates per user per month; it returns nil,
+// no quota, if limit is not positive.
+func NewQuotaStore(rdb *redis.Client, limit int) *QuotaStore {
+	if limit <= 0 {
+		return nil
+	}
+	return &QuotaStore{rdb: rdb, limit: limit, now: time.Now}
+}
+
+// month is the counter period containing t.
+func month(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+func usageKey(userID, month string) string {
+	return "usage:" + userID + ":" + month
+}
+
+// Take counts one create against the user's quota for the current month.
+// ok is false, and nothing is counted, if the quota is already used up.
+func (q *QuotaStore) Take(ctx context.Context, userID string) (ok bool, err error) {
+	if q == nil {
+		return true, nil
+	}
+	t := q.now()
+	key := usageKey(userID, month(t))
+	used, err := q.rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if used == 1 {
+		// Keep the counter a little past the end of the month.
+		q.rdb.ExpireAt(ctx, key, startOfNextMonth(t).Add(7*24*time.Hour))
+	}
+	if used > int64(q.limit) {
+		q.rdb.Decr(ctx, key)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Refund gives back a create counted by Take that didn't happen.
+func (q *QuotaStore) Refund(ctx context.Context, userID string) {
+	if q == nil {
+		return
+	}
+	if err := q.rdb.Decr(ctx, usageKey(userID, month(q.now()))).Err(); err != nil {
+		log.Printf("refund quota for %s: %v", userID, err)
+	}
+}
+
+// Used returns how many reports the user created this month.
+func (q *QuotaStore) Used(ctx context.Context, userID string) (int, error) {
+	n, err := q.rdb.Get(ctx, usageKey(userID, month(q.now()))).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return n, err
+}
+
+// Exhausted reports whether the user has no creates left this month,
+// without counting one.
+func (q *QuotaStore) Exhausted(ctx context.Context, userID string) (bool, error) {
+	if q == nil {
+		return false, nil
+	}
+	used, err := q.Used(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return used >= q.limit, nil
+}
+
+// takeQuota counts one report against userID's quota, writing the error
+// response and returning false if that isn't possible.
+func (h *Handler) takeQuota(w http.ResponseWriter, r *http.Request, userID string) bool {
+	taken, err := h.opts.Quota.Take(r.Context(), userID)
+	if err != nil {
+		log.Printf("take quota for %s: %v", userID, err)
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "usage quota unavailable")
+		return false
+	}
+	if !taken {
+		h.opts.Quota.writeExceeded(w)
+		return false
+	}
+	return true
+}
+
+// quotaLeft writes a 429 and returns false if userID has no reports left
+// this month, without counting one. Handlers that spend AI or search calls
+// without creating a report check it first.
+func (h *Handler) quotaLeft(w http.ResponseWriter, r *http.Request, userID string) bool {
+	exhausted, err := h.opts.Quota.Exhausted(r.Context(), userID)
+	if err != nil {
+		log.Printf("check quota for %s: %v", userID, err)
+		apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "usage quota unavailable")
+		return false
+	}
+	if exhausted {
+		h.opts.Quota.writeExceeded(w)
+		return false
+	}
+	return true
+}
+
+// writeExceeded responds that the monthly quota is used up.
+func (q *QuotaStore) writeExceeded(w http.ResponseWriter) {
+	apierror.WriteDetails(w, http.StatusTooManyRequests, apierror.QuotaExceeded, "monthly report quota reached", map[string]interface{}{
+		"limit":     q.limit,
+		"resets_at": startOfNextMonth(q.now()),
+	})
+}
+
+func startOfNextMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Usage reports the current user's report count for this month and how
+// much of the monthly quota is left. Without a quota, limit is 0 and
+// remaining is left out.
+func (h *Handler) Usage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	q := h.opts.Quota
+	resp := struct {
+		Month     string    `json:"month"`
+		Used      int       `json:"used"`
+		Limit     int       `json:"limit"`
+		Remaining *int      `json:"remaining,omitempty"`
+		ResetsAt  time.Time `json:"resets_at"`
+	}{Month: month(time.Now()), ResetsAt: startOfNextMonth(time.Now())}
+	if q != nil {
+		now := q.now()
+		used, err := q.Used(r.Context(), userID)
+		if err != nil {
+			log.Printf("usage for %s: %v", userID, err)
+			apierror.Write(w, http.StatusServiceUnavailable, apierror.Unavailable, "usage unavailable")
+			return
+		}
+		remaining := max(q.limit-used, 0)
+		resp.Month, resp.ResetsAt = month(now), startOfNextMonth(now)
+		resp.Used, resp.Limit, resp.Remaining = used, q.limit, &remaining
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,130 @@
+package research
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestQuotaMonthBoundary(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	quota := NewQuotaStore(rdb, 2)
+	// Counters expire a week after their month, so test a future one.
+	year := time.Now().Year() + 1
+	now := time.Date(year, time.January, 31, 23, 59, 0, 0, time.UTC)
+	quota.now = func() time.Time { return now }
+	env := newTestEnv(t, &Options{Quota: quota})
+	req := models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"}
+
+	type usage struct {
+		Month     string    `json:"month"`
+		Used      int       `json:"used"`
+		Limit     int       `json:"limit"`
+		Remaining *int      `json:"remaining"`
+		ResetsAt  time.Time `json:"resets_at"`
+	}
+	getUsage := func(userID string) usage {
+		t.Helper()
+		w := serve(env.h.Usage, newRequest(t, http.MethodGet, "/user/usage", userID, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("usage: got %d %s, want 200", w.Code, w.Body)
+		}
+		var u usage
+		decode(t, w, &u)
+		return u
+	}
+
+	env.create(t, "user-a", req)
+	env.create(t, "user-a", req)
+	w := serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a", req))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("over quota: got %d, want 429", w.Code)
+	}
+	var rejected struct {
+		Error struct {
+			Code    apierror.Code `json:"code"`
+			Details struct {
+				Limit    int       `json:"limit"`
+				ResetsAt time.Time `json:"resets_at"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	decode(t, w, &rejected)
+	if rejected.Error.Code != apierror.QuotaExceeded {
+		t.Errorf("code = %q, want %q", rejected.Error.Code, apierror.QuotaExceeded)
+	}
+	february := time.Date(year, time.February, 1, 0, 0, 0, 0, time.UTC)
+	if d := rejected.Error.Details; d.Limit != 2 || !d.ResetsAt.Equal(february) {
+		t.Errorf("429 details = %+v, want limit 2 resetting on %v", d, february)
+	}
+	u := getUsage("user-a")
+	if u.Month != fmt.Sprintf("%d-01", year) || u.Used != 2 || u.Limit != 2 || u.Remaining == nil || *u.Remaining != 0 {
+		t.Errorf("january usage = %+v, want 2 of 2 used", u)
+	}
+	if u := getUsage("user-b"); u.Used != 0 || *u.Remaining != 2 {
+		t.Errorf("user-b usage = %+v, want none used", u)
+	}
+
+	// The counter resets when the month turns.
+	now = february.Add(time.Second)
+	env.create(t, "user-a", req)
+	u = getUsage("user-a")
+	if u.Month != fmt.Sprintf("%d-02", year) || u.Used != 1 || *u.Remaining != 1 {
+		t.Errorf("february usage = %+v, want 1 of 2 used", u)
+	}
+	if !u.ResetsAt.Equal(time.Date(year, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("resets at %v, want March 1", u.ResetsAt)
+	}
+}
+
+func TestNoQuota(t *testing.T) {
+	if NewQuotaStore(nil, 0) != nil {
+		t.Fatal("a zero limit still limits")
+	}
+	env := newTestEnv(t, nil)
+	env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	w := serve(env.h.Usage, newRequest(t, http.MethodGet, "/user/usage", "user-a", nil))
+	var got map[string]interface{}
+	decode(t, w, &got)
+	if _, ok := got["remaining"]; ok || got["limit"] != 0.0 {
+		t.Errorf("usage without a quota = %v, want limit 0 and no remaining", got)
+	}
+}
+
+func TestQuotaMonthIsUTC(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	quota := NewQuotaStore(rdb, 1)
+	year := time.Now().Year() + 1
+	// Already February in Tokyo, still January in UTC.
+	now := time.Date(year, time.February, 1, 8, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	quota.now = func() time.Time { return now }
+
+	if ok, _ := quota.Take(ctx, "user-a"); !ok {
+		t.Fatal("first take refused")
+	}
+	now = time.Date(year, time.January, 31, 12, 0, 0, 0, time.UTC)
+	if ok, _ := quota.Take(ctx, "user-a"); ok {
+		t.Error("the take counted against February instead of January")
+	}
+}
+
+func TestQuotaRefund(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	quota := NewQuotaStore(rdb, 1)
+
+	quota.Take(ctx, "user-a")
+	if ok, _ := quota.Take(ctx, "user-a"); ok {
+		t.Fatal("take over the limit allowed")
+	}
+	quota.Refund(ctx, "user-a")
+	if ok, _ := quota.Take(ctx, "user-a"); !ok {
+		t.Error("take after a refund refused")
+	}
+}
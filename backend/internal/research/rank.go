@@ -0,0 +1,128 @@
+package research
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// DefaultSourceLimits caps how many sources per depth preset reach the
+// report prompt. A depth missing from the map, or a zero limit, keeps all
+// sources.
+var DefaultSourceLimits = map[string]int{
+	"Quick":    6,
+	"Standard": 12,
+	"Deep":     20,
+}
+
+// minSourceBodyLen is the shortest snippet worth citing; anything shorter
+// carries no information the model could use.
+const minSourceBodyLen = 40
+
+const msgNoUsableSources = "No search results had a usable snippet."
+
+// ParseSourceLimits parses per-depth source limits given as depth=limit.
+// Depths not listed keep their default.
+func ParseSourceLimits(entries []string) (map[string]int, error) {
+	limits := maps.Clone(DefaultSourceLimits)
+	for _, e := range entries {
+		depth, n, ok := strings.Cut(e, "=")
+		depth = strings.TrimSpace(depth)
+		if _, known := DepthConfig[depth]; !ok || !known {
+			return nil, fmt.Errorf("source limit %q: want depth=limit with depth one of Quick, Standard, Deep", e)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("source limit %q: limit must be a non-negative integer", e)
+		}
+		limits[depth] = limit
+	}
+	return limits, nil
+}
+
+// sourceLimit returns the source cap of a depth preset.
+func (h *Handler) sourceLimit(depth string) int {
+	if _, ok := DepthConfig[depth]; !ok {
+		depth = "Standard"
+	}
+	return h.opts.SourceLimits[depth]
+}
+
+// RankSources drops sources with near-empty snippets and orders the rest
+// by relevance to topic, best first, keeping at most limit (all if limit
+// is not positive). Relevance combines how many topic keywords a source
+// mentions, how much its snippet says, and its Credibility score, so it
+// should run after scoreSources. Ties keep their search order.
+func RankSources(sources []models.Source, topic string, limit int) []models.Source {
+	keywords := topicKeywords(topic)
+	type scored struct {
+		source models.Source
+		score  float64
+	}
+	kept := make([]scored, 0, len(sources))
+	for _, s := range sources {
+		body := strings.TrimSpace(s.Body)
+		if len([]rune(body)) < minSourceBodyLen || body == "N/A" {
+			continue
+		}
+		kept = append(kept, scored{s, relevance(s, keywords)})
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].score > kept[j].score })
+	if limit > 0 && len(kept) > limit {
+		kept = kept[:limit]
+	}
+	ranked := make([]models.Source, len(kept))
+	for i, k := range kept {
+		ranked[i] = k.source
+	}
+	return ranked
+}
+
+// relevance scores s from 0 to 1.
+func relevance(s models.Source, keywords []string) float64 {
+	overlap := 1.0
+	if len(keywords) > 0 {
+		text := strings.ToLower(s.Title + " " + s.Body)
+		found := 0
+		for _, k := range keywords {
+			if strings.Contains(text, k) {
+				found++
+			}
+		}
+		overlap = float64(found) / float64(len(keywords))
+	}
+	// Snippets are a few hundred characters at most; longer ones don't
+	// score higher.
+	length := min(float64(len([]rune(s.Body)))/300, 1)
+	return 0.5*overlap + 0.2*length + 0.3*s.Credibility
+}
+
+// stopWords are left out of the topic keywords.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true, "into": true,
+	"what": true, "how": true, "why": true, "are": true, "its": true, "their": true,
+	"about": true, "between": true, "over": true, "under": true, "versus": true,
+}
+
+// topicKeywords returns the distinct lowercase words of topic worth
+// matching: three letters or more and not a stop word.
+func topicKeywords(topic string) []string {
+	words := strings.FieldsFunc(strings.ToLower(topic), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var keywords []string
+	seen := map[string]bool{}
+	for _, w := range words {
+		if len([]rune(w)) < 3 || stopWords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		keywords = append(keywords, w)
+	}
+	return keywords
+}
@@ -0,0 +1,89 @@
+package research
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestRankSources(t *testing.T) {
+	long := strings.Repeat("Detailed measurements of panel output across seasons. ", 6)
+	sources := []models.Source{
+		{Title: "Off topic", Body: "A recipe for lemon cake that takes under an hour to bake."},
+		{Title: "Empty", Body: ""},
+		{Title: "Placeholder", Body: "N/A"},
+		{Title: "Too short", Body: "Solar panels are great."},
+		{Title: "Solar panel efficiency", Body: "Solar panels convert about a fifth of sunlight into power."},
+		{Title: "Solar panel field study", Body: "Solar panels: " + long},
+		{Title: "Solar panel efficiency, peer reviewed", Body: "Solar panels convert about a fifth of sunlight into power.", Credibility: 0.9},
+		{Title: "Solar farms", Body: "Large arrays of solar collectors are spreading across deserts."},
+	}
+
+	got := titles(RankSources(sources, "The efficiency of solar panels", 0))
+	want := []string{
+		"Solar panel efficiency, peer reviewed", // every keyword and credible
+		"Solar panel efficiency",                // every keyword, short snippet
+		"Solar panel field study",               // two keywords, long snippet
+		"Solar farms",                           // one keyword
+		"Off topic",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ranked\n%q\nwant\n%q", got, want)
+	}
+
+	if got := titles(RankSources(sources, "The efficiency of solar panels", 2)); !slices.Equal(got, want[:2]) {
+		t.Errorf("limit 2: got %q, want %q", got, want[:2])
+	}
+	if got := RankSources(sources[1:4], "solar", 5); len(got) != 0 {
+		t.Errorf("kept %q, want every empty snippet dropped", titles(got))
+	}
+}
+
+func TestRankSourcesKeepsSearchOrderOnTies(t *testing.T) {
+	body := "An overview of how photovoltaic cells turn light into current."
+	sources := []models.Source{{Title: "a", Body: body}, {Title: "b", Body: body}, {Title: "c", Body: body}}
+	if got := titles(RankSources(sources, "solar", 0)); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("got %q, want search order", got)
+	}
+}
+
+func TestTopicKeywords(t *testing.T) {
+	got := topicKeywords("What are the costs of Solar-panels vs. solar farms in 2024?")
+	want := []string{"costs", "solar", "panels", "farms", "2024"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseSourceLimits(t *testing.T) {
+	limits, err := ParseSourceLimits([]string{"Quick=3", " Deep = 0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits["Quick"] != 3 || limits["Standard"] != DefaultSourceLimits["Standard"] || limits["Deep"] != 0 {
+		t.Errorf("got %v", limits)
+	}
+	if DefaultSourceLimits["Quick"] == 3 {
+		t.Error("parsing changed the defaults")
+	}
+	for _, bad := range []string{"Quick", "Bottomless=3", "Quick=x", "Quick=-1"} {
+		if _, err := ParseSourceLimits([]string{bad}); err == nil {
+			t.Errorf("%q parsed without error", bad)
+		}
+	}
+}
+
+func TestCreateWithoutUsableSources(t *testing.T) {
+	env := newTestEnv(t, nil)
+	env.ai.sources = []models.Source{{Title: "Solar", Href: "https://example.org/solar"}}
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	if doc.Status != models.StatusFailed || doc.Error != msgNoUsableSources {
+		t.Errorf("got status %q error %q, want failed with %q", doc.Status, doc.Error, msgNoUsableSources)
+	}
+	if n := env.ai.called("/api/generate-report"); n != 0 {
+		t.Errorf("generate-report called %d times without usable sources", n)
+	}
+}
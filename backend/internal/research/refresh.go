@@ -0,0 +1,107 @@
+package research
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// RefreshSources re-runs the web search of a finished document with its
+// stored queries and replaces its sources, archiving the current version
+// first. The report is only rewritten when the body asks to regenerate it,
+// with the same fields as the regenerate endpoint; otherwise the refreshed
+// document is returned.
+func (h *Handler) RefreshSources(w http.ResponseWriter, r *http.Request) {
+	if h.rejectIfDraining(w) {
+		return
+	}
+	var req struct {
+		Regenerate *regenerateRequest `json:"regenerate"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+			return
+		}
+	}
+
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	if doc.Status == models.StatusPending || doc.Status == models.StatusRunning {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research is still running")
+		return
+	}
+	if len(doc.SearchQueries) == 0 {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "document has no search queries to refresh")
+		return
+	}
+	// Refreshing searches again, and regenerating counts as a new report,
+	// so neither is allowed once creating wouldn't be.
+	if !h.quotaLeft(w, r, doc.UserID) {
+		return
+	}
+	// Check the key before searching, so a missing one doesn't leave the
+	// sources refreshed but the report stale.
+	if regen := req.Regenerate; regen != nil && regen.APIKey == "" {
+		key, err := h.storedAPIKey(r.Context(), doc.UserID)
+		if err != nil {
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to load stored api key")
+			return
+		}
+		if key == "" {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "api_key is required")
+			return
+		}
+		regen.APIKey = key
+	}
+
+	provider, ok := h.provider(doc.Provider)
+	if !ok {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "unknown provider")
+		return
+	}
+	docID := doc.ID.Hex()
+	release, ok := h.lockDoc(w, r, docID)
+	if !ok {
+		return
+	}
+
+	// Results are never served from the cache: they are what went stale.
+	_, resultsPerQuery := depthCounts(models.CreateRequest{})
+	searchReq := models.CreateRequest{Topic: doc.Topic, Provider: doc.Provider, Dedup: doc.Dedup}
+	sources, serr := h.searchSources(r.Context(), searchReq, provider, nil, doc.SearchQueries, resultsPerQuery, &DebugCapture{})
+	if serr != nil {
+		release()
+		serr.write(w)
+		return
+	}
+
+	if err := h.archiveVersion(r.Context(), doc, docID); err != nil {
+		release()
+		log.Printf("archive version %s: %v", docID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to archive current version")
+		return
+	}
+	err := h.mongo.SetSources(r.Context(), docID, sources)
+	// regenerate takes the lock itself.
+	release()
+	if err != nil {
+		log.Printf("set sources %s: %v", docID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to update sources")
+		return
+	}
+
+	doc.Sources = sources
+	if req.Regenerate != nil {
+		regen := *req.Regenerate
+		regen.archived = true
+		h.regenerate(w, r, doc, regen)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
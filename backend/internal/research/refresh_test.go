@@ -0,0 +1,106 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestRefreshSources(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	refresh := func(body interface{}) (int, *models.Document) {
+		t.Helper()
+		w := serve(env.h.RefreshSources, newRequest(t, http.MethodPost, "/research/"+id+"/refresh-sources", "user-a", body, "id", id))
+		var got models.Document
+		if w.Code == http.StatusOK || w.Code == http.StatusAccepted {
+			decode(t, w, &got)
+		}
+		return w.Code, &got
+	}
+
+	fresh := models.Source{Title: "Perovskite cells", Body: "A new kind of solar cell that could make panels cheaper.", Href: "https://example.net/perovskite"}
+	env.ai.sources = append(env.ai.sources, fresh)
+	searches := env.ai.called("/api/search")
+	code, got := refresh(nil)
+	if code != http.StatusOK {
+		t.Fatalf("refresh: got %d, want 200", code)
+	}
+	if env.ai.called("/api/search") == searches {
+		t.Error("refresh did not search again")
+	}
+	if len(got.Sources) != len(doc.Sources)+1 || got.Sources[len(got.Sources)-1].Href != fresh.Href {
+		t.Errorf("refreshed sources = %+v, want the new one added", got.Sources)
+	}
+	if stored := env.sources(t, id); len(stored) != len(got.Sources) {
+		t.Errorf("stored %d sources, want %d", len(stored), len(got.Sources))
+	}
+	v, err := env.docs.GetVersion(context.Background(), id, 1)
+	if err != nil {
+		t.Fatalf("old version not archived: %v", err)
+	}
+	if len(v.Sources) != len(doc.Sources) {
+		t.Errorf("archived %d sources, want the %d old ones", len(v.Sources), len(doc.Sources))
+	}
+	if n := env.ai.called("/api/generate-report"); n != 1 {
+		t.Errorf("report regenerated %d times without being asked", n-1)
+	}
+
+	code, _ = refresh(map[string]interface{}{"regenerate": map[string]interface{}{"api_key": "sk-test", "overwrite": true}})
+	if code != http.StatusAccepted {
+		t.Fatalf("refresh and regenerate: got %d, want 202", code)
+	}
+	env.wait(t)
+	if n := env.ai.called("/api/generate-report"); n != 2 {
+		t.Errorf("generate-report called %d times, want 2", n)
+	}
+	versions, _ := env.docs.ListVersions(context.Background(), id)
+	if len(versions) != 2 {
+		t.Errorf("%d versions archived, want one per refresh", len(versions))
+	}
+}
+
+func TestRefreshSourcesRejects(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	skipped := env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test", SkipSearch: true})
+	running := env.insert(t, &models.Document{UserID: "user-a", Topic: "t", Status: models.StatusRunning, SearchQueries: []string{"q"}})
+	searches := env.ai.called("/api/search")
+
+	for name, tt := range map[string]struct {
+		id   string
+		body interface{}
+		code int
+	}{
+		"regenerate without key": {doc.ID.Hex(), map[string]interface{}{"regenerate": map[string]interface{}{}}, http.StatusBadRequest},
+		"no queries":             {skipped.ID.Hex(), nil, http.StatusConflict},
+		"running":                {running, nil, http.StatusConflict},
+	} {
+		w := serve(env.h.RefreshSources, newRequest(t, http.MethodPost, "/research/"+tt.id+"/refresh-sources", "user-a", tt.body, "id", tt.id))
+		if w.Code != tt.code {
+			t.Errorf("%s: got %d %s, want %d", name, w.Code, w.Body, tt.code)
+		}
+	}
+	if n := env.ai.called("/api/search"); n != searches {
+		t.Errorf("rejected refreshes searched %d times", n-searches)
+	}
+}
+
+func TestRefreshSourcesStopsAtQuota(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	env := newTestEnv(t, &Options{Quota: NewQuotaStore(rdb, 1)})
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	searches := env.ai.called("/api/search")
+
+	w := serve(env.h.RefreshSources, newRequest(t, http.MethodPost, "/research/"+id+"/refresh-sources", "user-a", nil, "id", id))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("refresh over quota: got %d %s, want 429", w.Code, w.Body)
+	}
+	if env.ai.called("/api/search") != searches {
+		t.Error("refresh over quota still searched")
+	}
+}
@@ -0,0 +1,163 @@
+package research
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Regenerate rewrites the report of a finished document from its stored
+// queries and sources, optionally with another model. By default the result
+// is a new document linked to the original via parent_id; overwrite=true
+// replaces the original in place instead.
+func (h *Handler) Regenerate(w http.ResponseWriter, r *http.Request) {
+	if h.rejectIfDraining(w) {
+		return
+	}
+	var body regenerateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+			return
+		}
+	}
+
+	orig, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	h.regenerate(w, r, orig, body)
+}
+
+// regenerateRequest is the body of a regenerate call.
+type regenerateRequest struct {
+	Model     string `json:"model"`
+	APIKey    string `json:"api_key"`
+	Overwrite bool   `json:"overwrite"`
+	// archived is set when the caller already archived the current
+	// version, so overwriting doesn't archive it twice.
+	archived bool
+}
+
+// regenerate starts rewriting the report of orig and responds with the
+// accepted document.
+func (h *Handler) regenerate(w http.ResponseWriter, r *http.Request, orig *models.Document, body regenerateRequest) {
+	if orig.Status == models.StatusPending || orig.Status == models.StatusRunning {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research is still running")
+		return
+	}
+	if body.APIKey == "" {
+		key, err := h.storedAPIKey(r.Context(), orig.UserID)
+		if err != nil {
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to load stored api key")
+			return
+		}
+		body.APIKey = key
+	}
+	if body.APIKey == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "api_key is required")
+		return
+	}
+	if body.Model == "" {
+		body.Model = orig.ModelUsed
+	} else if !h.modelAllowed(body.Model) {
+		h.writeModelNotAllowed(w)
+		return
+	}
+	if len(orig.Sources) == 0 && !orig.SearchSkipped {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "document has no sources to regenerate from")
+		return
+	}
+
+	origID := orig.ID.Hex()
+	release, ok := h.lockDoc(w, r, origID)
+	if !ok {
+		return
+	}
+	// A regenerate writes a new report, so it counts like a create.
+	if !h.takeQuota(w, r, orig.UserID) {
+		release()
+		return
+	}
+
+	req := models.CreateRequest{
+		Topic:          orig.Topic,
+		Model:          body.Model,
+		APIKey:         body.APIKey,
+		Provider:       orig.Provider,
+		SkipSearch:     orig.SearchSkipped,
+		Dedup:          orig.Dedup,
+		CitationStyle:  orig.CitationStyle,
+		Length:         orig.Length,
+		TargetWords:    orig.TargetWords,
+		TargetSections: orig.TargetSections,
+		Language:       orig.Language,
+		Temperature:    orig.Temperature,
+		MaxTokens:      orig.MaxTokens,
+		Watermark:      orig.Watermark,
+	}
+	if req.CitationStyle == "" {
+		req.CitationStyle = DefaultCitationStyle
+	}
+
+	doc := orig
+	docID := origID
+	if body.Overwrite {
+		if !body.archived {
+			if err := h.archiveVersion(r.Context(), doc, docID); err != nil {
+				release()
+				h.opts.Quota.Refund(r.Context(), orig.UserID)
+				log.Printf("archive version %s: %v", docID, err)
+				apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to archive current version")
+				return
+			}
+		}
+		doc.ModelUsed = req.Model
+		doc.RequestedModel = ""
+		doc.Status, doc.Step, doc.Error = models.StatusPending, "", ""
+		if err := h.mongo.SetStatus(r.Context(), docID, models.StatusPending, "", ""); err != nil {
+			release()
+			h.opts.Quota.Refund(r.Context(), orig.UserID)
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to update research")
+			return
+		}
+	} else {
+		doc = &models.Document{
+			UserID:         orig.UserID,
+			Topic:          orig.Topic,
+			ModelUsed:      req.Model,
+			Provider:       orig.Provider,
+			SearchQueries:  orig.SearchQueries,
+			Sources:        orig.Sources,
+			SearchSkipped:  orig.SearchSkipped,
+			Dedup:          orig.Dedup,
+			CitationStyle:  orig.CitationStyle,
+			Length:         orig.Length,
+			TargetWords:    orig.TargetWords,
+			TargetSections: orig.TargetSections,
+			Language:       orig.Language,
+			Temperature:    orig.Temperature,
+			MaxTokens:      orig.MaxTokens,
+			Watermark:      orig.Watermark,
+			Tags:           orig.Tags,
+			ParentID:       origID,
+			Status:         models.StatusPending,
+		}
+		var err error
+		docID, err = h.mongo.Insert(r.Context(), doc)
+		if err != nil {
+			release()
+			h.opts.Quota.Refund(r.Context(), orig.UserID)
+			log.Printf("mongo insert error: %v", err)
+			apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to save research")
+			return
+		}
+	}
+
+	accepted := *doc
+	h.startPipeline(job{docID: docID, doc: doc, req: req, reuseSources: true, release: release})
+	writeJSON(w, http.StatusAccepted, accepted)
+}
@@ -0,0 +1,87 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestRegenerate(t *testing.T) {
+	env := newTestEnv(t, nil)
+	orig := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Model: "mistral-small-latest"})
+	id := orig.ID.Hex()
+	regenerate := func(body map[string]interface{}) *models.Document {
+		t.Helper()
+		w := serve(env.h.Regenerate, newRequest(t, http.MethodPost, "/research/"+id+"/regenerate", "user-a", body, "id", id))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("regenerate: got %d %s, want 202", w.Code, w.Body)
+		}
+		var accepted models.Document
+		decode(t, w, &accepted)
+		env.wait(t)
+		doc, err := env.docs.GetByID(context.Background(), accepted.ID.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return doc
+	}
+
+	env.ai.report = "\\section{Second try}\n"
+	version := regenerate(map[string]interface{}{"api_key": "sk-test", "model": "mistral-large-latest"})
+	if version.ID == orig.ID || version.ParentID != id {
+		t.Errorf("new version %s has parent %q, want a new document linked to %s", version.ID.Hex(), version.ParentID, id)
+	}
+	if version.ModelUsed != "mistral-large-latest" || version.Status != models.StatusComplete {
+		t.Errorf("new version used %q with status %q", version.ModelUsed, version.Status)
+	}
+	if n := env.ai.called("/api/search"); n != 1 {
+		t.Errorf("searched %d times, want the stored sources reused", n)
+	}
+	if after, _ := env.docs.GetByID(context.Background(), id); after.LatexContent != orig.LatexContent {
+		t.Error("original changed without overwrite")
+	}
+
+	env.ai.report = "\\section{Third try}\n"
+	over := regenerate(map[string]interface{}{"api_key": "sk-test", "overwrite": true})
+	if over.ID != orig.ID || over.ModelUsed != "mistral-small-latest" {
+		t.Errorf("overwrite produced %s with model %q, want %s with the original model", over.ID.Hex(), over.ModelUsed, id)
+	}
+	if over.LatexContent == orig.LatexContent {
+		t.Error("overwrite kept the old report")
+	}
+
+	w := serve(env.h.Regenerate, newRequest(t, http.MethodPost, "/research/"+id+"/regenerate", "user-a", nil, "id", id))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("no api key: got %d, want 400", w.Code)
+	}
+}
+
+func TestRegenerateCountsAgainstQuota(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	quota := NewQuotaStore(rdb, 2)
+	env := newTestEnv(t, &Options{Quota: quota})
+	orig := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := orig.ID.Hex()
+	regenerate := func() int {
+		w := serve(env.h.Regenerate, newRequest(t, http.MethodPost, "/research/"+id+"/regenerate", "user-a",
+			map[string]interface{}{"api_key": "sk-test"}, "id", id))
+		env.wait(t)
+		return w.Code
+	}
+
+	if code := regenerate(); code != http.StatusAccepted {
+		t.Fatalf("regenerate: got %d, want 202", code)
+	}
+	if used, _ := quota.Used(context.Background(), "user-a"); used != 2 {
+		t.Errorf("used = %d after a create and a regenerate, want 2", used)
+	}
+	reports := env.ai.called("/api/generate-report")
+	if code := regenerate(); code != http.StatusTooManyRequests {
+		t.Errorf("regenerate over quota: got %d, want 429", code)
+	}
+	if env.ai.called("/api/generate-report") != reports {
+		t.Error("regenerate over quota still wrote a report")
+	}
+}
@@ -0,0 +1,100 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers with statuses in order, then 200 with body, and
+// counts the requests it gets.
+func flakyServer(t *testing.T, body string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		var req struct {
+			APIKey string `json:"api_key"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.APIKey != "sk-test" {
+			t.Errorf("attempt %d: body not resent intact (%v)", n, err)
+		}
+		if n <= len(statuses) {
+			http.Error(w, http.StatusText(statuses[n-1]), statuses[n-1])
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+func TestAIClientRetriesUnavailable(t *testing.T) {
+	srv, hits := flakyServer(t, `{"queries":["solar panels cost"]}`,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	c := NewAIClient(srv.URL, fastRetry)
+
+	queries, err := c.GenerateQueries(context.Background(), "sk-test", "gpt-4o", "solar panels", GenerationParams{})
+	if err != nil {
+		t.Fatalf("GenerateQueries: %v", err)
+	}
+	if !slices.Equal(queries, []string{"solar panels cost"}) {
+		t.Errorf("queries = %v", queries)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("got %d attempts, want 3", n)
+	}
+}
+
+func TestPostJSONRetries(t *testing.T) {
+	body := []byte(`{"api_key":"sk-test"}`)
+	tests := []struct {
+		name       string
+		statuses   []int
+		wantStatus int
+		wantHits   int32
+	}{
+		{"succeeds first time", nil, http.StatusOK, 1},
+		{"rate limited then ok", []int{http.StatusTooManyRequests}, http.StatusOK, 2},
+		{"client error is not retried", []int{http.StatusBadRequest}, http.StatusBadRequest, 1},
+		{"not found is not retried", []int{http.StatusNotFound}, http.StatusNotFound, 1},
+		{"gives up after max attempts", []int{502, 503, 504, 500}, http.StatusGatewayTimeout, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := flakyServer(t, `{}`, tt.statuses...)
+			resp, err := postJSON(context.Background(), srv.Client(), srv.URL, body, fastRetry)
+			if err != nil {
+				t.Fatalf("postJSON: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if n := hits.Load(); n != tt.wantHits {
+				t.Errorf("got %d attempts, want %d", n, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestPostJSONStopsWhenContextDone(t *testing.T) {
+	srv, hits := flakyServer(t, `{}`, 503, 503, 503)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := postJSON(ctx, srv.Client(), srv.URL, []byte(`{"api_key":"sk-test"}`), RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}); err == nil {
+		t.Error("got no error with a cancelled context")
+	}
+	if n := hits.Load(); n > 1 {
+		t.Errorf("got %d attempts after the context was cancelled", n)
+	}
+}
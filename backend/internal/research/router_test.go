@@ -0,0 +1,175 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+	"github.com/ayush/research-ai-agent/backend/internal/middleware"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+	"github.com/ayush/research-ai-agent/backend/internal/storetest"
+)
+
+// newTestRouter routes the research endpoints the way main.go does,
+// authenticating the bearer tokens sessions hands out.
+func newTestRouter(h *Handler, sessions *storetest.Sessions) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/research", func(r chi.Router) {
+		r.Use(middleware.RequireAuth(nil, sessions))
+		r.Post("/", h.Create)
+		r.Get("/", h.List)
+		r.Get("/trash", h.Trash)
+		r.Get("/{id}", h.Get)
+		r.Delete("/{id}", h.Delete)
+		r.Post("/{id}/restore", h.Restore)
+	})
+	return r
+}
+
+// client sends requests to a test router as one user.
+type client struct {
+	t      *testing.T
+	router http.Handler
+	token  string
+}
+
+func (c client) do(method, target string, body interface{}) *httptest.ResponseRecorder {
+	c.t.Helper()
+	r := httptest.NewRequest(method, target, jsonBody(c.t, body))
+	if c.token != "" {
+		storetest.Authorize(r, c.token)
+	}
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, r)
+	return w
+}
+
+// newClients returns a router on env and a client for each of users.
+func newClients(t *testing.T, env *testEnv, users ...string) []client {
+	sessions := storetest.NewSessions()
+	router := newTestRouter(env.h, sessions)
+	clients := make([]client, len(users))
+	for i, u := range users {
+		clients[i] = client{t: t, router: router, token: sessions.Login(u)}
+	}
+	return clients
+}
+
+func TestRouterRequiresAuth(t *testing.T) {
+	env := newTestEnv(t, nil)
+	router := newTestRouter(env.h, storetest.NewSessions())
+
+	for name, prepare := range map[string]func(*http.Request){
+		"no credentials": func(*http.Request) {},
+		"unknown token":  func(r *http.Request) { storetest.Authorize(r, "rat_unknown") },
+		"session cookie": func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: auth.SessionCookie, Value: "some-session"})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/research/", nil)
+			prepare(r)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("got %d %s, want 401", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestRouterCreateAndList(t *testing.T) {
+	env := newTestEnv(t, nil)
+	c := newClients(t, env, "user-a", "user-b")
+	alice, bob := c[0], c[1]
+
+	w := alice.do(http.MethodPost, "/research/", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("create: got %d %s, want 202", w.Code, w.Body)
+	}
+	var created models.Document
+	decode(t, w, &created)
+	if created.ID.IsZero() || created.UserID != "user-a" {
+		t.Fatalf("create returned %+v", created)
+	}
+	env.wait(t)
+
+	var list listResponse
+	w = alice.do(http.MethodGet, "/research/", nil)
+	decode(t, w, &list)
+	if w.Code != http.StatusOK || len(list.Documents) != 1 {
+		t.Fatalf("list: got %d with %d documents, want 200 with 1", w.Code, len(list.Documents))
+	}
+	if doc := list.Documents[0]; doc.ID != created.ID || doc.Status != models.StatusComplete {
+		t.Errorf("listed %s (%s), want %s (complete)", doc.ID.Hex(), doc.Status, created.ID.Hex())
+	}
+
+	w = bob.do(http.MethodGet, "/research/", nil)
+	decode(t, w, &list)
+	if len(list.Documents) != 0 {
+		t.Errorf("another user sees %d documents", len(list.Documents))
+	}
+	if w := bob.do(http.MethodGet, "/research/"+created.ID.Hex(), nil); w.Code != http.StatusForbidden {
+		t.Errorf("another user's get: got %d, want 403", w.Code)
+	}
+}
+
+func TestRouterCreateValidates(t *testing.T) {
+	env := newTestEnv(t, nil)
+	alice := newClients(t, env, "user-a")[0]
+
+	for name, req := range map[string]models.CreateRequest{
+		"no topic":        {APIKey: "sk-test"},
+		"no api key":      {Topic: "solar panels"},
+		"unknown dedup":   {Topic: "solar panels", APIKey: "sk-test", Dedup: "sometimes"},
+		"bad credibility": {Topic: "solar panels", APIKey: "sk-test", MinCredibility: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if w := alice.do(http.MethodPost, "/research/", req); w.Code != http.StatusBadRequest {
+				t.Errorf("got %d %s, want 400", w.Code, w.Body)
+			}
+		})
+	}
+	if stats := env.h.Stats(); stats.Started != 0 {
+		t.Errorf("invalid requests started %d pipelines", stats.Started)
+	}
+}
+
+func TestRouterDelete(t *testing.T) {
+	env := newTestEnv(t, nil)
+	alice := newClients(t, env, "user-a")[0]
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+
+	if w := alice.do(http.MethodDelete, "/research/"+id, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: got %d %s, want 200", w.Code, w.Body)
+	}
+	var list listResponse
+	decode(t, alice.do(http.MethodGet, "/research/", nil), &list)
+	if len(list.Documents) != 0 {
+		t.Errorf("trashed document still listed")
+	}
+	decode(t, alice.do(http.MethodGet, "/research/trash", nil), &list)
+	if len(list.Documents) != 1 || list.Documents[0].ID.Hex() != id {
+		t.Errorf("trash = %v, want the deleted document", list.Documents)
+	}
+	if len(env.files.Keys()) == 0 {
+		t.Error("moving to the trash removed the files")
+	}
+
+	if w := alice.do(http.MethodDelete, "/research/"+id+"?permanent=true", nil); w.Code != http.StatusOK {
+		t.Fatalf("permanent delete: got %d %s, want 200", w.Code, w.Body)
+	}
+	if _, err := env.docs.GetByID(context.Background(), id); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("document still stored: %v", err)
+	}
+	if keys := env.files.Keys(); len(keys) != 0 {
+		t.Errorf("files left after permanent delete: %v", keys)
+	}
+}
@@ -0,0 +1,64 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// Searcher runs web searches; every Provider is one.
+type Searcher interface {
+	Search(ctx context.Context, queries []string, resultsPerQuery int) ([]models.Source, error)
+}
+
+// searchConcurrent searches each query separately, at most concurrency at a
+// time, and concatenates the results in query order so the outcome doesn't
+// depend on which request finished first. Queries that fail are logged and
+// skipped; it only returns an error when every query failed. With
+// maxSources > 0 the result is truncated to that many sources.
+func searchConcurrent(ctx context.Context, s Searcher, queries []string, resultsPerQuery, concurrency, maxSources int) ([]models.Source, error) {
+	results := make([][]models.Source, len(queries))
+	errs := make([]error, len(queries))
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, q := range queries {
+		i, q := i, q
+		g.Go(func() error {
+			sources, err := s.Search(ctx, []string{q}, resultsPerQuery)
+			if err != nil {
+				log.Printf("search %q error: %v", q, err)
+				errs[i] = err
+				return nil
+			}
+			for j := range sources {
+				if sources[j].Query == "" {
+					sources[j].Query = q
+				}
+			}
+			results[i] = sources
+			return nil
+		})
+	}
+	g.Wait()
+
+	var sources []models.Source
+	failed := 0
+	for i := range queries {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		sources = append(sources, results[i]...)
+	}
+	if len(queries) > 0 && failed == len(queries) {
+		return nil, errors.Join(errs...)
+	}
+	if maxSources > 0 && len(sources) > maxSources {
+		sources = sources[:maxSources]
+	}
+	return sources, nil
+}
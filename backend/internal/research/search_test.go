@@ -0,0 +1,90 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// countingSearcher answers each query with two sources after a short delay
+// and records the highest number of searches in flight at once.
+type countingSearcher struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	fail     map[string]bool
+}
+
+func (s *countingSearcher) Search(ctx context.Context, queries []string, resultsPerQuery int) ([]models.Source, error) {
+	s.mu.Lock()
+	s.inFlight++
+	s.peak = max(s.peak, s.inFlight)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q := queries[0]
+	if s.fail[q] {
+		return nil, errors.New("search failed for " + q)
+	}
+	return []models.Source{
+		{Title: q + " 1", Href: "https://example.org/" + q + "/1"},
+		{Title: q + " 2", Href: "https://example.org/" + q + "/2"},
+	}, nil
+}
+
+func titles(sources []models.Source) []string {
+	var out []string
+	for _, s := range sources {
+		out = append(out, s.Title)
+	}
+	return out
+}
+
+func TestSearchConcurrentBoundsConcurrency(t *testing.T) {
+	s := &countingSearcher{}
+	queries := []string{"a", "b", "c", "d", "e", "f"}
+
+	sources, err := searchConcurrent(context.Background(), s, queries, 2, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", s.peak)
+	}
+	want := []string{"a 1", "a 2", "b 1", "b 2", "c 1", "c 2", "d 1", "d 2", "e 1", "e 2", "f 1", "f 2"}
+	if got := titles(sources); !slices.Equal(got, want) {
+		t.Errorf("got %v, want results in query order", got)
+	}
+	for _, src := range sources {
+		if src.Query != src.Title[:1] {
+			t.Errorf("source %q tagged with query %q", src.Title, src.Query)
+		}
+	}
+}
+
+func TestSearchConcurrentPartialFailure(t *testing.T) {
+	s := &countingSearcher{fail: map[string]bool{"b": true}}
+
+	sources, err := searchConcurrent(context.Background(), s, []string{"a", "b", "c"}, 2, 3, 3)
+	if err != nil {
+		t.Fatalf("one failed query aborted the search: %v", err)
+	}
+	if got, want := titles(sources), []string{"a 1", "a 2", "c 1"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	s.fail = map[string]bool{"a": true, "b": true}
+	if _, err := searchConcurrent(context.Background(), s, []string{"a", "b"}, 2, 2, 0); err == nil {
+		t.Error("every query failed but no error was returned")
+	}
+}
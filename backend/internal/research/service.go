@@ -1,10 +1,11 @@
 package research
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"strings"
 
@@ -18,42 +19,157 @@ var DepthConfig = map[string][2]int{
 	"Deep":     {6, 7},
 }
 
-// checkResp reads the response body and returns an error if the status is not 2xx.
-// On error it includes the upstream body for debugging.
+// Bounds of the explicit depth overrides of a create request.
+const (
+	maxQueriesLimit      = 10
+	resultsPerQueryLimit = 15
+)
+
+// depthCounts returns the number of queries and results per query for req:
+// its named depth preset, with any explicit overrides applied.
+func depthCounts(req models.CreateRequest) (maxQueries, resultsPerQuery int) {
+	depth, ok := DepthConfig[req.Depth]
+	if !ok {
+		depth = DepthConfig["Standard"]
+	}
+	maxQueries, resultsPerQuery = depth[0], depth[1]
+	if req.MaxQueries > 0 {
+		maxQueries = req.MaxQueries
+	}
+	if req.ResultsPerQuery > 0 {
+		resultsPerQuery = req.ResultsPerQuery
+	}
+	return maxQueries, resultsPerQuery
+}
+
+// LengthConfig maps report length presets to target word/section counts.
+var LengthConfig = map[string][2]int{
+	"Brief":         {1500, 4},
+	"Standard":      {3000, 6},
+	"Comprehensive": {6000, 10},
+}
+
+// ReportOptions are optional generation parameters forwarded to the AI
+// service. Zero values are omitted so the service keeps its defaults.
+type ReportOptions struct {
+	TargetWords    int    `json:"target_words,omitempty"`
+	TargetSections int    `json:"target_sections,omitempty"`
+	CitationStyle  string `json:"citation_style,omitempty"`
+	Language       string `json:"language,omitempty"`
+	GenerationParams
+}
+
+// GenerationParams are model sampling parameters. Unset fields are left
+// out of the request so the AI service applies its defaults.
+type GenerationParams struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+}
+
+// StatusError is a non-2xx answer from one of the Python services.
+type StatusError struct {
+	Service string
+	Path    string
+	Code    int
+	Body    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s returned %d: %s", e.Service, e.Path, e.Code, e.Body)
+}
+
+// checkResp reads the response body and returns a *StatusError if the status
+// is not 2xx. On error it includes the upstream body for debugging.
 func checkResp(resp *http.Response, service, path string) error {
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		return nil
 	}
 	body, _ := io.ReadAll(resp.Body)
-	return fmt.Errorf("%s %s returned %d: %s", service, path, resp.StatusCode, string(body))
+	return &StatusError{Service: service, Path: path, Code: resp.StatusCode, Body: string(body)}
+}
+
+// snippetLen caps how much of an unexpected response body is kept in errors.
+const snippetLen = 200
+
+// UnexpectedResponseError means a service answered 2xx with a content type
+// other than the one expected, e.g. an HTML error page from a proxy.
+type UnexpectedResponseError struct {
+	Service     string
+	Path        string
+	ContentType string
+	Snippet     string
+}
+
+func (e *UnexpectedResponseError) Error() string {
+	return fmt.Sprintf("%s %s returned an unexpected response (%s): %s",
+		e.Service, e.Path, e.ContentType, e.Snippet)
+}
+
+// checkContentType returns an *UnexpectedResponseError if the response's
+// media type isn't want. A missing Content-Type is let through to the decoder.
+func checkContentType(resp *http.Response, service, path, want string) error {
+	ct := resp.Header.Get("Content-Type")
+	if ct == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err == nil && (mediaType == want || (want == "application/json" && strings.HasSuffix(mediaType, "+json"))) {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, snippetLen))
+	return &UnexpectedResponseError{
+		Service:     service,
+		Path:        path,
+		ContentType: ct,
+		Snippet:     strings.TrimSpace(string(body)),
+	}
+}
+
+// checkJSON combines checkResp with a check for a JSON content type.
+func checkJSON(resp *http.Response, service, path string) error {
+	if err := checkResp(resp, service, path); err != nil {
+		return err
+	}
+	return checkContentType(resp, service, path, "application/json")
 }
 
 // ---------------------------------------------------------------------------
 // AIClient — calls the Python AI service (generate-queries, search, report)
 // ---------------------------------------------------------------------------
 
+// AIService is the AI service API the handlers depend on: a Provider that
+// can also suggest follow-up queries. *AIClient implements it.
+type AIService interface {
+	Provider
+	GapQueries(ctx context.Context, apiKey, model, topic, report string) ([]string, error)
+}
+
 // AIClient calls the Python AI service over HTTP.
 type AIClient struct {
 	baseURL    string
 	httpClient *http.Client
+	retry      RetryPolicy
 }
 
-func NewAIClient(baseURL string) *AIClient {
-	return &AIClient{baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{}}
+func NewAIClient(baseURL string, retry RetryPolicy) *AIClient {
+	return &AIClient{baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{}, retry: retry}
 }
 
 // GenerateQueries calls POST /api/generate-queries.
-func (c *AIClient) GenerateQueries(apiKey, model, topic string) ([]string, error) {
-	body, _ := json.Marshal(map[string]string{
-		"api_key": apiKey, "model": model, "topic": topic,
-	})
-	resp, err := c.post("/api/generate-queries", body)
+func (c *AIClient) GenerateQueries(ctx context.Context, apiKey, model, topic string, params GenerationParams) ([]string, error) {
+	body, _ := json.Marshal(struct {
+		APIKey string `json:"api_key"`
+		Model  string `json:"model"`
+		Topic  string `json:"topic"`
+		GenerationParams
+	}{apiKey, model, topic, params})
+	resp, err := c.post(ctx, "/api/generate-queries", body)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if err := checkResp(resp, "ai-service", "/api/generate-queries"); err != nil {
+	if err := checkJSON(resp, "ai-service", "/api/generate-queries"); err != nil {
 		return nil, err
 	}
 
@@ -67,17 +183,17 @@ func (c *AIClient) GenerateQueries(apiKey, model, topic string) ([]string, error
 }
 
 // Search calls POST /api/search.
-func (c *AIClient) Search(queries []string, resultsPerQuery int) ([]models.Source, error) {
+func (c *AIClient) Search(ctx context.Context, queries []string, resultsPerQuery int) ([]models.Source, error) {
 	body, _ := json.Marshal(map[string]interface{}{
 		"queries": queries, "results_per_query": resultsPerQuery,
 	})
-	resp, err := c.post("/api/search", body)
+	resp, err := c.post(ctx, "/api/search", body)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if err := checkResp(resp, "ai-service", "/api/search"); err != nil {
+	if err := checkJSON(resp, "ai-service", "/api/search"); err != nil {
 		return nil, err
 	}
 
@@ -91,18 +207,22 @@ func (c *AIClient) Search(queries []string, resultsPerQuery int) ([]models.Sourc
 }
 
 // GenerateReport calls POST /api/generate-report.
-func (c *AIClient) GenerateReport(apiKey, model, topic, context string, sources []models.Source) (string, error) {
-	body, _ := json.Marshal(map[string]interface{}{
-		"api_key": apiKey, "model": model, "topic": topic,
-		"context": context, "sources": sources,
-	})
-	resp, err := c.post("/api/generate-report", body)
+func (c *AIClient) GenerateReport(ctx context.Context, apiKey, model, topic, ctxStr string, sources []models.Source, opts ReportOptions) (string, error) {
+	body, _ := json.Marshal(struct {
+		APIKey  string          `json:"api_key"`
+		Model   string          `json:"model"`
+		Topic   string          `json:"topic"`
+		Context string          `json:"context"`
+		Sources []models.Source `json:"sources"`
+		ReportOptions
+	}{apiKey, model, topic, ctxStr, sources, opts})
+	resp, err := c.post(ctx, "/api/generate-report", body)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	if err := checkResp(resp, "ai-service", "/api/generate-report"); err != nil {
+	if err := checkJSON(resp, "ai-service", "/api/generate-report"); err != nil {
 		return "", err
 	}
 
@@ -115,12 +235,33 @@ func (c *AIClient) GenerateReport(apiKey, model, topic, context string, sources
 	return result.LatexBody, nil
 }
 
-func (c *AIClient) post(path string, body []byte) (*http.Response, error) {
-	resp, err := c.httpClient.Post(
-		c.baseURL+path,
-		"application/json",
-		bytes.NewReader(body),
-	)
+// GapQueries calls POST /api/gap-queries, asking the model to identify angles
+// the report misses and suggest follow-up search queries for them.
+func (c *AIClient) GapQueries(ctx context.Context, apiKey, model, topic, report string) ([]string, error) {
+	body, _ := json.Marshal(map[string]string{
+		"api_key": apiKey, "model": model, "topic": topic, "report": report,
+	})
+	resp, err := c.post(ctx, "/api/gap-queries", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkJSON(resp, "ai-service", "/api/gap-queries"); err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Queries []string `json:"queries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("ai-service /api/gap-queries: decode: %w", err)
+	}
+	return result.Queries, nil
+}
+
+func (c *AIClient) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	resp, err := postJSON(ctx, c.httpClient, c.baseURL+path, body, c.retry)
 	if err != nil {
 		return nil, fmt.Errorf("ai-service %s: %w", path, err)
 	}
@@ -131,22 +272,37 @@ func (c *AIClient) post(path string, body []byte) (*http.Response, error) {
 // LaTeXClient — calls the LaTeX compilation service (compile-pdf, compile-tex)
 // ---------------------------------------------------------------------------
 
+// LaTeXService compiles LaTeX reports. *LaTeXClient implements it.
+type LaTeXService interface {
+	CompilePDF(ctx context.Context, latexBody, title string, opts PDFOptions) ([]byte, error)
+	CompileTex(ctx context.Context, latexBody, title string) (string, error)
+}
+
 // LaTeXClient calls the Python LaTeX service over HTTP.
 type LaTeXClient struct {
 	baseURL    string
 	httpClient *http.Client
+	retry      RetryPolicy
 }
 
-func NewLaTeXClient(baseURL string) *LaTeXClient {
-	return &LaTeXClient{baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{}}
+func NewLaTeXClient(baseURL string, retry RetryPolicy) *LaTeXClient {
+	return &LaTeXClient{baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{}, retry: retry}
+}
+
+// PDFOptions are the optional settings of a PDF compile.
+type PDFOptions struct {
+	// Watermark is printed diagonally across every page; empty for none.
+	Watermark string `json:"watermark,omitempty"`
 }
 
 // CompilePDF calls POST /api/compile-pdf and returns raw PDF bytes.
-func (c *LaTeXClient) CompilePDF(latexBody, title string) ([]byte, error) {
-	body, _ := json.Marshal(map[string]string{
-		"latex_body": latexBody, "title": title,
-	})
-	resp, err := c.post("/api/compile-pdf", body)
+func (c *LaTeXClient) CompilePDF(ctx context.Context, latexBody, title string, opts PDFOptions) ([]byte, error) {
+	body, _ := json.Marshal(struct {
+		LatexBody string `json:"latex_body"`
+		Title     string `json:"title"`
+		PDFOptions
+	}{latexBody, title, opts})
+	resp, err := c.post(ctx, "/api/compile-pdf", body)
 	if err != nil {
 		return nil, err
 	}
@@ -155,21 +311,24 @@ func (c *LaTeXClient) CompilePDF(latexBody, title string) ([]byte, error) {
 	if err := checkResp(resp, "latex-service", "/api/compile-pdf"); err != nil {
 		return nil, err
 	}
+	if err := checkContentType(resp, "latex-service", "/api/compile-pdf", "application/pdf"); err != nil {
+		return nil, err
+	}
 	return io.ReadAll(resp.Body)
 }
 
 // CompileTex calls POST /api/compile-tex and returns the .tex source.
-func (c *LaTeXClient) CompileTex(latexBody, title string) (string, error) {
+func (c *LaTeXClient) CompileTex(ctx context.Context, latexBody, title string) (string, error) {
 	body, _ := json.Marshal(map[string]string{
 		"latex_body": latexBody, "title": title,
 	})
-	resp, err := c.post("/api/compile-tex", body)
+	resp, err := c.post(ctx, "/api/compile-tex", body)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	if err := checkResp(resp, "latex-service", "/api/compile-tex"); err != nil {
+	if err := checkJSON(resp, "latex-service", "/api/compile-tex"); err != nil {
 		return "", err
 	}
 
@@ -182,12 +341,8 @@ func (c *LaTeXClient) CompileTex(latexBody, title string) (string, error) {
 	return result.TexSource, nil
 }
 
-func (c *LaTeXClient) post(path string, body []byte) (*http.Response, error) {
-	resp, err := c.httpClient.Post(
-		c.baseURL+path,
-		"application/json",
-		bytes.NewReader(body),
-	)
+func (c *LaTeXClient) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	resp, err := postJSON(ctx, c.httpClient, c.baseURL+path, body, c.retry)
 	if err != nil {
 		return nil, fmt.Errorf("latex-service %s: %w", path, err)
 	}
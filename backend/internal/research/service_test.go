@@ -0,0 +1,147 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// hangingServer never answers until the client gives up, and reports each
+// request it receives on started.
+func hangingServer(t *testing.T) (*httptest.Server, chan struct{}) {
+	t.Helper()
+	started := make(chan struct{}, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Drain the body so the server notices the client hanging up.
+		io.Copy(io.Discard, r.Body)
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, started
+}
+
+// clientCalls returns a call to every AI and LaTeX client method against
+// the server at url.
+func clientCalls(url string) map[string]func(context.Context) error {
+	ai := NewAIClient(url, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	latex := NewLaTeXClient(url, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	return map[string]func(context.Context) error{
+		"GenerateQueries": func(ctx context.Context) error {
+			_, err := ai.GenerateQueries(ctx, "sk-test", "gpt-4o", "solar panels", GenerationParams{})
+			return err
+		},
+		"Search": func(ctx context.Context) error {
+			_, err := ai.Search(ctx, []string{"solar"}, 3)
+			return err
+		},
+		"GenerateReport": func(ctx context.Context) error {
+			_, err := ai.GenerateReport(ctx, "sk-test", "gpt-4o", "solar panels", "", nil, ReportOptions{})
+			return err
+		},
+		"CompilePDF": func(ctx context.Context) error {
+			_, err := latex.CompilePDF(ctx, "body", "title", PDFOptions{})
+			return err
+		},
+		"CompileTex": func(ctx context.Context) error {
+			_, err := latex.CompileTex(ctx, "body", "title")
+			return err
+		},
+		"GapQueries": func(ctx context.Context) error {
+			_, err := ai.GapQueries(ctx, "sk-test", "gpt-4o", "solar panels", "report")
+			return err
+		},
+	}
+}
+
+func TestClientsStopWhenContextCancelled(t *testing.T) {
+	srv, started := hangingServer(t)
+	for name, call := range clientCalls(srv.URL) {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			errc := make(chan error, 1)
+			go func() { errc <- call(ctx) }()
+
+			<-started
+			cancel()
+			select {
+			case err := <-errc:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("got %v, want context.Canceled", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("call did not return after the context was cancelled")
+			}
+		})
+	}
+}
+
+func TestClientsRejectUnexpectedContentType(t *testing.T) {
+	page := "<html><body>502 Bad Gateway " + strings.Repeat("x", 500) + "</body></html>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(srv.Close)
+
+	for name, call := range clientCalls(srv.URL) {
+		t.Run(name, func(t *testing.T) {
+			err := call(context.Background())
+			var unexpected *UnexpectedResponseError
+			if !errors.As(err, &unexpected) {
+				t.Fatalf("got %v, want *UnexpectedResponseError", err)
+			}
+			if unexpected.ContentType != "text/html; charset=utf-8" || !strings.HasPrefix(unexpected.Snippet, "<html>") {
+				t.Errorf("got %+v", unexpected)
+			}
+			if len(unexpected.Snippet) > snippetLen {
+				t.Errorf("snippet is %d bytes, want at most %d", len(unexpected.Snippet), snippetLen)
+			}
+		})
+	}
+}
+
+func TestClientsDecodeErrorsAreNotUnexpected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.Write([]byte(`{"queries": "not a list"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := NewAIClient(srv.URL, RetryPolicy{}).GenerateQueries(context.Background(), "sk-test", "gpt-4o", "solar panels", GenerationParams{})
+	var unexpected *UnexpectedResponseError
+	if err == nil || errors.As(err, &unexpected) {
+		t.Errorf("got %v, want a plain decode error", err)
+	}
+}
+
+func TestDepthCounts(t *testing.T) {
+	tests := []struct {
+		name                      string
+		req                       models.CreateRequest
+		wantQueries, wantPerQuery int
+	}{
+		{"default", models.CreateRequest{}, 4, 5},
+		{"quick", models.CreateRequest{Depth: "Quick"}, 2, 3},
+		{"deep", models.CreateRequest{Depth: "Deep"}, 6, 7},
+		{"unknown depth", models.CreateRequest{Depth: "Bottomless"}, 4, 5},
+		{"queries override", models.CreateRequest{Depth: "Quick", MaxQueries: 9}, 9, 3},
+		{"results override", models.CreateRequest{Depth: "Deep", ResultsPerQuery: 1}, 6, 1},
+		{"both overrides", models.CreateRequest{MaxQueries: 1, ResultsPerQuery: 15}, 1, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, r := depthCounts(tt.req)
+			if q != tt.wantQueries || r != tt.wantPerQuery {
+				t.Errorf("got %d queries of %d results, want %d of %d", q, r, tt.wantQueries, tt.wantPerQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package research
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// ShareStore wraps Redis for public share links. Each document has at most
+// one active token, tracked in both directions so revoking by document ID
+// removes the token.
+type ShareStore struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+func NewShareStore(rdb *redis.Client, ttl time.Duration) *ShareStore {
+	return &ShareStore{rdb: rdb, ttl: ttl}
+}
+
+// Create issues a new unguessable token for docID, replacing any existing one.
+func (s *ShareStore) Create(ctx context.Context, docID string) (string, time.Time, error) {
+	if err := s.Revoke(ctx, docID); err != nil {
+		return "", time.Time{}, err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, "share:"+token, docID, s.ttl)
+		p.Set(ctx, "share_doc:"+docID, token, s.ttl)
+		return nil
+	})
+	return token, time.Now().Add(s.ttl), err
+}
+
+// Get returns the document ID for a token, or "" if unknown or expired.
+func (s *ShareStore) Get(ctx context.Context, token string) (string, error) {
+	docID, err := s.rdb.Get(ctx, "share:"+token).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return docID, err
+}
+
+// Revoke deletes the share link of docID, if any.
+func (s *ShareStore) Revoke(ctx context.Context, docID string) error {
+	token, err := s.rdb.Get(ctx, "share_doc:"+docID).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.rdb.Del(ctx, "share:"+token, "share_doc:"+docID).Err()
+}
+
+// sharedDocument is the public view of a shared report.
+type sharedDocument struct {
+	ID        string          `json:"id"`
+	Topic     string          `json:"topic"`
+	ModelUsed string          `json:"model_used"`
+	Sources   []models.Source `json:"sources"`
+	HasPDF    bool            `json:"has_pdf"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// CreateShare issues a public link for a document the user owns.
+func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	docID := doc.ID.Hex()
+	token, expires, err := h.opts.Shares.Create(r.Context(), docID)
+	if err != nil {
+		log.Printf("share create %s: %v", docID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to create share link")
+		return
+	}
+
+	base := strings.TrimRight(h.opts.PublicURL, "/")
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"token":      token,
+		"url":        base + "/api/v1/share/" + token,
+		"expires_at": expires.UTC().Format(time.RFC3339),
+	})
+}
+
+// RevokeShare deletes the public link of a document the user owns.
+func (h *Handler) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	if err := h.opts.Shares.Revoke(r.Context(), doc.ID.Hex()); err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to revoke share link")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"message":"share link revoked"}`))
+}
+
+// sharedDoc resolves the {token} URL param to its document, writing a 404
+// if the link is unknown, expired, or points at a deleted document.
+func (h *Handler) sharedDoc(w http.ResponseWriter, r *http.Request) (*models.Document, bool) {
+	docID, err := h.opts.Shares.Get(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "share store error")
+		return nil, false
+	}
+	if docID == "" {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "share link not found or expired")
+		return nil, false
+	}
+	doc, err := h.mongo.GetByID(r.Context(), docID)
+	if err != nil || doc.DeletedAt != nil {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "share link not found or expired")
+		return nil, false
+	}
+	return doc, true
+}
+
+// GetShared returns the public metadata of a shared document.
+func (h *Handler) GetShared(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.sharedDoc(w, r)
+	if !ok {
+		return
+	}
+	sources := doc.Sources
+	if sources == nil {
+		sources = []models.Source{}
+	}
+	writeJSON(w, http.StatusOK, sharedDocument{
+		ID:        doc.ID.Hex(),
+		Topic:     doc.Topic,
+		ModelUsed: doc.ModelUsed,
+		Sources:   sources,
+		HasPDF:    doc.PDFObjectKey != "",
+		CreatedAt: doc.CreatedAt,
+	})
+}
+
+// SharedPDF streams the PDF of a shared document.
+func (h *Handler) SharedPDF(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.sharedDoc(w, r)
+	if !ok {
+		return
+	}
+	h.servePDF(w, r, doc)
+}
@@ -0,0 +1,78 @@
+package research
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// maxSlugLen caps the length of a slug in characters.
+const maxSlugLen = 60
+
+// Slugify turns a topic into a name that is safe in object keys and
+// filenames: lowercased, with every run of characters other than letters
+// and digits (slashes, quotes, spaces, emoji, …) collapsed to a single
+// hyphen, trimmed of hyphens and cut to maxSlugLen characters. Letters
+// outside ASCII are kept. It returns "" if nothing usable is left.
+func Slugify(topic string) string {
+	var b strings.Builder
+	n, dash := 0, false
+	for _, c := range strings.ToLower(topic) {
+		if n >= maxSlugLen {
+			break
+		}
+		switch {
+		case unicode.IsLetter(c) || unicode.IsDigit(c):
+			b.WriteRune(c)
+			n++
+			dash = false
+		case n > 0 && !dash:
+			b.WriteByte('-')
+			n++
+			dash = true
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// asciiSlug keeps only the ASCII letters, digits and hyphens of slug.
+func asciiSlug(slug string) string {
+	var b strings.Builder
+	for _, c := range slug {
+		if c < unicode.MaxASCII && (c == '-' || unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			if c == '-' && (b.Len() == 0 || strings.HasSuffix(b.String(), "-")) {
+				continue
+			}
+			b.WriteRune(c)
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// downloadName returns the filename a document's file with extension ext
+// is downloaded as: the slugified topic, or the report word of its
+// language when the topic has no usable characters.
+func downloadName(doc *models.Document, ext string) string {
+	if slug := Slugify(doc.Topic); slug != "" {
+		return slug + "." + ext
+	}
+	return reportFilename(doc.Language, ext)
+}
+
+// contentDisposition builds an attachment header for a document's file.
+// Clients that understand RFC 5987 get the full UTF-8 name; the plain
+// filename parameter carries an ASCII fallback.
+func contentDisposition(doc *models.Document, ext string) string {
+	name := downloadName(doc, ext)
+	fallback := asciiSlug(strings.TrimSuffix(name, "."+ext))
+	if fallback == "" {
+		fallback = strings.TrimSuffix(reportFilename(doc.Language, ext), "."+ext)
+	}
+	fallback += "." + ext
+	if fallback == name {
+		return `attachment; filename="` + name + `"`
+	}
+	return `attachment; filename="` + fallback + `"; filename*=UTF-8''` + url.PathEscape(name)
+}
@@ -0,0 +1,55 @@
+package research
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct{ topic, want string }{
+		{"Solar Panels", "solar-panels"},
+		{"AC/DC: a history", "ac-dc-a-history"},
+		{"../../etc/passwd", "etc-passwd"},
+		{`"Quoted" \ topic`, "quoted-topic"},
+		{"Rocket 🚀 launches 🎉", "rocket-launches"},
+		{"🎉🎉🎉", ""},
+		{"Énergie solaire", "énergie-solaire"},
+		{"  tabs\tand\nnewlines  ", "tabs-and-newlines"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.topic); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestSlugifyLongTopic(t *testing.T) {
+	got := Slugify(strings.Repeat("very long topic ", 20))
+	if n := utf8.RuneCountInString(got); n > maxSlugLen {
+		t.Errorf("slug has %d characters, want at most %d", n, maxSlugLen)
+	}
+	if strings.HasSuffix(got, "-") || !strings.HasPrefix(got, "very-long-topic-very") {
+		t.Errorf("slug = %q", got)
+	}
+	if got := Slugify(strings.Repeat("é", 100)); utf8.RuneCountInString(got) != maxSlugLen {
+		t.Errorf("non-ASCII slug = %q, want %d characters", got, maxSlugLen)
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct{ topic, want string }{
+		{"Solar panels", `attachment; filename="solar-panels.pdf"`},
+		{"Énergie solaire", `attachment; filename="nergie-solaire.pdf"; filename*=UTF-8''%C3%A9nergie-solaire.pdf`},
+		{"太陽光", `attachment; filename="report.pdf"; filename*=UTF-8''%E5%A4%AA%E9%99%BD%E5%85%89.pdf`},
+		{"🎉", `attachment; filename="report.pdf"`},
+	}
+	for _, tt := range tests {
+		if got := contentDisposition(&models.Document{Topic: tt.topic, Language: "en"}, "pdf"); got != tt.want {
+			t.Errorf("%q:\n got %s\nwant %s", tt.topic, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package research
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+const (
+	maxSourceURLLen   = 2048
+	maxSourceTitleLen = 500
+	maxSourceBodyLen  = 5000
+)
+
+// SourceID derives a stable identifier for a source from its URL, so the same
+// citation keeps its ID across fetches and re-runs.
+func SourceID(s models.Source) string {
+	key := s.Href
+	if key == "" {
+		key = s.Title
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
+
+// assignSourceIDs fills in missing source IDs in place.
+func assignSourceIDs(sources []models.Source) {
+	for i := range sources {
+		if sources[i].ID == "" {
+			sources[i].ID = SourceID(sources[i])
+		}
+	}
+}
+
+// validSourceURL reports whether s is an absolute http(s) URL.
+func validSourceURL(s string) bool {
+	if len(s) > maxSourceURLLen {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// AddSource appends a user-provided source to a finished document. The
+// report isn't changed unless the body asks to regenerate it, which takes
+// the same fields as the regenerate endpoint.
+func (h *Handler) AddSource(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		models.Source
+		Regenerate *regenerateRequest `json:"regenerate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	src := models.Source{
+		Title: strings.TrimSpace(req.Title),
+		Body:  strings.TrimSpace(req.Body),
+		Href:  strings.TrimSpace(req.Href),
+	}
+	if !validSourceURL(src.Href) {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "href must be an http or https URL")
+		return
+	}
+	if len(src.Title) > maxSourceTitleLen || len(src.Body) > maxSourceBodyLen {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "source title or body is too long")
+		return
+	}
+	if src.Title == "" {
+		src.Title = src.Href
+	}
+	src.ID = SourceID(src)
+	src.Credibility = scoreSourceCredibility(src, h.opts.CredibleDomains)
+
+	h.editSources(w, r, req.Regenerate, func(sources []models.Source) ([]models.Source, *sourceEditError) {
+		for _, s := range sources {
+			if s.ID == src.ID || normalizeHref(s.Href) == normalizeHref(src.Href) {
+				return nil, &sourceEditError{http.StatusConflict, apierror.Conflict, "source already exists"}
+			}
+		}
+		return append(sources, src), nil
+	})
+}
+
+// RemoveSource removes the source at the given index of a finished
+// document's sources. Like AddSource, it can regenerate the report.
+func (h *Handler) RemoveSource(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.Atoi(chi.URLParam(r, "index"))
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid source index")
+		return
+	}
+	var req struct {
+		Regenerate *regenerateRequest `json:"regenerate"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+			return
+		}
+	}
+
+	h.editSources(w, r, req.Regenerate, func(sources []models.Source) ([]models.Source, *sourceEditError) {
+		if index < 0 || index >= len(sources) {
+			return nil, &sourceEditError{http.StatusNotFound, apierror.NotFound, "source not found"}
+		}
+		return append(sources[:index:index], sources[index+1:]...), nil
+	})
+}
+
+// sourceEditError rejects a change to a document's sources.
+type sourceEditError struct {
+	status int
+	code   apierror.Code
+	msg    string
+}
+
+// editSources applies edit to the sources of the requested document and
+// stores the result, unless edit returns an error to reject the change.
+// With regen set, the report is then regenerated and the accepted document
+// returned; otherwise the updated sources are.
+func (h *Handler) editSources(w http.ResponseWriter, r *http.Request, regen *regenerateRequest,
+	edit func([]models.Source) ([]models.Source, *sourceEditError)) {
+	if regen != nil && h.rejectIfDraining(w) {
+		return
+	}
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	docID := doc.ID.Hex()
+	release, ok := h.lockDoc(w, r, docID)
+	if !ok {
+		return
+	}
+	// Read the sources again under the lock: an edit that held it before
+	// us may have changed them since.
+	if doc, ok = h.ownedDoc(w, r); !ok {
+		release()
+		return
+	}
+	if doc.Status == models.StatusPending || doc.Status == models.StatusRunning {
+		release()
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research is still running")
+		return
+	}
+
+	assignSourceIDs(doc.Sources)
+	sources, eerr := edit(doc.Sources)
+	if eerr != nil {
+		release()
+		apierror.Write(w, eerr.status, eerr.code, eerr.msg)
+		return
+	}
+	if sources == nil {
+		sources = []models.Source{}
+	}
+	err := h.mongo.SetSources(r.Context(), docID, sources)
+	// regenerate takes the lock itself.
+	release()
+	if err != nil {
+		log.Printf("set sources %s: %v", docID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to update sources")
+		return
+	}
+
+	doc.Sources = sources
+	if regen != nil {
+		h.regenerate(w, r, doc, *regen)
+		return
+	}
+	writeJSON(w, http.StatusOK, sources)
+}
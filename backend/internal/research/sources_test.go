@@ -0,0 +1,164 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+	"github.com/ayush/research-ai-agent/backend/internal/storetest"
+)
+
+func (e *testEnv) sources(t *testing.T, id string) []models.Source {
+	t.Helper()
+	doc, err := e.docs.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.Sources
+}
+
+func TestAddSource(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	add := func(body interface{}) int {
+		t.Helper()
+		return serve(env.h.AddSource, newRequest(t, http.MethodPost, "/research/"+id+"/sources", "user-a", body, "id", id)).Code
+	}
+
+	paper := models.Source{Title: " Perovskite cells ", Body: "A new kind of solar cell.", Href: "https://example.net/perovskite"}
+	if code := add(paper); code != http.StatusOK {
+		t.Fatalf("add: got %d, want 200", code)
+	}
+	got := env.sources(t, id)
+	if len(got) != len(doc.Sources)+1 {
+		t.Fatalf("got %d sources, want %d", len(got), len(doc.Sources)+1)
+	}
+	added := got[len(got)-1]
+	if added.Title != "Perovskite cells" || added.Href != paper.Href || added.ID != SourceID(added) {
+		t.Errorf("added source = %+v", added)
+	}
+	for _, s := range got {
+		if s.ID == "" {
+			t.Errorf("source %q has no ID", s.Href)
+		}
+	}
+
+	for name, tt := range map[string]struct {
+		body models.Source
+		code int
+	}{
+		"duplicate":      {models.Source{Href: "https://www.example.net/perovskite/"}, http.StatusConflict},
+		"no url":         {models.Source{Title: "Notes"}, http.StatusBadRequest},
+		"relative url":   {models.Source{Href: "/perovskite"}, http.StatusBadRequest},
+		"javascript":     {models.Source{Href: "javascript:alert(1)"}, http.StatusBadRequest},
+		"title too long": {models.Source{Href: "https://example.net/long", Title: string(make([]byte, maxSourceTitleLen+1))}, http.StatusBadRequest},
+	} {
+		if code := add(tt.body); code != tt.code {
+			t.Errorf("%s: got %d, want %d", name, code, tt.code)
+		}
+	}
+	if n := len(env.sources(t, id)); n != len(got) {
+		t.Errorf("rejected adds changed the sources: %d, want %d", n, len(got))
+	}
+}
+
+// readHook is a ResearchStore that runs hook once, right after the next
+// GetByID has taken its copy of the document.
+type readHook struct {
+	*storetest.ResearchStore
+	hook func()
+}
+
+func (s *readHook) GetByID(ctx context.Context, id string) (*models.Document, error) {
+	doc, err := s.ResearchStore.GetByID(ctx, id)
+	if hook := s.hook; hook != nil {
+		s.hook = nil
+		hook()
+	}
+	return doc, err
+}
+
+func TestConcurrentAddSourcesKeepBoth(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	_, rdb := newTestRedis(t)
+	docs := &readHook{ResearchStore: env.docs}
+	h := NewHandler(docs, env.files, env.ai.client(), env.latex.client(), Options{Locks: NewDocLocker(rdb, time.Minute)})
+	add := func(href string) int {
+		return serve(h.AddSource, newRequest(t, http.MethodPost, "/research/"+id+"/sources", "user-a",
+			models.Source{Title: "Added", Href: href}, "id", id)).Code
+	}
+
+	// The second add runs start to finish after the first has read the
+	// document but before it takes the lock.
+	var second int
+	docs.hook = func() { second = add("https://example.net/second") }
+	if code := add("https://example.net/first"); code != http.StatusOK || second != http.StatusOK {
+		t.Fatalf("adds: got %d and %d, want 200 for both", code, second)
+	}
+
+	got := env.sources(t, id)
+	if len(got) != len(doc.Sources)+2 {
+		t.Fatalf("got %d sources, want %d: one add was lost", len(got), len(doc.Sources)+2)
+	}
+}
+
+func TestRemoveSource(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	remove := func(index string) int {
+		t.Helper()
+		return serve(env.h.RemoveSource, newRequest(t, http.MethodDelete, "/research/"+id+"/sources/"+index, "user-a", nil,
+			"id", id, "index", index)).Code
+	}
+
+	for index, want := range map[string]int{
+		"-1": http.StatusNotFound,
+		"2":  http.StatusNotFound,
+		"x":  http.StatusBadRequest,
+	} {
+		if code := remove(index); code != want {
+			t.Errorf("index %s: got %d, want %d", index, code, want)
+		}
+	}
+	if code := remove("0"); code != http.StatusOK {
+		t.Fatalf("remove: got %d, want 200", code)
+	}
+	got := env.sources(t, id)
+	if len(got) != 1 || got[0].Href != doc.Sources[1].Href {
+		t.Errorf("sources = %+v, want only the second", got)
+	}
+	if code := remove("0"); code != http.StatusOK {
+		t.Fatalf("remove last: got %d, want 200", code)
+	}
+	if got := env.sources(t, id); got == nil || len(got) != 0 {
+		t.Errorf("sources = %v, want an empty list", got)
+	}
+}
+
+func TestAddSourceRegenerates(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+
+	w := serve(env.h.AddSource, newRequest(t, http.MethodPost, "/research/"+id+"/sources", "user-a", map[string]interface{}{
+		"href":       "https://example.net/perovskite",
+		"regenerate": map[string]interface{}{"api_key": "sk-test", "overwrite": true},
+	}, "id", id))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("got %d %s, want 202", w.Code, w.Body)
+	}
+	env.wait(t)
+	reports := env.ai.bodies["/api/generate-report"]
+	if len(reports) != 2 {
+		t.Fatalf("generate-report called %d times, want 2", len(reports))
+	}
+	if sent, _ := reports[1]["sources"].([]interface{}); len(sent) != len(doc.Sources)+1 {
+		t.Errorf("regenerated with %d sources, want %d", len(sent), len(doc.Sources)+1)
+	}
+}
@@ -0,0 +1,25 @@
+package research
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+)
+
+// UserStats returns dashboard totals for the current user's reports: how
+// many there are, per model, the storage their files use and when the
+// latest was created. Documents in the trash are left out.
+func (h *Handler) UserStats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	stats, err := h.mongo.StatsByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("stats for %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "database error")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
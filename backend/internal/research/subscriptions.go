@@ -0,0 +1,323 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Subscription limits.
+const (
+	minSubscriptionHours = 1
+	maxSubscriptionHours = 30 * 24
+	// subscriptionLease is how long a claimed subscription is hidden from
+	// other workers while it runs.
+	subscriptionLease = 30 * time.Minute
+)
+
+// SubscriptionStore persists topic subscriptions and their seen URLs.
+type SubscriptionStore interface {
+	InsertSubscription(ctx context.Context, sub *models.Subscription) error
+	ListSubscriptions(ctx context.Context, userID string) ([]models.Subscription, error)
+	DeleteSubscription(ctx context.Context, userID, id string) error
+	DeleteUserSubscriptions(ctx context.Context, userID string) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.Subscription, error)
+	RecordRun(ctx context.Context, id primitive.ObjectID, seen []string, ranAt, next time.Time) error
+}
+
+// EmailSender delivers plain-text notification emails.
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// UserLookup resolves a user ID to the account, for notification addresses.
+type UserLookup interface {
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+}
+
+// CreateSubscription subscribes the current user to periodic searches of a
+// topic.
+func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	if h.isGuest(userID) {
+		// Subscriptions outlive guests, whose data is swept.
+		apierror.Write(w, http.StatusForbidden, apierror.Forbidden, "sign up to subscribe to topics")
+		return
+	}
+	var req models.SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	req.Topic = strings.TrimSpace(req.Topic)
+	if req.Topic == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "topic is required")
+		return
+	}
+	if req.IntervalHours < minSubscriptionHours || req.IntervalHours > maxSubscriptionHours {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("interval_hours must be between %d and %d",
+			minSubscriptionHours, maxSubscriptionHours))
+		return
+	}
+	if req.MinCredibility < 0 || req.MinCredibility > 1 {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "min_credibility must be between 0 and 1")
+		return
+	}
+	if req.WebhookURL != "" {
+		// Webhooks are signed and delivered like callbacks.
+		if h.opts.Callbacks == nil {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "webhooks are not enabled on this server")
+			return
+		}
+		if !validCallbackURL(req.WebhookURL) {
+			apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "webhook_url must be an http(s) URL")
+			return
+		}
+	}
+	if !req.NotifyEmail && req.WebhookURL == "" && !req.AutoReport {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "enable notify_email, webhook_url or auto_report")
+		return
+	}
+	if key, err := h.storedAPIKey(r.Context(), userID); err != nil || key == "" {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "subscriptions need a stored api key")
+		return
+	}
+	if _, ok := DepthConfig[req.Depth]; !ok {
+		req.Depth = "Standard"
+	}
+
+	sub := &models.Subscription{
+		UserID:         userID,
+		Topic:          req.Topic,
+		Depth:          req.Depth,
+		IntervalHours:  req.IntervalHours,
+		MinCredibility: req.MinCredibility,
+		NotifyEmail:    req.NotifyEmail,
+		WebhookURL:     req.WebhookURL,
+		AutoReport:     req.AutoReport,
+		// The first run only records what's already out there.
+		NextRunAt: time.Now(),
+	}
+	if err := h.opts.Subscriptions.InsertSubscription(r.Context(), sub); err != nil {
+		log.Printf("insert subscription: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to save subscription")
+		return
+	}
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+// ListSubscriptions returns the current user's subscriptions.
+func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	subs, err := h.opts.Subscriptions.ListSubscriptions(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "database error")
+		return
+	}
+	if subs == nil {
+		subs = []models.Subscription{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]models.Subscription{"subscriptions": subs})
+}
+
+// DeleteSubscription cancels one of the current user's subscriptions.
+func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	err := h.opts.Subscriptions.DeleteSubscription(r.Context(), userID, chi.URLParam(r, "id"))
+	if errors.Is(err, models.ErrSubscriptionNotFound) {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "delete failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "deleted"})
+}
+
+// RunSubscriptions processes due subscriptions every interval until ctx is
+// done. A non-positive interval disables them.
+func (h *Handler) RunSubscriptions(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		log.Printf("subscription poll interval %v: subscriptions disabled", interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.runDueSubscriptions(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *Handler) runDueSubscriptions(ctx context.Context) {
+	for ctx.Err() == nil {
+		sub, err := h.opts.Subscriptions.ClaimDue(ctx, time.Now(), subscriptionLease)
+		if err != nil {
+			log.Printf("claim subscription: %v", err)
+			return
+		}
+		if sub == nil {
+			return
+		}
+		h.runSubscription(ctx, sub)
+	}
+}
+
+// runSubscription re-searches a subscription's topic and acts on sources
+// that weren't seen in earlier runs.
+func (h *Handler) runSubscription(ctx context.Context, sub *models.Subscription) {
+	id := sub.ID.Hex()
+	ranAt := time.Now()
+	next := ranAt.Add(time.Duration(sub.IntervalHours) * time.Hour)
+
+	apiKey, err := h.storedAPIKey(ctx, sub.UserID)
+	if err != nil || apiKey == "" {
+		log.Printf("subscription %s: no stored api key, skipping", id)
+		h.recordSubscriptionRun(ctx, sub, nil, ranAt, next)
+		return
+	}
+	req := models.CreateRequest{
+		Topic:          sub.Topic,
+		Model:          DefaultModel,
+		Depth:          sub.Depth,
+		APIKey:         apiKey,
+		Dedup:          h.opts.DedupMode,
+		CitationStyle:  DefaultCitationStyle,
+		Language:       DefaultLanguage,
+		MinCredibility: sub.MinCredibility,
+	}
+	if model, substituted := h.opts.ModelHealth.Resolve(ctx, req.Model); substituted {
+		req.Model = model
+	}
+	_, sources, serr := h.collectSources(ctx, req, &DebugCapture{}, func(string) {})
+	if serr != nil {
+		log.Printf("subscription %s: %s", id, serr.msg)
+		h.recordSubscriptionRun(ctx, sub, nil, ranAt, next)
+		return
+	}
+
+	fresh := newSources(sub.SeenURLs, sources)
+	seen := make([]string, 0, len(fresh))
+	for _, s := range fresh {
+		seen = append(seen, normalizeHref(s.Href))
+	}
+	h.recordSubscriptionRun(ctx, sub, seen, ranAt, next)
+
+	// The first run only establishes the baseline.
+	if sub.LastRunAt.IsZero() || len(fresh) == 0 {
+		return
+	}
+	h.notifySubscriber(ctx, sub, fresh)
+	if sub.AutoReport {
+		h.startUpdateReport(ctx, sub, req)
+	}
+}
+
+func (h *Handler) recordSubscriptionRun(ctx context.Context, sub *models.Subscription, seen []string, ranAt, next time.Time) {
+	if err := h.opts.Subscriptions.RecordRun(ctx, sub.ID, seen, ranAt, next); err != nil {
+		log.Printf("record subscription run %s: %v", sub.ID.Hex(), err)
+	}
+}
+
+// newSources returns the sources whose normalized URLs are not in seen,
+// keeping the first of any that share a URL.
+func newSources(seen []string, sources []models.Source) []models.Source {
+	known := make(map[string]bool, len(seen)+len(sources))
+	for _, u := range seen {
+		known[u] = true
+	}
+	var fresh []models.Source
+	for _, s := range sources {
+		key := normalizeHref(s.Href)
+		if key == "" || known[key] {
+			continue
+		}
+		known[key] = true
+		fresh = append(fresh, s)
+	}
+	return fresh
+}
+
+// notifySubscriber sends the new sources by email and/or webhook, as the
+// subscription asks. Failures are logged; the sources stay marked as seen.
+func (h *Handler) notifySubscriber(ctx context.Context, sub *models.Subscription, fresh []models.Source) {
+	id := sub.ID.Hex()
+	if sub.NotifyEmail && h.opts.Email != nil && h.opts.Users != nil {
+		user, err := h.opts.Users.GetUserByID(ctx, sub.UserID)
+		if err == nil && user != nil {
+			var body strings.Builder
+			fmt.Fprintf(&body, "%d new sources were found for %q:\r\n\r\n", len(fresh), sub.Topic)
+			for _, s := range fresh {
+				fmt.Fprintf(&body, "- %s\r\n  %s\r\n", s.Title, s.Href)
+			}
+			subject := "New sources for " + strings.Join(strings.Fields(sub.Topic), " ")
+			err = h.opts.Email.Send(ctx, user.Email, subject, body.String())
+		}
+		if err != nil {
+			log.Printf("subscription %s email: %v", id, err)
+		}
+	}
+	if sub.WebhookURL != "" {
+		if h.opts.Callbacks == nil {
+			log.Printf("subscription %s webhook: callbacks are disabled", id)
+			return
+		}
+		payload, _ := json.Marshal(map[string]interface{}{
+			"subscription_id": id,
+			"topic":           sub.Topic,
+			"sources":         fresh,
+		})
+		if err := h.opts.Callbacks.deliver(ctx, sub.WebhookURL, payload); err != nil {
+			log.Printf("subscription %s webhook: %v", id, err)
+		}
+	}
+}
+
+// startUpdateReport starts a regular research run for the subscription's
+// topic so the user gets a report covering the new sources.
+func (h *Handler) startUpdateReport(ctx context.Context, sub *models.Subscription, req models.CreateRequest) {
+	req.Length, req.TargetWords, req.TargetSections = resolveLength("", 0, 0)
+	doc := &models.Document{
+		UserID:         sub.UserID,
+		Topic:          req.Topic,
+		ModelUsed:      req.Model,
+		Dedup:          req.Dedup,
+		CitationStyle:  req.CitationStyle,
+		Length:         req.Length,
+		TargetWords:    req.TargetWords,
+		TargetSections: req.TargetSections,
+		Language:       req.Language,
+		Status:         models.StatusPending,
+	}
+	docID, err := h.mongo.Insert(ctx, doc)
+	if err != nil {
+		log.Printf("subscription %s update report: %v", sub.ID.Hex(), err)
+		return
+	}
+	h.startPipeline(job{docID: docID, doc: doc, req: req})
+}
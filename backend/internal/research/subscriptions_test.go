@@ -0,0 +1,323 @@
+package research
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// memSubs is an in-memory SubscriptionStore.
+type memSubs struct {
+	mu   sync.Mutex
+	subs []*models.Subscription
+}
+
+func (m *memSubs) InsertSubscription(ctx context.Context, sub *models.Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub.ID = primitive.NewObjectID()
+	sub.CreatedAt = time.Now()
+	cp := *sub
+	m.subs = append(m.subs, &cp)
+	return nil
+}
+
+func (m *memSubs) ListSubscriptions(ctx context.Context, userID string) ([]models.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.Subscription
+	for _, s := range m.subs {
+		if s.UserID == userID {
+			out = append(out, *s)
+		}
+	}
+	return out, nil
+}
+
+func (m *memSubs) DeleteSubscription(ctx context.Context, userID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.subs {
+		if s.ID.Hex() == id && s.UserID == userID {
+			m.subs = append(m.subs[:i], m.subs[i+1:]...)
+			return nil
+		}
+	}
+	return models.ErrSubscriptionNotFound
+}
+
+func (m *memSubs) DeleteUserSubscriptions(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs = slices.DeleteFunc(m.subs, func(s *models.Subscription) bool { return s.UserID == userID })
+	return nil
+}
+
+func (m *memSubs) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.subs {
+		if !s.NextRunAt.After(now) {
+			s.NextRunAt = now.Add(lease)
+			cp := *s
+			cp.SeenURLs = slices.Clone(s.SeenURLs)
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memSubs) RecordRun(ctx context.Context, id primitive.ObjectID, seen []string, ranAt, next time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.subs {
+		if s.ID == id {
+			s.SeenURLs = append(s.SeenURLs, seen...)
+			s.LastRunAt, s.NextRunAt = ranAt, next
+		}
+	}
+	return nil
+}
+
+// due makes every subscription due to run now.
+func (m *memSubs) due() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.subs {
+		s.NextRunAt = time.Now().Add(-time.Second)
+	}
+}
+
+// sentEmails records notification emails.
+type sentEmails struct {
+	mu   sync.Mutex
+	sent []string // "to: subject"
+}
+
+func (e *sentEmails) Send(ctx context.Context, to, subject, body string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sent = append(e.sent, to+": "+subject)
+	return nil
+}
+
+type fixedUsers map[string]string // user ID to email
+
+func (u fixedUsers) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	return &models.User{Email: u[id]}, nil
+}
+
+func TestNewSources(t *testing.T) {
+	sources := []models.Source{
+		{Href: "https://example.edu/solar"},
+		{Href: "https://www.example.edu/solar/?utm_source=feed"}, // same page as the first
+		{Href: "https://example.org/cost"},
+		{Href: ""},
+		{Href: "https://example.net/new"},
+	}
+	tests := []struct {
+		name string
+		seen []string
+		want []string
+	}{
+		{"nothing seen", nil, []string{"https://example.edu/solar", "https://example.org/cost", "https://example.net/new"}},
+		{"some seen", []string{"example.edu/solar", "example.org/cost"}, []string{"https://example.net/new"}},
+		{"all seen", []string{"example.edu/solar", "example.org/cost", "example.net/new"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, s := range newSources(tt.seen, sources) {
+				got = append(got, s.Href)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newSubscriptionEnv(t *testing.T) (*testEnv, *memSubs, *sentEmails) {
+	subs, emails := &memSubs{}, &sentEmails{}
+	callbacks := NewCallbacks("s3cret", RetryPolicy{})
+	allowLoopback(callbacks.client)
+	env := newTestEnv(t, &Options{
+		Subscriptions: subs,
+		Email:         emails,
+		Users:         fixedUsers{"user-a": "a@example.com"},
+		APIKeys:       storedKeys{"user-a": "sk-stored"},
+		Callbacks:     callbacks,
+	})
+	return env, subs, emails
+}
+
+func TestSubscriptionHandlers(t *testing.T) {
+	env, subs, _ := newSubscriptionEnv(t)
+
+	w := serve(env.h.CreateSubscription, newRequest(t, http.MethodPost, "/research/subscriptions", "user-a",
+		models.SubscriptionRequest{Topic: " solar panels ", IntervalHours: 24, NotifyEmail: true}))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d %s, want 201", w.Code, w.Body)
+	}
+	var created models.Subscription
+	decode(t, w, &created)
+	if created.Topic != "solar panels" || created.Depth != "Standard" || created.UserID != "user-a" {
+		t.Errorf("created %+v", created)
+	}
+
+	for name, req := range map[string]models.SubscriptionRequest{
+		"no topic":       {IntervalHours: 24, NotifyEmail: true},
+		"interval zero":  {Topic: "t", NotifyEmail: true},
+		"interval long":  {Topic: "t", IntervalHours: maxSubscriptionHours + 1, NotifyEmail: true},
+		"credibility":    {Topic: "t", IntervalHours: 24, NotifyEmail: true, MinCredibility: 2},
+		"webhook scheme": {Topic: "t", IntervalHours: 24, WebhookURL: "ftp://example.com/hook"},
+		"webhook host":   {Topic: "t", IntervalHours: 24, WebhookURL: "https:///hook"},
+		"no action":      {Topic: "t", IntervalHours: 24},
+	} {
+		w := serve(env.h.CreateSubscription, newRequest(t, http.MethodPost, "/research/subscriptions", "user-a", req))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", name, w.Code)
+		}
+	}
+	env.h.opts.Callbacks = nil
+	w = serve(env.h.CreateSubscription, newRequest(t, http.MethodPost, "/research/subscriptions", "user-a",
+		models.SubscriptionRequest{Topic: "t", IntervalHours: 24, WebhookURL: "https://example.com/hook"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("webhook with callbacks disabled: got %d, want 400", w.Code)
+	}
+	w = serve(env.h.CreateSubscription, newRequest(t, http.MethodPost, "/research/subscriptions", "user-b",
+		models.SubscriptionRequest{Topic: "t", IntervalHours: 24, NotifyEmail: true}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("no stored api key: got %d, want 400", w.Code)
+	}
+
+	list := func(userID string) []models.Subscription {
+		t.Helper()
+		w := serve(env.h.ListSubscriptions, newRequest(t, http.MethodGet, "/research/subscriptions", userID, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: got %d %s, want 200", w.Code, w.Body)
+		}
+		var got map[string][]models.Subscription
+		decode(t, w, &got)
+		return got["subscriptions"]
+	}
+	if got := list("user-a"); len(got) != 1 || got[0].ID != created.ID {
+		t.Errorf("user-a lists %+v, want the created subscription", got)
+	}
+	if got := list("user-b"); got == nil || len(got) != 0 {
+		t.Errorf("user-b lists %+v, want an empty list", got)
+	}
+
+	id := created.ID.Hex()
+	w = serve(env.h.DeleteSubscription, newRequest(t, http.MethodDelete, "/research/subscriptions/"+id, "user-b", nil, "id", id))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("delete as another user: got %d, want 404", w.Code)
+	}
+	w = serve(env.h.DeleteSubscription, newRequest(t, http.MethodDelete, "/research/subscriptions/"+id, "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Errorf("delete: got %d %s, want 200", w.Code, w.Body)
+	}
+	if len(subs.subs) != 0 {
+		t.Errorf("%d subscriptions left after delete", len(subs.subs))
+	}
+}
+
+func TestRunSubscriptionNotifiesOnNewSources(t *testing.T) {
+	env, subs, emails := newSubscriptionEnv(t)
+	var mu sync.Mutex
+	var hooks [][]models.Source
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != env.h.opts.Callbacks.Sign(body) {
+			t.Errorf("webhook signature %q does not match its body", r.Header.Get(SignatureHeader))
+		}
+		var payload struct {
+			Sources []models.Source `json:"sources"`
+		}
+		json.Unmarshal(body, &payload)
+		mu.Lock()
+		hooks = append(hooks, payload.Sources)
+		mu.Unlock()
+	}))
+	t.Cleanup(hook.Close)
+	ctx := context.Background()
+	subs.InsertSubscription(ctx, &models.Subscription{
+		UserID: "user-a", Topic: "solar panels", Depth: "Standard", IntervalHours: 24,
+		NotifyEmail: true, WebhookURL: hook.URL, AutoReport: true,
+	})
+
+	// The first run records a baseline without notifying.
+	env.h.runDueSubscriptions(ctx)
+	if len(emails.sent) != 0 || len(hooks) != 0 {
+		t.Fatalf("baseline run notified: %d emails, %d webhooks", len(emails.sent), len(hooks))
+	}
+	if got := subs.subs[0].SeenURLs; len(got) != 2 {
+		t.Fatalf("seen after baseline = %v, want 2 URLs", got)
+	}
+	if next := subs.subs[0].NextRunAt; time.Until(next) < 23*time.Hour {
+		t.Errorf("next run at %v, want a day out", next)
+	}
+
+	// Not due yet: nothing runs.
+	env.h.runDueSubscriptions(ctx)
+	if n := env.ai.called("/api/search"); n != 1 {
+		t.Fatalf("searched %d times, want 1", n)
+	}
+
+	// Nothing new: no notification.
+	subs.due()
+	env.h.runDueSubscriptions(ctx)
+	if len(emails.sent) != 0 || len(hooks) != 0 {
+		t.Fatalf("run without new sources notified: %d emails, %d webhooks", len(emails.sent), len(hooks))
+	}
+
+	fresh := models.Source{Title: "Perovskite cells", Body: "A new kind of solar cell that could make panels cheaper.", Href: "https://example.net/perovskite"}
+	env.ai.sources = append(env.ai.sources, fresh)
+	subs.due()
+	env.h.runDueSubscriptions(ctx)
+	env.wait(t)
+
+	if len(emails.sent) != 1 || emails.sent[0] != "a@example.com: New sources for solar panels" {
+		t.Errorf("emails = %v, want one to a@example.com", emails.sent)
+	}
+	if len(hooks) != 1 || len(hooks[0]) != 1 || hooks[0][0].Href != fresh.Href {
+		t.Errorf("webhooks = %+v, want one with the new source", hooks)
+	}
+	if got := subs.subs[0].SeenURLs; len(got) != 3 || got[2] != "example.net/perovskite" {
+		t.Errorf("seen = %v, want the new URL added", got)
+	}
+	docs, _, _ := env.docs.ListByUser(ctx, "user-a", models.ListOptions{Limit: 10})
+	if len(docs) != 1 || docs[0].Status != models.StatusComplete {
+		t.Errorf("auto report: got %d documents, want one complete", len(docs))
+	}
+}
+
+func TestRunSubscriptionsWithoutInterval(t *testing.T) {
+	env, subs, _ := newSubscriptionEnv(t)
+	subs.InsertSubscription(context.Background(), &models.Subscription{
+		UserID: "user-a", Topic: "solar panels", IntervalHours: 24, NotifyEmail: true,
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		env.h.RunSubscriptions(context.Background(), 0)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunSubscriptions with a zero interval did not return")
+	}
+	if n := env.ai.called("/api/search"); n != 0 {
+		t.Errorf("searched %d times with subscriptions disabled", n)
+	}
+}
@@ -0,0 +1,145 @@
+package research
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Tag limits.
+const (
+	maxTagLen     = 32
+	maxTags       = 20
+	maxBulkTagIDs = 100
+)
+
+// normalizeTags lowercases and trims tags, collapses inner whitespace to a
+// single dash, and drops empties and duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		t = strings.Join(strings.Fields(strings.ToLower(t)), "-")
+		if t == "" || seen[t] {
+			continue
+		}
+		if len(t) > maxTagLen {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", t, maxTagLen)
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// BulkTag adds and removes tags across several of the user's documents.
+// IDs the user doesn't own, and trashed documents, are reported as not found
+// and left untouched.
+func (h *Handler) BulkTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IDs    []string `json:"ids"`
+		Add    []string `json:"add"`
+		Remove []string `json:"remove"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	if len(req.IDs) == 0 {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "ids is required")
+		return
+	}
+	if len(req.IDs) > maxBulkTagIDs {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("at most %d ids per request", maxBulkTagIDs))
+		return
+	}
+	add, err := normalizeTags(req.Add)
+	if err == nil {
+		req.Remove, err = normalizeTags(req.Remove)
+	}
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+	if len(add) == 0 && len(req.Remove) == 0 {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "add or remove is required")
+		return
+	}
+
+	updated, err := h.mongo.BulkUpdateTags(r.Context(), userID, req.IDs, add, req.Remove)
+	if err != nil {
+		log.Printf("bulk tag for %s: %v", userID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to update tags")
+		return
+	}
+
+	results := make(map[string]string, len(req.IDs))
+	for _, id := range req.IDs {
+		results[id] = "not_found"
+	}
+	for _, id := range updated {
+		results[id] = "updated"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+// SetTags replaces the tags of one of the user's documents.
+func (h *Handler) SetTags(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid request body")
+		return
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, err.Error())
+		return
+	}
+	if len(tags) > maxTags {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, fmt.Sprintf("at most %d tags per document", maxTags))
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if _, ok := h.ownedDoc(w, r); !ok {
+		return
+	}
+	if err := h.mongo.SetTags(r.Context(), id, tags); err != nil {
+		log.Printf("set tags %s: %v", id, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to update tags")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"tags": tags})
+}
+
+// Tags returns each tag the user has applied with the number of documents
+// carrying it.
+func (h *Handler) Tags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	counts, err := h.mongo.TagsByUser(r.Context(), userID)
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "database error")
+		return
+	}
+	if counts == nil {
+		counts = []models.TagCount{}
+	}
+	writeJSON(w, http.StatusOK, counts)
+}
@@ -0,0 +1,129 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := normalizeTags([]string{" Energy ", "solar   power", "energy", "", "  ", "GRID"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"energy", "solar-power", "grid"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err := normalizeTags([]string{strings.Repeat("x", maxTagLen+1)}); err == nil {
+		t.Error("overlong tag accepted")
+	}
+}
+
+func TestBulkTagOnlyOwnedDocuments(t *testing.T) {
+	env := newTestEnv(t, nil)
+	mine := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar", Tags: []string{"draft"}})
+	also := env.insert(t, &models.Document{UserID: "user-a", Topic: "wind"})
+	foreign := env.insert(t, &models.Document{UserID: "user-b", Topic: "hydro", Tags: []string{"draft"}})
+	const missing = "000000000000000000000000"
+
+	w := serve(env.h.BulkTag, newRequest(t, http.MethodPost, "/research/bulk-tag", "user-a", map[string][]string{
+		"ids": {mine, also, foreign, missing}, "add": {"Energy"}, "remove": {"DRAFT"},
+	}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var resp struct {
+		Results map[string]string `json:"results"`
+	}
+	decode(t, w, &resp)
+	want := map[string]string{mine: "updated", also: "updated", foreign: "not_found", missing: "not_found"}
+	for id, status := range want {
+		if resp.Results[id] != status {
+			t.Errorf("result for %s = %q, want %q", id, resp.Results[id], status)
+		}
+	}
+
+	for id, tags := range map[string][]string{mine: {"energy"}, also: {"energy"}, foreign: {"draft"}} {
+		doc, err := env.docs.GetByID(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(doc.Tags, tags) {
+			t.Errorf("tags of %s = %v, want %v", doc.Topic, doc.Tags, tags)
+		}
+	}
+}
+
+func TestBulkTagValidates(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar"})
+	tooMany := make([]string, maxBulkTagIDs+1)
+	for i := range tooMany {
+		tooMany[i] = id
+	}
+
+	for name, body := range map[string]interface{}{
+		"no ids":        map[string][]string{"add": {"energy"}},
+		"too many":      map[string][]string{"ids": tooMany, "add": {"energy"}},
+		"no changes":    map[string][]string{"ids": {id}, "add": {"  "}},
+		"long tag":      map[string][]string{"ids": {id}, "add": {strings.Repeat("x", maxTagLen+1)}},
+		"not an object": "nope",
+	} {
+		if w := serve(env.h.BulkTag, newRequest(t, http.MethodPost, "/research/bulk-tag", "user-a", body)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", name, w.Code)
+		}
+	}
+}
+
+func TestTagsSetWhileRunningAreKept(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id, finish := env.startBlocked(t, "user-a")
+
+	w := serve(env.h.SetTags, newRequest(t, http.MethodPut, "/research/"+id+"/tags", "user-a",
+		map[string][]string{"tags": {"Energy", "solar power"}}, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("set tags: got %d %s, want 200", w.Code, w.Body)
+	}
+	w = serve(env.h.BulkTag, newRequest(t, http.MethodPost, "/research/bulk-tag", "user-a",
+		map[string][]string{"ids": {id}, "add": {"draft"}}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("bulk tag: got %d %s, want 200", w.Code, w.Body)
+	}
+	finish()
+
+	doc, err := env.docs.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"energy", "solar-power", "draft"}; !slices.Equal(doc.Tags, want) {
+		t.Errorf("tags after the run = %v, want %v", doc.Tags, want)
+	}
+}
+
+func TestBulkTagSkipsTrashedAndForeignDocuments(t *testing.T) {
+	env := newTestEnv(t, nil)
+	live := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels"})
+	trashed := env.insert(t, &models.Document{UserID: "user-a", Topic: "solar panels"})
+	env.trash(t, trashed)
+	foreign := env.insert(t, &models.Document{UserID: "user-b", Topic: "solar panels"})
+
+	w := serve(env.h.BulkTag, newRequest(t, http.MethodPost, "/research/bulk-tag", "user-a",
+		map[string][]string{"ids": {live, trashed, foreign}, "add": {"energy"}}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	var resp struct {
+		Results map[string]string `json:"results"`
+	}
+	decode(t, w, &resp)
+	want := map[string]string{live: "updated", trashed: "not_found", foreign: "not_found"}
+	for id, status := range want {
+		if resp.Results[id] != status {
+			t.Errorf("result for %s = %q, want %q", id, resp.Results[id], status)
+		}
+	}
+}
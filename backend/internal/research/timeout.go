@@ -0,0 +1,86 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StepTimeouts bound how long each pipeline step may take. Compile covers
+// both the PDF and the .tex compilation.
+type StepTimeouts struct {
+	Queries time.Duration
+	Search  time.Duration
+	Report  time.Duration
+	Compile time.Duration
+}
+
+// DefaultStepTimeouts apply to any step Options leaves at zero. Writing
+// the report is by far the slowest step.
+var DefaultStepTimeouts = StepTimeouts{
+	Queries: time.Minute,
+	Search:  2 * time.Minute,
+	Report:  5 * time.Minute,
+	Compile: 2 * time.Minute,
+}
+
+// withDefaults fills in the zero fields of t from DefaultStepTimeouts.
+func (t StepTimeouts) withDefaults() StepTimeouts {
+	d := DefaultStepTimeouts
+	if t.Queries <= 0 {
+		t.Queries = d.Queries
+	}
+	if t.Search <= 0 {
+		t.Search = d.Search
+	}
+	if t.Report <= 0 {
+		t.Report = d.Report
+	}
+	if t.Compile <= 0 {
+		t.Compile = d.Compile
+	}
+	return t
+}
+
+func (t StepTimeouts) forStep(step string) time.Duration {
+	switch step {
+	case StepGeneratingQueries:
+		return t.Queries
+	case StepSearching:
+		return t.Search
+	case StepWritingReport:
+		return t.Report
+	case StepCompilingPDF, StepCompilingTex:
+		return t.Compile
+	}
+	return 0
+}
+
+// stepNames describe steps in timeout messages.
+var stepNames = map[string]string{
+	StepGeneratingQueries: "Generating search queries",
+	StepSearching:         "Web search",
+	StepWritingReport:     "Report generation",
+	StepCompilingPDF:      "PDF compilation",
+	StepCompilingTex:      "TeX compilation",
+}
+
+// stepContext derives the context a step's calls run under.
+func (h *Handler) stepContext(ctx context.Context, step string) (context.Context, context.CancelFunc) {
+	if d := h.opts.StepTimeouts.forStep(step); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// stepTimedOut reports whether err is a step's own deadline, rather than
+// the whole job being cancelled.
+func stepTimedOut(parent context.Context, err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil
+}
+
+// timeoutMsg is the error shown when step ran out of time.
+func (h *Handler) timeoutMsg(step string) string {
+	return fmt.Sprintf("%s timed out after %s.", stepNames[step], h.opts.StepTimeouts.forStep(step))
+}
@@ -0,0 +1,38 @@
+package research
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestStepTimeout(t *testing.T) {
+	env := newTestEnv(t, &Options{StepTimeouts: StepTimeouts{Report: 50 * time.Millisecond}})
+	env.ai.block, env.ai.writing = make(chan struct{}), make(chan struct{})
+	t.Cleanup(func() { close(env.ai.block) })
+
+	start := time.Now()
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timed-out run took %v", elapsed)
+	}
+	if doc.Status != models.StatusFailed || doc.Step != StepWritingReport {
+		t.Fatalf("got status %q step %q, want failed while writing", doc.Status, doc.Step)
+	}
+	if want := "Report generation timed out after 50ms."; doc.Error != want {
+		t.Errorf("error = %q, want %q", doc.Error, want)
+	}
+}
+
+func TestStepTimeoutDefaults(t *testing.T) {
+	got := StepTimeouts{Search: 30 * time.Second}.withDefaults()
+	want := DefaultStepTimeouts
+	want.Search = 30 * time.Second
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got.forStep(StepCompilingTex) != got.Compile || got.forStep(StepSaving) != 0 {
+		t.Errorf("forStep maps steps wrongly: %+v", got)
+	}
+}
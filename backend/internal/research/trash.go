@@ -0,0 +1,93 @@
+package research
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// trashSweepBatch caps how many expired documents one sweep purges.
+const trashSweepBatch = 100
+
+// Restore takes a document back out of the trash.
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	doc, ok := h.ownedDocInTrash(w, r)
+	if !ok {
+		return
+	}
+	if doc.DeletedAt == nil {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "document is not in the trash")
+		return
+	}
+	if err := h.mongo.SetDeleted(r.Context(), id, nil); err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "restore failed")
+		return
+	}
+	doc.DeletedAt = nil
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// RunTrashSweeper purges documents that have been in the trash longer than
+// retention, checking every interval until ctx is cancelled.
+func (h *Handler) RunTrashSweeper(ctx context.Context, retention, interval time.Duration) {
+	if interval <= 0 {
+		log.Printf("trash sweep interval %v: trash sweeping disabled", interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.sweepTrash(ctx, time.Now().Add(-retention))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweepTrash purges documents trashed before cutoff.
+func (h *Handler) sweepTrash(ctx context.Context, cutoff time.Time) {
+	docs, err := h.mongo.DeletedBefore(ctx, cutoff, trashSweepBatch)
+	if err != nil {
+		log.Printf("sweep trash: %v", err)
+		return
+	}
+	if purged := h.purgeAll(ctx, docs, "sweep trash"); purged > 0 {
+		log.Printf("sweep trash: purged %d documents", purged)
+	}
+}
+
+// purgeAll purges docs one by one, skipping those locked by a running
+// operation and logging failures under what, and returns how many it
+// purged.
+func (h *Handler) purgeAll(ctx context.Context, docs []models.Document, what string) int {
+	purged := 0
+	for i := range docs {
+		id := docs[i].ID.Hex()
+		release := func() {}
+		if h.opts.Locks != nil {
+			unlock, ok, err := h.opts.Locks.TryLock(ctx, id)
+			if err != nil || !ok {
+				// Busy; the next sweep will pick it up.
+				continue
+			}
+			release = unlock
+		}
+		err := h.purge(ctx, &docs[i], id)
+		release()
+		if err != nil {
+			log.Printf("%s %s: %v", what, id, err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
@@ -0,0 +1,197 @@
+package research
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	keys := env.files.Keys()
+
+	list := func(handler http.HandlerFunc) []models.Document {
+		t.Helper()
+		w := serve(handler, newRequest(t, http.MethodGet, "/research", "user-a", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: got %d %s, want 200", w.Code, w.Body)
+		}
+		var got listResponse
+		decode(t, w, &got)
+		return got.Documents
+	}
+
+	w := serve(env.h.Delete, newRequest(t, http.MethodDelete, "/research/"+id, "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: got %d %s, want 200", w.Code, w.Body)
+	}
+	if got := list(env.h.List); len(got) != 0 {
+		t.Errorf("list shows %d trashed documents", len(got))
+	}
+	if got := list(env.h.Trash); len(got) != 1 || got[0].ID != doc.ID || got[0].DeletedAt == nil {
+		t.Errorf("trash = %+v, want the deleted document", got)
+	}
+	if got := env.files.Keys(); len(got) != len(keys) {
+		t.Errorf("files = %v after a soft delete, want %v", got, keys)
+	}
+
+	w = serve(env.h.Restore, newRequest(t, http.MethodPost, "/research/"+id+"/restore", "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: got %d %s, want 200", w.Code, w.Body)
+	}
+	if got := list(env.h.List); len(got) != 1 {
+		t.Errorf("list after restore shows %d documents, want 1", len(got))
+	}
+	if got := list(env.h.Trash); len(got) != 0 {
+		t.Errorf("trash after restore shows %d documents", len(got))
+	}
+	w = serve(env.h.Restore, newRequest(t, http.MethodPost, "/research/"+id+"/restore", "user-a", nil, "id", id))
+	if w.Code != http.StatusConflict {
+		t.Errorf("restoring a live document: got %d, want 409", w.Code)
+	}
+}
+
+func TestSweepTrash(t *testing.T) {
+	env := newTestEnv(t, nil)
+	ctx := context.Background()
+	old := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	recent := env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test"})
+	live := env.create(t, "user-a", models.CreateRequest{Topic: "tides", APIKey: "sk-test"})
+	longAgo, yesterday := time.Now().Add(-40*24*time.Hour), time.Now().Add(-24*time.Hour)
+	env.docs.SetDeleted(ctx, old.ID.Hex(), &longAgo)
+	env.docs.SetDeleted(ctx, recent.ID.Hex(), &yesterday)
+
+	env.h.sweepTrash(ctx, time.Now().Add(-30*24*time.Hour))
+
+	if _, err := env.docs.GetByID(ctx, old.ID.Hex()); err == nil {
+		t.Error("expired trash was not purged")
+	}
+	for _, doc := range []*models.Document{recent, live} {
+		if _, err := env.docs.GetByID(ctx, doc.ID.Hex()); err != nil {
+			t.Errorf("%s was purged: %v", doc.Topic, err)
+		}
+	}
+	for _, key := range env.files.Keys() {
+		if key == old.PDFObjectKey || key == old.TexObjectKey {
+			t.Errorf("file %s of the purged document is left", key)
+		}
+	}
+}
+
+func TestRunTrashSweeperWithoutInterval(t *testing.T) {
+	env := newTestEnv(t, nil)
+	done := make(chan struct{})
+	go func() {
+		env.h.RunTrashSweeper(context.Background(), time.Hour, 0)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunTrashSweeper with a zero interval did not return")
+	}
+}
+
+// trash moves the document id to the trash directly in the store.
+func (e *testEnv) trash(t *testing.T, id string) {
+	t.Helper()
+	now := time.Now()
+	if err := e.docs.SetDeleted(context.Background(), id, &now); err != nil {
+		t.Fatalf("trash %s: %v", id, err)
+	}
+}
+
+func TestTrashedDocumentsAreNotFound(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	env.trash(t, id)
+
+	for name, handler := range map[string]http.HandlerFunc{
+		"get":        env.h.Get,
+		"status":     env.h.Status,
+		"sources":    env.h.Sources,
+		"pdf":        env.h.DownloadPDF,
+		"tex":        env.h.DownloadTex,
+		"tags":       env.h.SetTags,
+		"regenerate": env.h.Regenerate,
+		"cancel":     env.h.Cancel,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(handler, newRequest(t, http.MethodPost, "/research/"+id, "user-a",
+				map[string]interface{}{"tags": []string{"x"}, "api_key": "sk-test"}, "id", id))
+			if w.Code != http.StatusNotFound {
+				t.Errorf("got %d %s, want 404", w.Code, w.Body)
+			}
+		})
+	}
+
+	if w := serve(env.h.Delete, newRequest(t, http.MethodDelete, "/research/"+id, "user-a", nil, "id", id)); w.Code != http.StatusConflict {
+		t.Errorf("trashing again: got %d, want 409", w.Code)
+	}
+	if w := serve(env.h.Restore, newRequest(t, http.MethodPost, "/research/"+id+"/restore", "user-a", nil, "id", id)); w.Code != http.StatusOK {
+		t.Fatalf("restore: got %d %s, want 200", w.Code, w.Body)
+	}
+	if w := serve(env.h.Get, newRequest(t, http.MethodGet, "/research/"+id, "user-a", nil, "id", id)); w.Code != http.StatusOK {
+		t.Errorf("get after restore: got %d, want 200", w.Code)
+	}
+}
+
+func TestPermanentlyDeleteTrashedDocument(t *testing.T) {
+	env := newTestEnv(t, nil)
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test"})
+	id := doc.ID.Hex()
+	env.trash(t, id)
+
+	w := serve(env.h.Delete, newRequest(t, http.MethodDelete, "/research/"+id+"?permanent=true", "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d %s, want 200", w.Code, w.Body)
+	}
+	if keys := env.files.Keys(); len(keys) != 0 {
+		t.Errorf("files left: %v", keys)
+	}
+}
+
+func TestDeleteRejectsRunningResearch(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id, finish := env.startBlocked(t, "user-a")
+
+	for _, target := range []string{"/research/" + id, "/research/" + id + "?permanent=true"} {
+		w := serve(env.h.Delete, newRequest(t, http.MethodDelete, target, "user-a", nil, "id", id))
+		if w.Code != http.StatusConflict {
+			t.Errorf("DELETE %s while running: got %d, want 409", target, w.Code)
+		}
+	}
+	finish()
+
+	w := serve(env.h.Delete, newRequest(t, http.MethodDelete, "/research/"+id+"?permanent=true", "user-a", nil, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete after the run: got %d %s, want 200", w.Code, w.Body)
+	}
+	if keys := env.files.Keys(); len(keys) != 0 {
+		t.Errorf("files left: %v", keys)
+	}
+}
+
+func TestPipelineKeepsTrashMove(t *testing.T) {
+	env := newTestEnv(t, nil)
+	id, finish := env.startBlocked(t, "user-a")
+	env.trash(t, id)
+	finish()
+
+	doc, err := env.docs.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.DeletedAt == nil {
+		t.Error("finishing the run took the document out of the trash")
+	}
+	if doc.Status != models.StatusComplete {
+		t.Errorf("status = %q, want %q", doc.Status, models.StatusComplete)
+	}
+}
@@ -0,0 +1,36 @@
+package research
+
+import (
+	"context"
+	"log"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// artifact is a single object produced by the pipeline and destined for the
+// file store. On a failed upload *key is cleared so the document never
+// points at an object that doesn't exist.
+type artifact struct {
+	key         *string
+	data        []byte
+	contentType string
+}
+
+// uploadArtifacts uploads all artifacts concurrently, bounded by
+// opts.UploadConcurrency. Failures are logged per artifact and don't affect
+// the others, so a failed .tex upload never loses a successful PDF.
+func (h *Handler) uploadArtifacts(ctx context.Context, artifacts []artifact) {
+	var g errgroup.Group
+	g.SetLimit(h.opts.UploadConcurrency)
+	for _, a := range artifacts {
+		a := a
+		g.Go(func() error {
+			if err := h.minio.Upload(ctx, *a.key, a.data, a.contentType); err != nil {
+				log.Printf("upload %s error: %v", *a.key, err)
+				*a.key = ""
+			}
+			return nil
+		})
+	}
+	g.Wait()
+}
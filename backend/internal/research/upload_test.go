@@ -0,0 +1,81 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/storetest"
+)
+
+// slowFiles is a FileStore whose uploads take a while, tracking how many
+// run at once, and fail for the keys in fail.
+type slowFiles struct {
+	FileStore
+	fail    map[string]bool
+	running atomic.Int32
+	peak    atomic.Int32
+	mu      sync.Mutex
+	stored  []string
+}
+
+func (s *slowFiles) Upload(ctx context.Context, key string, data []byte, contentType string) error {
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if s.fail[key] {
+		return errors.New("disk full")
+	}
+	s.mu.Lock()
+	s.stored = append(s.stored, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func TestUploadArtifactsIsBounded(t *testing.T) {
+	files := &slowFiles{}
+	h := NewHandler(storetest.NewResearchStore(), files, nil, nil, Options{UploadConcurrency: 2})
+
+	keys := make([]string, 6)
+	var artifacts []artifact
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user-a/%d.pdf", i)
+		artifacts = append(artifacts, artifact{key: &keys[i], data: []byte("x"), contentType: "application/pdf"})
+	}
+	h.uploadArtifacts(context.Background(), artifacts)
+
+	if len(files.stored) != len(keys) {
+		t.Errorf("uploaded %d artifacts, want %d", len(files.stored), len(keys))
+	}
+	if peak := files.peak.Load(); peak != 2 {
+		t.Errorf("%d uploads ran at once, want 2", peak)
+	}
+}
+
+func TestUploadArtifactsClearsFailedKeys(t *testing.T) {
+	files := &slowFiles{fail: map[string]bool{"user-a/report.tex": true}}
+	h := NewHandler(storetest.NewResearchStore(), files, nil, nil, Options{})
+
+	pdfKey, texKey := "user-a/report.pdf", "user-a/report.tex"
+	h.uploadArtifacts(context.Background(), []artifact{
+		{key: &pdfKey, data: []byte("%PDF"), contentType: "application/pdf"},
+		{key: &texKey, data: []byte("\\documentclass{article}"), contentType: "application/x-tex"},
+	})
+
+	if pdfKey != "user-a/report.pdf" {
+		t.Errorf("pdf key = %q, want it kept", pdfKey)
+	}
+	if texKey != "" {
+		t.Errorf("tex key = %q, want it cleared after the failed upload", texKey)
+	}
+}
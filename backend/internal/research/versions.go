@@ -0,0 +1,166 @@
+package research
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/ayush/research-ai-agent/backend/internal/apierror"
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// archiveVersion snapshots the current report of doc before it gets
+// overwritten. Its files are copied under version-specific keys, because
+// edits and regenerations rewrite the document's canonical keys in place.
+// Callers hold the document lock.
+func (h *Handler) archiveVersion(ctx context.Context, doc *models.Document, docID string) error {
+	if doc.LatexContent == "" && doc.PDFObjectKey == "" && doc.TexObjectKey == "" {
+		return nil // nothing generated yet
+	}
+	v := &models.Version{
+		ID:           primitive.NewObjectID(),
+		DocID:        docID,
+		LatexContent: doc.LatexContent,
+		ModelUsed:    doc.ModelUsed,
+		PDFSize:      doc.PDFSize,
+		TexSize:      doc.TexSize,
+		Sources:      doc.Sources,
+	}
+	var err error
+	if v.PDFObjectKey, err = h.copyObject(ctx, doc.PDFObjectKey, v.ID.Hex()); err != nil {
+		return err
+	}
+	if v.TexObjectKey, err = h.copyObject(ctx, doc.TexObjectKey, v.ID.Hex()); err != nil {
+		h.minio.Remove(ctx, v.PDFObjectKey)
+		return err
+	}
+	if err := h.mongo.AppendVersion(ctx, v); err != nil {
+		h.removeVersionObjects(ctx, *v)
+		return err
+	}
+	return nil
+}
+
+// copyObject copies the object at key next to it with suffix inserted
+// before the extension and returns the new key ("" if key is "").
+func (h *Handler) copyObject(ctx context.Context, key, suffix string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+	data, ct, err := h.minio.Download(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("copy %s: %w", key, err)
+	}
+	dst := key + "." + suffix
+	if dot := strings.LastIndex(key, "."); dot > strings.LastIndex(key, "/") {
+		dst = key[:dot] + "." + suffix + key[dot:]
+	}
+	if err := h.minio.Upload(ctx, dst, data, ct); err != nil {
+		return "", fmt.Errorf("copy %s: %w", key, err)
+	}
+	return dst, nil
+}
+
+func (h *Handler) removeVersionObjects(ctx context.Context, v models.Version) {
+	if v.PDFObjectKey != "" {
+		h.minio.Remove(ctx, v.PDFObjectKey)
+	}
+	if v.TexObjectKey != "" {
+		h.minio.Remove(ctx, v.TexObjectKey)
+	}
+}
+
+// deleteVersions removes every version of a document and their files.
+func (h *Handler) deleteVersions(ctx context.Context, docID string) {
+	versions, err := h.mongo.ListVersions(ctx, docID)
+	if err != nil {
+		log.Printf("list versions %s: %v", docID, err)
+		return
+	}
+	for _, v := range versions {
+		h.removeVersionObjects(ctx, v)
+	}
+	if err := h.mongo.DeleteVersions(ctx, docID); err != nil {
+		log.Printf("delete versions %s: %v", docID, err)
+	}
+}
+
+// ListVersions returns the earlier versions of a document, newest first.
+func (h *Handler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	versions, err := h.mongo.ListVersions(r.Context(), doc.ID.Hex())
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "database error")
+		return
+	}
+	if versions == nil {
+		versions = []models.Version{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]models.Version{"versions": versions})
+}
+
+// RestoreVersion rolls a document back to an earlier version. The current
+// report is archived first, so a restore can itself be undone.
+func (h *Handler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(chi.URLParam(r, "v"))
+	if err != nil || n < 1 {
+		apierror.Write(w, http.StatusBadRequest, apierror.InvalidRequest, "invalid version")
+		return
+	}
+	doc, ok := h.ownedDoc(w, r)
+	if !ok {
+		return
+	}
+	if doc.Status == models.StatusPending || doc.Status == models.StatusRunning {
+		apierror.Write(w, http.StatusConflict, apierror.Conflict, "research is still running")
+		return
+	}
+	docID := doc.ID.Hex()
+	release, ok := h.lockDoc(w, r, docID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	v, err := h.mongo.GetVersion(r.Context(), docID, n)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		apierror.Write(w, http.StatusNotFound, apierror.NotFound, "version not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "database error")
+		return
+	}
+
+	if err := h.archiveVersion(r.Context(), doc, docID); err != nil {
+		log.Printf("archive version %s: %v", docID, err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to archive current version")
+		return
+	}
+	doc.LatexContent = v.LatexContent
+	doc.ModelUsed = v.ModelUsed
+	doc.PDFObjectKey = v.PDFObjectKey
+	doc.TexObjectKey = v.TexObjectKey
+	doc.PDFSize = v.PDFSize
+	doc.TexSize = v.TexSize
+	if v.Sources != nil {
+		doc.Sources = v.Sources
+	}
+	if err := h.mongo.Update(r.Context(), docID, doc); err != nil {
+		log.Printf("mongo update error: %v", err)
+		apierror.Write(w, http.StatusInternalServerError, apierror.Internal, "failed to save research")
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
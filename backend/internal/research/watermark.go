@@ -0,0 +1,27 @@
+package research
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxWatermarkLen caps watermark text, in characters, so it fits across a
+// page.
+const maxWatermarkLen = 40
+
+// normalizeWatermark collapses the whitespace of a requested watermark and
+// checks it is short, single-line text.
+func normalizeWatermark(s string) (string, error) {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) > maxWatermarkLen {
+		return "", fmt.Errorf("watermark must be at most %d characters", maxWatermarkLen)
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return "", fmt.Errorf("watermark must be plain text")
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,86 @@
+package research
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+func TestNormalizeWatermark(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{"", "", false},
+		{"DRAFT", "DRAFT", false},
+		{"  Acme \t Corp  ", "Acme Corp", false},
+		{strings.Repeat("é", maxWatermarkLen), strings.Repeat("é", maxWatermarkLen), false},
+		{strings.Repeat("x", maxWatermarkLen+1), "", true},
+		{"DRAFT\x00", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeWatermark(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("normalizeWatermark(%q) = %q, %v; want %q (error: %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCreateForwardsWatermark(t *testing.T) {
+	env := newTestEnv(t, nil)
+
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Watermark: " DRAFT  copy "})
+	if doc.Watermark != "DRAFT copy" {
+		t.Errorf("stored watermark = %q, want %q", doc.Watermark, "DRAFT copy")
+	}
+	env.create(t, "user-a", models.CreateRequest{Topic: "wind", APIKey: "sk-test"})
+
+	pdf := env.latex.bodies["/api/compile-pdf"]
+	if len(pdf) != 2 {
+		t.Fatalf("compile-pdf called %d times, want 2", len(pdf))
+	}
+	if pdf[0]["watermark"] != "DRAFT copy" {
+		t.Errorf("compile-pdf body = %v, want the watermark", pdf[0])
+	}
+	if _, ok := pdf[1]["watermark"]; ok {
+		t.Errorf("compile-pdf body = %v, want no watermark", pdf[1])
+	}
+	for _, body := range env.latex.bodies["/api/compile-tex"] {
+		if _, ok := body["watermark"]; ok {
+			t.Errorf("compile-tex body = %v, want no watermark", body)
+		}
+	}
+
+	// Recompiling an edited report keeps the watermark.
+	id := doc.ID.Hex()
+	w := serve(env.h.UpdateLatex, newRequest(t, http.MethodPut, "/research/"+id+"/latex", "user-a",
+		map[string]string{"latex_content": "\\section{Edited}\nSolar panels, revised."}, "id", id))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update latex: got %d %s, want 200", w.Code, w.Body)
+	}
+	if pdf := env.latex.bodies["/api/compile-pdf"]; pdf[len(pdf)-1]["watermark"] != "DRAFT copy" {
+		t.Errorf("recompile body = %v, want the stored watermark", pdf[len(pdf)-1])
+	}
+
+	w = serve(env.h.Create, newRequest(t, http.MethodPost, "/research", "user-a",
+		models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Watermark: strings.Repeat("x", maxWatermarkLen+1)}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("long watermark: got %d, want 400", w.Code)
+	}
+}
+
+func TestWatermarkSpecialsForwardedVerbatim(t *testing.T) {
+	env := newTestEnv(t, nil)
+	// The LaTeX service escapes the text; it must arrive as written.
+	const mark = `50% #1 \draft {v2} $5_a ~^ &`
+	doc := env.create(t, "user-a", models.CreateRequest{Topic: "solar panels", APIKey: "sk-test", Watermark: mark})
+	if doc.Watermark != mark {
+		t.Errorf("stored watermark = %q, want %q", doc.Watermark, mark)
+	}
+	pdf := env.latex.bodies["/api/compile-pdf"]
+	if len(pdf) != 1 || pdf[0]["watermark"] != mark {
+		t.Errorf("compile-pdf bodies = %v, want the watermark %q", pdf, mark)
+	}
+}
@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// Box encrypts small secrets at rest with AES-256-GCM. The key is derived
+// from a server secret, so rotating that secret makes old values unreadable.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox derives an encryption key from secret for the given purpose, so
+// the same server secret yields independent keys per use.
+func NewBox(secret, purpose string) (*Box, error) {
+	if secret == "" {
+		return nil, errors.New("secrets: empty secret")
+	}
+	key := sha256.Sum256([]byte(purpose + ":" + secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts plaintext, prefixing the random nonce to the ciphertext.
+func (b *Box) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts a value produced by Seal.
+func (b *Box) Open(sealed []byte) ([]byte, error) {
+	n := b.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("secrets: ciphertext too short")
+	}
+	return b.aead.Open(nil, sealed[:n], sealed[n:], nil)
+}
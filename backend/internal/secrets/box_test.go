@@ -0,0 +1,51 @@
+package secrets
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBoxRoundTrip(t *testing.T) {
+	b, err := NewBox("server-secret", "api-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := []byte("sk-test-123")
+	a, err := b.Seal(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := b.Seal(plain)
+	if bytes.Contains(a, plain) || bytes.Equal(a, c) {
+		t.Error("sealed values leak the plaintext or repeat a nonce")
+	}
+	got, err := b.Open(a)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("Open = %q, %v; want %q", got, err, plain)
+	}
+}
+
+func TestBoxRejectsForeignValues(t *testing.T) {
+	b, _ := NewBox("server-secret", "api-keys")
+	sealed, _ := b.Seal([]byte("sk-test-123"))
+
+	otherPurpose, _ := NewBox("server-secret", "webhooks")
+	otherSecret, _ := NewBox("rotated-secret", "api-keys")
+	for name, box := range map[string]*Box{"other purpose": otherPurpose, "other secret": otherSecret} {
+		if _, err := box.Open(sealed); err == nil {
+			t.Errorf("%s opened the value", name)
+		}
+	}
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := b.Open(tampered); err == nil {
+		t.Error("tampered value opened")
+	}
+	if _, err := b.Open([]byte("short")); err == nil {
+		t.Error("truncated value opened")
+	}
+	if _, err := NewBox("", "api-keys"); err == nil {
+		t.Error("NewBox accepted an empty secret")
+	}
+}
@@ -0,0 +1,67 @@
+package shutdown
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+)
+
+// Hooks runs cleanup functions, in registration order, once the server has
+// drained. A failing hook is logged and doesn't stop the ones after it.
+type Hooks struct {
+	hooks []hook
+}
+
+type hook struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// Add registers fn to run at shutdown.
+func (h *Hooks) Add(name string, fn func(context.Context) error) {
+	h.hooks = append(h.hooks, hook{name: name, fn: fn})
+}
+
+// Run calls every hook with ctx.
+func (h *Hooks) Run(ctx context.Context) {
+	for _, hk := range h.hooks {
+		if err := hk.fn(ctx); err != nil {
+			log.Printf("shutdown hook %q: %v", hk.name, err)
+		}
+	}
+}
+
+// Event is the final record written before the process exits.
+type Event struct {
+	Event           string    `json:"event"`
+	Time            time.Time `json:"time"`
+	UptimeSeconds   float64   `json:"uptime_seconds"`
+	JobsProcessed   int64     `json:"jobs_processed"`
+	JobsFailed      int64     `json:"jobs_failed"`
+	JobsInterrupted int64     `json:"jobs_interrupted"`
+}
+
+// Record logs ev as a single JSON line and, when path is set, appends the
+// same line to that file so it survives log rotation.
+func Record(ev Event, path string) error {
+	ev.Event = "shutdown"
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	log.Printf("%s", line)
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,68 @@
+package shutdown
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHooksRunInOrderPastFailures(t *testing.T) {
+	var ran []string
+	var hooks Hooks
+	for _, name := range []string{"first", "failing", "last"} {
+		hooks.Add(name, func(ctx context.Context) error {
+			if err := ctx.Err(); err != nil {
+				t.Errorf("hook %s got a done context: %v", name, err)
+			}
+			ran = append(ran, name)
+			if name == "failing" {
+				return errors.New("flush failed")
+			}
+			return nil
+		})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	hooks.Run(ctx)
+
+	if want := []string{"first", "failing", "last"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestRecordAppendsEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shutdown.log")
+	for i := int64(1); i <= 2; i++ {
+		if err := Record(Event{JobsProcessed: i, JobsInterrupted: 1}, path); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var ev Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("decode %q: %v", data, err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	for i, ev := range events {
+		if ev.Event != "shutdown" || ev.JobsProcessed != int64(i+1) || ev.JobsInterrupted != 1 {
+			t.Errorf("event %d = %+v", i, ev)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// Storage backends accepted by NewFileStore.
+const (
+	BackendMinio = "minio"
+	BackendS3    = "s3"
+	BackendLocal = "local"
+)
+
+// FileStore is what every storage backend provides.
+type FileStore interface {
+	Upload(ctx context.Context, key string, data []byte, contentType string) error
+	Download(ctx context.Context, key string) ([]byte, string, error)
+	DownloadStream(ctx context.Context, key string) (io.ReadSeekCloser, models.ObjectInfo, error)
+	Stat(ctx context.Context, key string) (models.ObjectInfo, error)
+	Remove(ctx context.Context, key string) error
+	Ping(ctx context.Context) error
+}
+
+// FileStoreConfig selects and configures a storage backend.
+type FileStoreConfig struct {
+	Backend string
+
+	// Local disk.
+	LocalDir string
+
+	// MinIO.
+	MinioEndpoint  string
+	MinioAccessKey string
+	MinioSecretKey string
+	MinioBucket    string
+	MinioUseSSL    bool
+
+	// AWS S3 or another S3-compatible service. Without keys, credentials
+	// come from the AWS environment variables, shared credentials file or
+	// instance role.
+	S3Endpoint  string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	S3Bucket    string
+}
+
+// NewFileStore returns the backend named by cfg.Backend, defaulting to
+// MinIO. Local disk is only used when asked for by name.
+func NewFileStore(ctx context.Context, cfg FileStoreConfig) (FileStore, error) {
+	switch cfg.Backend {
+	case "", BackendMinio:
+		if cfg.MinioEndpoint == "" {
+			return nil, fmt.Errorf("%s storage needs an endpoint; set STORAGE_BACKEND=%s to store files on disk", BackendMinio, BackendLocal)
+		}
+		creds := credentials.NewStaticV4(cfg.MinioAccessKey, cfg.MinioSecretKey, "")
+		return newMinio(ctx, cfg.MinioEndpoint, creds, cfg.MinioBucket, "", cfg.MinioUseSSL)
+	case BackendS3:
+		creds := credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+		if cfg.S3AccessKey != "" {
+			creds = credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, "")
+		}
+		return newMinio(ctx, cfg.S3Endpoint, creds, cfg.S3Bucket, cfg.S3Region, true)
+	case BackendLocal:
+		return newLocal(cfg.LocalDir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (want %s, %s or %s)", cfg.Backend, BackendMinio, BackendS3, BackendLocal)
+	}
+}
+
+// newLocal and newMinio keep a failed constructor from returning a non-nil
+// FileStore holding a nil pointer.
+func newLocal(dir string) (FileStore, error) {
+	s, err := NewLocalFileStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func newMinio(ctx context.Context, endpoint string, creds *credentials.Credentials, bucket, region string, useSSL bool) (FileStore, error) {
+	s, err := NewMinioStore(ctx, endpoint, creds, bucket, region, useSSL)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
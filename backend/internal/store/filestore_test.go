@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeS3 answers just enough of the S3 API for a client to find an existing
+// bucket.
+func fakeS3(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["location"]; ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestNewFileStore(t *testing.T) {
+	endpoint := fakeS3(t)
+	tests := []struct {
+		name string
+		cfg  FileStoreConfig
+		want string
+	}{
+		{"default", FileStoreConfig{MinioEndpoint: endpoint, MinioBucket: "pdfs"}, "minio"},
+		{"minio", FileStoreConfig{Backend: BackendMinio, MinioEndpoint: endpoint, MinioBucket: "pdfs"}, "minio"},
+		{"minio without endpoint", FileStoreConfig{Backend: BackendMinio}, "error"},
+		{"local", FileStoreConfig{Backend: BackendLocal, MinioEndpoint: endpoint}, "local"},
+		{"unknown", FileStoreConfig{Backend: "gcs"}, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.LocalDir = t.TempDir()
+			s, err := NewFileStore(context.Background(), tt.cfg)
+			got := "error"
+			switch s.(type) {
+			case *MinioStore:
+				got = "minio"
+			case *LocalFileStore:
+				got = "local"
+			}
+			if got != tt.want {
+				t.Errorf("got %s (%T, %v), want %s", got, s, err, tt.want)
+			}
+			if (err != nil) != (tt.want == "error") {
+				t.Errorf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewFileStoreFailureIsNil(t *testing.T) {
+	// A typed nil would pass a != nil check and panic on first use.
+	s, err := NewFileStore(context.Background(), FileStoreConfig{Backend: BackendS3, S3Endpoint: "bad endpoint/with/path"})
+	if err == nil || s != nil {
+		t.Errorf("got %v, %v; want a nil store and an error", s, err)
+	}
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// LocalFileStore stores files on the local filesystem under a base
+// directory, using object keys as relative paths. Meant for development
+// and tests where running MinIO is overkill.
+type LocalFileStore struct {
+	baseDir string
+}
+
+func NewLocalFileStore(baseDir string) (*LocalFileStore, error) {
+	abs, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("local store: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("local store mkdir: %w", err)
+	}
+	return &LocalFileStore{baseDir: abs}, nil
+}
+
+// Ping checks that the base directory is still usable.
+func (s *LocalFileStore) Ping(_ context.Context) error {
+	info, err := os.Stat(s.baseDir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("local store: %s is not a directory", s.baseDir)
+	}
+	return nil
+}
+
+// path resolves key under the base directory, rejecting keys that would
+// escape it.
+func (s *LocalFileStore) path(key string) (string, error) {
+	p := filepath.Join(s.baseDir, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, s.baseDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("local store: invalid key %q", key)
+	}
+	return p, nil
+}
+
+// Upload writes data to the file for key, creating parent directories.
+func (s *LocalFileStore) Upload(ctx context.Context, key string, data []byte, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+// Download reads the file for key and infers its content type from the
+// extension.
+func (s *LocalFileStore) Download(ctx context.Context, key string) ([]byte, string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentTypeFor(key), nil
+}
+
+// DownloadStream opens the file for key and returns it with its
+// metadata. The caller must close it.
+func (s *LocalFileStore) DownloadStream(ctx context.Context, key string) (io.ReadSeekCloser, models.ObjectInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, models.ObjectInfo{}, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, models.ObjectInfo{}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, models.ObjectInfo{}, err
+	}
+	return f, fileInfo(key, info), nil
+}
+
+// Stat returns the metadata of the file for key.
+func (s *LocalFileStore) Stat(ctx context.Context, key string) (models.ObjectInfo, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return models.ObjectInfo{}, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return models.ObjectInfo{}, err
+	}
+	return fileInfo(key, info), nil
+}
+
+// fileInfo describes the file for key. Its ETag is derived from the
+// modification time and size, so Stat never has to read the contents.
+func fileInfo(key string, info fs.FileInfo) models.ObjectInfo {
+	return models.ObjectInfo{
+		ContentType: contentTypeFor(key),
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		ETag:        fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}
+}
+
+// Remove deletes the file for key. Missing files are not an error.
+func (s *LocalFileStore) Remove(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// RemoveOlderThan deletes every file under prefix modified before cutoff
+// and returns how many were removed.
+func (s *LocalFileStore) RemoveOlderThan(ctx context.Context, prefix string, cutoff time.Time) (int, error) {
+	root, err := s.path(prefix)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// contentTypeFor guesses a content type from the key's file extension.
+func contentTypeFor(key string) string {
+	switch ext := strings.ToLower(filepath.Ext(key)); ext {
+	case ".tex":
+		return "application/x-tex"
+	case ".pdf":
+		return "application/pdf"
+	default:
+		if ct := mime.TypeByExtension(ext); ct != "" {
+			return ct
+		}
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalFileStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewLocalFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key, contentType string
+	}{
+		{"user-a/doc-1/report.pdf", "application/pdf"},
+		{"user-a/doc-1/report.tex", "application/x-tex"},
+		{"user-a/notes.json", "application/json"},
+		{"blob", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		data := []byte("contents of " + tt.key)
+		if err := s.Upload(ctx, tt.key, data, tt.contentType); err != nil {
+			t.Fatalf("Upload %s: %v", tt.key, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(tt.key))); err != nil {
+			t.Errorf("%s not stored at its relative path: %v", tt.key, err)
+		}
+		got, ct, err := s.Download(ctx, tt.key)
+		if err != nil {
+			t.Fatalf("Download %s: %v", tt.key, err)
+		}
+		if !bytes.Equal(got, data) || ct != tt.contentType {
+			t.Errorf("Download %s = %q, %s; want %q, %s", tt.key, got, ct, data, tt.contentType)
+		}
+	}
+
+	if err := s.Upload(ctx, "blob", []byte("replaced"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := s.Download(ctx, "blob"); string(got) != "replaced" {
+		t.Errorf("overwrite kept %q", got)
+	}
+
+	if err := s.Remove(ctx, "user-a/doc-1/report.pdf"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, _, err := s.Download(ctx, "user-a/doc-1/report.pdf"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Download after Remove: got %v, want not exist", err)
+	}
+	if err := s.Remove(ctx, "user-a/doc-1/report.pdf"); err != nil {
+		t.Errorf("removing a missing file: %v", err)
+	}
+}
+
+func TestLocalFileStoreRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s, err := NewLocalFileStore(filepath.Join(root, "files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(root, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../secret.txt", "a/../../secret.txt", "../files-other/x", "", ".", "a/.."} {
+		if err := s.Upload(ctx, key, []byte("x"), ""); err == nil {
+			t.Errorf("Upload(%q) succeeded", key)
+		}
+		if _, _, err := s.Download(ctx, key); err == nil {
+			t.Errorf("Download(%q) succeeded", key)
+		}
+		if err := s.Remove(ctx, key); err == nil {
+			t.Errorf("Remove(%q) succeeded", key)
+		}
+	}
+	if data, err := os.ReadFile(secret); err != nil || string(data) != "secret" {
+		t.Errorf("file outside the base directory changed: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "files-other")); !os.IsNotExist(err) {
+		t.Errorf("sibling directory created: %v", err)
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
 )
 
 // MinioStore wraps a MinIO client for file storage.
@@ -16,10 +20,13 @@ type MinioStore struct {
 	bucket string
 }
 
-func NewMinioStore(ctx context.Context, endpoint, accessKey, secretKey, bucket string, useSSL bool) (*MinioStore, error) {
+// NewMinioStore connects to a MinIO or S3-compatible endpoint and creates
+// bucket if it is missing. An empty region lets the client detect it.
+func NewMinioStore(ctx context.Context, endpoint string, creds *credentials.Credentials, bucket, region string, useSSL bool) (*MinioStore, error) {
 	client, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Creds:  creds,
 		Secure: useSSL,
+		Region: region,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("minio client: %w", err)
@@ -31,7 +38,7 @@ func NewMinioStore(ctx context.Context, endpoint, accessKey, secretKey, bucket s
 		return nil, fmt.Errorf("minio bucket check: %w", err)
 	}
 	if !exists {
-		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: region}); err != nil {
 			return nil, fmt.Errorf("minio make bucket: %w", err)
 		}
 	}
@@ -39,6 +46,18 @@ func NewMinioStore(ctx context.Context, endpoint, accessKey, secretKey, bucket s
 	return &MinioStore{client: client, bucket: bucket}, nil
 }
 
+// Ping checks that MinIO is reachable and the bucket exists.
+func (s *MinioStore) Ping(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %q does not exist", s.bucket)
+	}
+	return nil
+}
+
 // Upload stores bytes under the given object key.
 func (s *MinioStore) Upload(ctx context.Context, key string, data []byte, contentType string) error {
 	reader := bytes.NewReader(data)
@@ -68,7 +87,70 @@ func (s *MinioStore) Download(ctx context.Context, key string) ([]byte, string,
 	return data, info.ContentType, nil
 }
 
+// DownloadStream opens the object for reading without buffering it and
+// returns the reader with the object's metadata. The reader seeks with
+// ranged requests. The caller must close it.
+func (s *MinioStore) DownloadStream(ctx context.Context, key string) (io.ReadSeekCloser, models.ObjectInfo, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, models.ObjectInfo{}, err
+	}
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, models.ObjectInfo{}, err
+	}
+	return obj, objectInfo(info), nil
+}
+
+// Stat returns the metadata of the object without reading it.
+func (s *MinioStore) Stat(ctx context.Context, key string) (models.ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return models.ObjectInfo{}, err
+	}
+	return objectInfo(info), nil
+}
+
+func objectInfo(info minio.ObjectInfo) models.ObjectInfo {
+	return models.ObjectInfo{
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		ModTime:     info.LastModified,
+		ETag:        `"` + strings.Trim(info.ETag, `"`) + `"`,
+	}
+}
+
+// PresignedGetURL returns a URL that downloads the object without
+// credentials until expiry passes. S3 caps expiry at seven days.
+func (s *MinioStore) PresignedGetURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
 // Remove deletes an object.
 func (s *MinioStore) Remove(ctx context.Context, key string) error {
 	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
 }
+
+// RemoveOlderThan deletes every object under prefix last modified before
+// cutoff and returns how many were removed.
+func (s *MinioStore) RemoveOlderThan(ctx context.Context, prefix string, cutoff time.Time) (int, error) {
+	removed := 0
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return removed, obj.Err
+		}
+		if !obj.LastModified.Before(cutoff) {
+			continue
+		}
+		if err := s.Remove(ctx, obj.Key); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -2,7 +2,11 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,11 +19,53 @@ import (
 
 // MongoStore handles research document CRUD in MongoDB.
 type MongoStore struct {
-	col *mongo.Collection
+	col      *mongo.Collection
+	versions *mongo.Collection
 }
 
 func NewMongoStore(db *mongo.Database) *MongoStore {
-	return &MongoStore{col: db.Collection("research")}
+	return &MongoStore{col: db.Collection("research"), versions: db.Collection("research_versions")}
+}
+
+// EnsureIndexes creates the indexes the research queries rely on. It is
+// idempotent and safe to call on every startup.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	names, err := s.col.Indexes().CreateMany(ctx, researchIndexes())
+	if err != nil {
+		return fmt.Errorf("mongo ensure indexes: %w", err)
+	}
+	log.Printf("mongo indexes ensured: %v", names)
+	_, err = s.versions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "doc_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("mongo ensure version indexes: %w", err)
+	}
+	return nil
+}
+
+// researchIndexes lists the indexes of the research collection.
+func researchIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			// Listing a user's documents newest first.
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("user_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "tags", Value: 1}},
+			Options: options.Index().SetName("user_tags"),
+		},
+		{
+			Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetName("deleted_at").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "topic", Value: "text"}, {Key: "latex_content", Value: "text"}},
+			Options: options.Index().SetName("topic_content_text").SetWeights(bson.M{"topic": 5, "latex_content": 1}),
+		},
+	}
 }
 
 func (s *MongoStore) Insert(ctx context.Context, doc *models.Document) (string, error) {
@@ -29,12 +75,252 @@ func (s *MongoStore) Insert(ctx context.Context, doc *models.Document) (string,
 		return "", fmt.Errorf("mongo insert: %w", err)
 	}
 	oid := res.InsertedID.(primitive.ObjectID)
+	doc.ID = oid
 	return oid.Hex(), nil
 }
 
-func (s *MongoStore) ListByUser(ctx context.Context, userID string) ([]models.Document, error) {
+// ListByUser returns one page of a user's documents, newest first, and the
+// cursor for the next page ("" when there are no more).
+func (s *MongoStore) ListByUser(ctx context.Context, userID string, opts models.ListOptions) ([]models.Document, string, error) {
+	filter := bson.M{"user_id": userID, "deleted_at": bson.M{"$exists": opts.Trashed}}
+	if opts.Model != "" {
+		filter["model_used"] = opts.Model
+	}
+	if !opts.From.IsZero() || !opts.To.IsZero() {
+		created := bson.M{}
+		if !opts.From.IsZero() {
+			created["$gte"] = opts.From
+		}
+		if !opts.To.IsZero() {
+			created["$lte"] = opts.To
+		}
+		filter["created_at"] = created
+	}
+	if opts.Tag != "" {
+		filter["tags"] = opts.Tag
+	}
+	if opts.Domain != "" {
+		filter["sources.href"] = primitive.Regex{Pattern: domainPattern(opts.Domain), Options: "i"}
+	}
+	if opts.Cursor != "" {
+		oid, err := primitive.ObjectIDFromHex(opts.Cursor)
+		if err != nil {
+			return nil, "", models.ErrInvalidCursor
+		}
+		var last models.Document
+		err = s.col.FindOne(ctx, bson.M{"_id": oid, "user_id": userID}).Decode(&last)
+		if err == mongo.ErrNoDocuments {
+			return nil, "", models.ErrInvalidCursor
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": last.CreatedAt}},
+			bson.M{"created_at": last.CreatedAt, "_id": bson.M{"$lt": oid}},
+		}
+	}
+
+	// Fetch one extra document to learn whether another page exists.
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(opts.Limit) + 1)
+	cur, err := s.col.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cur.Close(ctx)
+
+	var docs []models.Document
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(docs) > opts.Limit {
+		docs = docs[:opts.Limit]
+		next = docs[len(docs)-1].ID.Hex()
+	}
+	return docs, next, nil
+}
+
+// hostPattern captures the host of a URL, minus any leading "www.".
+const hostPattern = `^[a-zA-Z][a-zA-Z0-9+.-]*://(?:www\.)?([^/:?#]+)`
+
+// domainPattern matches URLs whose host is domain (with or without "www.").
+func domainPattern(domain string) string {
+	d := regexp.QuoteMeta(strings.TrimPrefix(strings.ToLower(domain), "www."))
+	return `^[a-zA-Z][a-zA-Z0-9+.-]*://(?:www\.)?` + d + `(?:[/:?#]|$)`
+}
+
+// DomainsByUser counts citations per source host across all of a user's
+// documents, most cited first.
+func (s *MongoStore) DomainsByUser(ctx context.Context, userID string) ([]models.DomainCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": bson.M{"$exists": false}}}},
+		{{Key: "$unwind", Value: "$sources"}},
+		{{Key: "$project", Value: bson.M{
+			"doc":  "$_id",
+			"host": bson.M{"$regexFind": bson.M{"input": "$sources.href", "regex": hostPattern}},
+		}}},
+		{{Key: "$match", Value: bson.M{"host": bson.M{"$ne": nil}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"$toLower": bson.M{"$arrayElemAt": bson.A{"$host.captures", 0}}},
+			"citations": bson.M{"$sum": 1},
+			"docs":      bson.M{"$addToSet": "$doc"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id": 0, "domain": "$_id", "citations": 1, "documents": bson.M{"$size": "$docs"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "citations", Value: -1}, {Key: "domain", Value: 1}}}},
+	}
+	cur, err := s.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var counts []models.DomainCount
+	if err := cur.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// TagsByUser counts the tags on a user's live documents, most used first.
+func (s *MongoStore) TagsByUser(ctx context.Context, userID string) ([]models.TagCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": bson.M{"$exists": false}}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "tag": "$_id", "count": 1}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "tag", Value: 1}}}},
+	}
+	cur, err := s.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var counts []models.TagCount
+	if err := cur.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// StatsByUser counts a user's live documents, overall and per model, and
+// sums their stored file sizes.
+func (s *MongoStore) StatsByUser(ctx context.Context, userID string) (*models.ResearchStats, error) {
+	fileBytes := bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$pdf_size", 0}},
+		bson.M{"$ifNull": bson.A{"$tex_size", 0}},
+	}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "deleted_at": bson.M{"$exists": false}}}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":    nil,
+					"total":  bson.M{"$sum": 1},
+					"bytes":  bson.M{"$sum": fileBytes},
+					"latest": bson.M{"$max": "$created_at"},
+				}},
+			},
+			"models": bson.A{
+				bson.M{"$group": bson.M{"_id": "$model_used", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$project": bson.M{"_id": 0, "model": "$_id", "count": 1}},
+			},
+		}}},
+	}
+	cur, err := s.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var results []struct {
+		Totals []struct {
+			Total  int       `bson:"total"`
+			Bytes  int64     `bson:"bytes"`
+			Latest time.Time `bson:"latest"`
+		} `bson:"totals"`
+		Models []models.ModelCount `bson:"models"`
+	}
+	if err := cur.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	stats := &models.ResearchStats{ByModel: []models.ModelCount{}}
+	if len(results) == 0 {
+		return stats, nil
+	}
+	if len(results[0].Models) > 0 {
+		stats.ByModel = results[0].Models
+	}
+	if len(results[0].Totals) > 0 {
+		t := results[0].Totals[0]
+		stats.Total, stats.StorageBytes = t.Total, t.Bytes
+		stats.LatestAt = &t.Latest
+	}
+	return stats, nil
+}
+
+// SetTags replaces the tags of a document.
+func (s *MongoStore) SetTags(ctx context.Context, id string, tags []string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	update := bson.M{"$set": bson.M{"tags": tags}}
+	if len(tags) == 0 {
+		update = bson.M{"$unset": bson.M{"tags": ""}}
+	}
+	_, err = s.col.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// SetSources replaces the sources of a document.
+func (s *MongoStore) SetSources(ctx context.Context, id string, sources []models.Source) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	_, err = s.col.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"sources": sources}})
+	return err
+}
+
+// EachByUser calls fn for every document of a user, newest first, without
+// loading them all into memory. It stops at the first error fn returns.
+func (s *MongoStore) EachByUser(ctx context.Context, userID string, fn func(*models.Document) error) error {
 	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
 	cur, err := s.col.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var doc models.Document
+		if err := cur.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(&doc); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// SearchByUser runs a full-text search over a user's topics and report
+// content, best matches first and newest first among equal scores.
+func (s *MongoStore) SearchByUser(ctx context.Context, userID, query string) ([]models.Document, error) {
+	filter := bson.M{"user_id": userID, "deleted_at": bson.M{"$exists": false}, "$text": bson.M{"$search": query}}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "created_at", Value: -1}}).
+		SetLimit(50)
+	cur, err := s.col.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +345,84 @@ func (s *MongoStore) GetByID(ctx context.Context, id string) (*models.Document,
 	return &doc, nil
 }
 
+// Update replaces a stored document, keeping its _id.
+func (s *MongoStore) Update(ctx context.Context, id string, doc *models.Document) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	doc.ID = oid
+	_, err = s.col.ReplaceOne(ctx, bson.M{"_id": oid}, doc)
+	return err
+}
+
+// SaveResult records the outcome of a pipeline run on a document: its
+// report, sources, files and final status. Unlike Update it leaves the
+// fields the run doesn't own, such as tags and deleted_at, untouched.
+func (s *MongoStore) SaveResult(ctx context.Context, id string, doc *models.Document) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	_, err = s.col.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{
+		"latex_content":   doc.LatexContent,
+		"sources":         doc.Sources,
+		"search_queries":  doc.SearchQueries,
+		"model_used":      doc.ModelUsed,
+		"requested_model": doc.RequestedModel,
+		"pdf_object_key":  doc.PDFObjectKey,
+		"tex_object_key":  doc.TexObjectKey,
+		"pdf_size":        doc.PDFSize,
+		"tex_size":        doc.TexSize,
+		"status":          doc.Status,
+		"step":            doc.Step,
+		"error":           doc.Error,
+		"compile_error":   doc.CompileError,
+		"step_timings":    doc.StepTimings,
+	}})
+	return err
+}
+
+// SetStatus records the job status, current step, and error message.
+func (s *MongoStore) SetStatus(ctx context.Context, id, status, step, errMsg string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	_, err = s.col.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{
+		"status": status, "step": step, "error": errMsg,
+	}})
+	return err
+}
+
+// CancelActive marks a pending or running document cancelled and reports
+// whether it was. A run that already finished keeps its status.
+func (s *MongoStore) CancelActive(ctx context.Context, id, step, errMsg string) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid id: %w", err)
+	}
+	filter := bson.M{"_id": oid, "status": bson.M{"$in": []string{models.StatusPending, models.StatusRunning}}}
+	res, err := s.col.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"status": models.StatusCancelled, "step": step, "error": errMsg,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// SetStepTimings records the step durations of a run that didn't get to
+// save the whole document.
+func (s *MongoStore) SetStepTimings(ctx context.Context, id string, timings map[string]int64) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	_, err = s.col.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"step_timings": timings}})
+	return err
+}
+
 func (s *MongoStore) Delete(ctx context.Context, id string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -67,3 +431,153 @@ func (s *MongoStore) Delete(ctx context.Context, id string) error {
 	_, err = s.col.DeleteOne(ctx, bson.M{"_id": oid})
 	return err
 }
+
+// BulkUpdateTags adds and removes tags on those of ids that belong to userID
+// and returns the IDs that were updated. Invalid, foreign or trashed IDs are
+// skipped.
+func (s *MongoStore) BulkUpdateTags(ctx context.Context, userID string, ids, add, remove []string) ([]string, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": oids}, "user_id": userID, "deleted_at": bson.M{"$exists": false}}
+	cur, err := s.col.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var owned []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &owned); err != nil {
+		return nil, err
+	}
+	if len(owned) == 0 {
+		return nil, nil
+	}
+	oids = oids[:0]
+	updated := make([]string, len(owned))
+	for i, d := range owned {
+		oids = append(oids, d.ID)
+		updated[i] = d.ID.Hex()
+	}
+	filter["_id"] = bson.M{"$in": oids}
+
+	// $addToSet and $pull can't target the same field in one update.
+	if len(add) > 0 {
+		if _, err := s.col.UpdateMany(ctx, filter, bson.M{
+			"$addToSet": bson.M{"tags": bson.M{"$each": add}},
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if len(remove) > 0 {
+		if _, err := s.col.UpdateMany(ctx, filter, bson.M{
+			"$pull": bson.M{"tags": bson.M{"$in": remove}},
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+// AppendVersion stores v as the next version of its document and sets
+// v.Version. Callers hold the document lock, so numbering can't race.
+func (s *MongoStore) AppendVersion(ctx context.Context, v *models.Version) error {
+	var last models.Version
+	err := s.versions.FindOne(ctx, bson.M{"doc_id": v.DocID},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1}),
+	).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	v.Version = last.Version + 1
+	v.CreatedAt = time.Now()
+	res, err := s.versions.InsertOne(ctx, v)
+	if err != nil {
+		return fmt.Errorf("mongo insert version: %w", err)
+	}
+	v.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ListVersions returns a document's versions, newest first, without their
+// LaTeX bodies or sources.
+func (s *MongoStore) ListVersions(ctx context.Context, docID string) ([]models.Version, error) {
+	cur, err := s.versions.Find(ctx, bson.M{"doc_id": docID}, options.Find().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"latex_content": 0, "sources": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var versions []models.Version
+	if err := cur.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetVersion returns one version of a document.
+func (s *MongoStore) GetVersion(ctx context.Context, docID string, version int) (*models.Version, error) {
+	var v models.Version
+	if err := s.versions.FindOne(ctx, bson.M{"doc_id": docID, "version": version}).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// DeleteVersions removes every version of a document.
+func (s *MongoStore) DeleteVersions(ctx context.Context, docID string) error {
+	_, err := s.versions.DeleteMany(ctx, bson.M{"doc_id": docID})
+	return err
+}
+
+// SetDeleted moves a document to the trash (at != nil) or restores it.
+func (s *MongoStore) SetDeleted(ctx context.Context, id string, at *time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}}
+	if at != nil {
+		update = bson.M{"$set": bson.M{"deleted_at": *at}}
+	}
+	_, err = s.col.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// DeletedBefore returns up to limit documents trashed before cutoff.
+func (s *MongoStore) DeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Document, error) {
+	cur, err := s.col.Find(ctx, bson.M{"deleted_at": bson.M{"$lt": cutoff}},
+		options.Find().SetLimit(int64(limit)).SetProjection(bson.M{"latex_content": 0, "sources": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []models.Document
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// CreatedBefore returns up to limit documents created before cutoff by
+// users whose ID starts with userPrefix, trashed ones included.
+func (s *MongoStore) CreatedBefore(ctx context.Context, userPrefix string, cutoff time.Time, limit int) ([]models.Document, error) {
+	cur, err := s.col.Find(ctx, bson.M{
+		"user_id":    bson.M{"$regex": "^" + regexp.QuoteMeta(userPrefix)},
+		"created_at": bson.M{"$lt": cutoff},
+	}, options.Find().SetLimit(int64(limit)).SetProjection(bson.M{"latex_content": 0, "sources": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []models.Document
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestHostPattern(t *testing.T) {
+	re := regexp.MustCompile(hostPattern)
+	for href, want := range map[string]string{
+		"https://example.edu/solar":         "example.edu",
+		"https://www.nature.com/articles/x": "nature.com",
+		"http://News.Example.org:8080/a":    "News.Example.org",
+		"https://arxiv.org?q=1":             "arxiv.org",
+		"https://arxiv.org#top":             "arxiv.org",
+		"ftp://files.example.com":           "files.example.com",
+		"not a url":                         "",
+		"/relative/path":                    "",
+	} {
+		got := ""
+		if m := re.FindStringSubmatch(href); m != nil {
+			got = m[1]
+		}
+		if got != want {
+			t.Errorf("host of %q = %q, want %q", href, got, want)
+		}
+	}
+}
+
+func TestDomainPattern(t *testing.T) {
+	re := regexp.MustCompile("(?i)" + domainPattern("WWW.Example.edu"))
+	for href, want := range map[string]bool{
+		"https://example.edu":           true,
+		"https://example.edu/solar":     true,
+		"https://www.example.edu/solar": true,
+		"http://EXAMPLE.EDU:443/x":      true,
+		"https://example.edu?q=1":       true,
+		"https://news.example.edu/a":    false,
+		"https://example.edu.evil.com":  false,
+		"https://exampleXedu/a":         false,
+		"https://notexample.edu/a":      false,
+	} {
+		if got := re.MatchString(href); got != want {
+			t.Errorf("match %q = %v, want %v", href, got, want)
+		}
+	}
+}
+
+func TestResearchIndexes(t *testing.T) {
+	names := map[string]bool{}
+	var userCreated bson.D
+	for _, idx := range researchIndexes() {
+		name := *idx.Options.Name
+		if names[name] {
+			t.Errorf("index name %q used twice", name)
+		}
+		names[name] = true
+		if name == "user_created" {
+			userCreated = idx.Keys.(bson.D)
+		}
+	}
+	want := bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}
+	if !reflect.DeepEqual(userCreated, want) {
+		t.Errorf("user_created keys = %v, want %v", userCreated, want)
+	}
+}
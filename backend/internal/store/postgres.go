@@ -2,10 +2,13 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/ayush/research-ai-agent/backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // PostgresStore handles user CRUD against PostgreSQL.
@@ -26,7 +29,22 @@ func (s *PostgresStore) Migrate(ctx context.Context) error {
 			email      VARCHAR(255) UNIQUE NOT NULL,
 			password   VARCHAR(255) NOT NULL,
 			created_at TIMESTAMPTZ  DEFAULT NOW()
-		)
+		);
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS api_key_enc BYTEA;
+		-- Users from before verification existed count as verified: the
+		-- column is added with TRUE for them, then new users default to FALSE.
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT TRUE;
+		ALTER TABLE users ALTER COLUMN email_verified SET DEFAULT FALSE;
+		CREATE TABLE IF NOT EXISTS personal_access_tokens (
+			id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id      UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name         VARCHAR(100) NOT NULL,
+			prefix       VARCHAR(20)  NOT NULL,
+			token_hash   BYTEA        UNIQUE NOT NULL,
+			created_at   TIMESTAMPTZ  DEFAULT NOW(),
+			last_used_at TIMESTAMPTZ
+		);
+		CREATE INDEX IF NOT EXISTS personal_access_tokens_user_id ON personal_access_tokens (user_id);
 	`)
 	return err
 }
@@ -48,8 +66,9 @@ func (s *PostgresStore) CreateUser(ctx context.Context, username, email, hashedP
 func (s *PostgresStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	var u models.User
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, username, email, password, created_at FROM users WHERE email = $1`, email,
-	).Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.CreatedAt)
+		`SELECT id, username, email, password, created_at, api_key_enc IS NOT NULL, email_verified
+		 FROM users WHERE email = $1`, email,
+	).Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.CreatedAt, &u.HasAPIKey, &u.EmailVerified)
 	if err != nil {
 		return nil, err
 	}
@@ -59,10 +78,165 @@ func (s *PostgresStore) GetUserByEmail(ctx context.Context, email string) (*mode
 func (s *PostgresStore) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	var u models.User
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, username, email, created_at FROM users WHERE id = $1`, id,
-	).Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt)
+		`SELECT id, username, email, created_at, api_key_enc IS NOT NULL, email_verified
+		 FROM users WHERE id = $1`, id,
+	).Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt, &u.HasAPIKey, &u.EmailVerified)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetPasswordHash returns the bcrypt password hash of a user.
+func (s *PostgresStore) GetPasswordHash(ctx context.Context, userID string) (string, error) {
+	var hash string
+	err := s.pool.QueryRow(ctx, `SELECT password FROM users WHERE id = $1`, userID).Scan(&hash)
+	return hash, err
+}
+
+// UpdatePassword replaces the bcrypt password hash of a user.
+func (s *PostgresStore) UpdatePassword(ctx context.Context, userID, hashedPassword string) error {
+	tag, err := s.pool.Exec(ctx, `UPDATE users SET password = $2 WHERE id = $1`, userID, hashedPassword)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateUser changes the username and/or email of a user; empty values are
+// left as they are. Changing the email clears email_verified. It returns
+// models.ErrUserConflict if the new username or email is taken.
+func (s *PostgresStore) UpdateUser(ctx context.Context, userID, username, email string) (*models.User, error) {
+	var u models.User
+	err := s.pool.QueryRow(ctx,
+		`UPDATE users SET
+			username       = COALESCE(NULLIF($2, ''), username),
+			email          = COALESCE(NULLIF($3, ''), email),
+			email_verified = email_verified AND (NULLIF($3, '') IS NULL OR $3 = email)
+		 WHERE id = $1
+		 RETURNING id, username, email, created_at, api_key_enc IS NOT NULL, email_verified`,
+		userID, username, email,
+	).Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt, &u.HasAPIKey, &u.EmailVerified)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, models.ErrUserConflict
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &u, nil
 }
+
+// SetEmailVerified marks a user's email as verified if it is still email,
+// so a link sent to an old address can't verify a newer one.
+func (s *PostgresStore) SetEmailVerified(ctx context.Context, userID, email string) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE users SET email_verified = TRUE WHERE id = $1 AND email = $2`, userID, email)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteUser removes a user row. Access tokens go with it.
+func (s *PostgresStore) DeleteUser(ctx context.Context, userID string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// SetAPIKey stores the encrypted provider API key of a user.
+func (s *PostgresStore) SetAPIKey(ctx context.Context, userID string, encrypted []byte) error {
+	_, err := s.pool.Exec(ctx, `UPDATE users SET api_key_enc = $2 WHERE id = $1`, userID, encrypted)
+	return err
+}
+
+// ClearAPIKey removes the stored provider API key of a user.
+func (s *PostgresStore) ClearAPIKey(ctx context.Context, userID string) error {
+	_, err := s.pool.Exec(ctx, `UPDATE users SET api_key_enc = NULL WHERE id = $1`, userID)
+	return err
+}
+
+// GetAPIKey returns the encrypted provider API key of a user, or nil.
+func (s *PostgresStore) GetAPIKey(ctx context.Context, userID string) ([]byte, error) {
+	var enc []byte
+	err := s.pool.QueryRow(ctx, `SELECT api_key_enc FROM users WHERE id = $1`, userID).Scan(&enc)
+	if err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+// CreateAccessToken stores the hash of a new personal access token.
+func (s *PostgresStore) CreateAccessToken(ctx context.Context, userID, name, prefix string, hash []byte) (*models.AccessToken, error) {
+	t := models.AccessToken{Name: name, Prefix: prefix}
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		userID, name, prefix, hash,
+	).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create access token: %w", err)
+	}
+	return &t, nil
+}
+
+// ListAccessTokens returns a user's access tokens, newest first.
+func (s *PostgresStore) ListAccessTokens(ctx context.Context, userID string) ([]models.AccessToken, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, name, prefix, created_at, last_used_at
+		 FROM personal_access_tokens WHERE user_id = $1
+		 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tokens []models.AccessToken
+	for rows.Next() {
+		var t models.AccessToken
+		if err := rows.Scan(&t.ID, &t.Name, &t.Prefix, &t.CreatedAt, &t.LastUsedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// DeleteAccessToken revokes one of a user's access tokens.
+func (s *PostgresStore) DeleteAccessToken(ctx context.Context, userID, id string) error {
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrTokenNotFound
+	}
+	return nil
+}
+
+// UserIDForToken returns the owner of the token with the given hash and
+// records the use, or "" if no such token exists.
+func (s *PostgresStore) UserIDForToken(ctx context.Context, hash []byte) (string, error) {
+	var userID string
+	err := s.pool.QueryRow(ctx,
+		`UPDATE personal_access_tokens SET last_used_at = NOW()
+		 WHERE token_hash = $1 RETURNING user_id`, hash,
+	).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return userID, err
+}
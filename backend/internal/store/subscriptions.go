@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// maxSeenURLs caps the seen-URL set per subscription; the oldest entries
+// are dropped first.
+const maxSeenURLs = 5000
+
+// SubscriptionStore persists topic subscriptions in MongoDB.
+type SubscriptionStore struct {
+	col *mongo.Collection
+}
+
+func NewSubscriptionStore(db *mongo.Database) *SubscriptionStore {
+	return &SubscriptionStore{col: db.Collection("subscriptions")}
+}
+
+// EnsureIndexes creates the indexes used to list and schedule subscriptions.
+func (s *SubscriptionStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "next_run_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo ensure subscription indexes: %w", err)
+	}
+	return nil
+}
+
+func (s *SubscriptionStore) InsertSubscription(ctx context.Context, sub *models.Subscription) error {
+	sub.CreatedAt = time.Now()
+	if sub.SeenURLs == nil {
+		sub.SeenURLs = []string{}
+	}
+	res, err := s.col.InsertOne(ctx, sub)
+	if err != nil {
+		return fmt.Errorf("mongo insert subscription: %w", err)
+	}
+	sub.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ListSubscriptions returns a user's subscriptions, newest first.
+func (s *SubscriptionStore) ListSubscriptions(ctx context.Context, userID string) ([]models.Subscription, error) {
+	cur, err := s.col.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetProjection(bson.M{"seen_urls": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var subs []models.Subscription
+	if err := cur.All(ctx, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// DeleteSubscription removes one of a user's subscriptions.
+func (s *SubscriptionStore) DeleteSubscription(ctx context.Context, userID, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrSubscriptionNotFound
+	}
+	res, err := s.col.DeleteOne(ctx, bson.M{"_id": oid, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return models.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// DeleteUserSubscriptions removes all subscriptions of a user.
+func (s *SubscriptionStore) DeleteUserSubscriptions(ctx context.Context, userID string) error {
+	_, err := s.col.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// ClaimDue returns a subscription whose next run is due and pushes its
+// next run out by lease, so concurrent workers don't pick it up too. It
+// returns nil when nothing is due.
+func (s *SubscriptionStore) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.Subscription, error) {
+	var sub models.Subscription
+	err := s.col.FindOneAndUpdate(ctx,
+		bson.M{"next_run_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"next_run_at": now.Add(lease)}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "next_run_at", Value: 1}}),
+	).Decode(&sub)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// RecordRun adds newly seen URLs to a subscription and schedules its next run.
+func (s *SubscriptionStore) RecordRun(ctx context.Context, id primitive.ObjectID, seen []string, ranAt, next time.Time) error {
+	update := bson.M{"$set": bson.M{"last_run_at": ranAt, "next_run_at": next}}
+	if len(seen) > 0 {
+		update["$push"] = bson.M{"seen_urls": bson.M{"$each": seen, "$slice": -maxSeenURLs}}
+	}
+	_, err := s.col.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// PrefixSweeper can delete stored objects under a key prefix by age.
+type PrefixSweeper interface {
+	RemoveOlderThan(ctx context.Context, prefix string, cutoff time.Time) (int, error)
+}
+
+// RunSweeper removes objects under prefix older than retention every
+// interval until ctx is done. It is used for short-lived guest artifacts,
+// whose keys live under a dedicated prefix that never overlaps with
+// registered users' user-ID prefixes. It returns at once if interval
+// isn't positive.
+func RunSweeper(ctx context.Context, s PrefixSweeper, prefix string, retention, interval time.Duration) {
+	if interval <= 0 {
+		log.Printf("sweep %s: interval %v, sweeping disabled", prefix, interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := s.RemoveOlderThan(ctx, prefix, time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("sweep %s: %v", prefix, err)
+		} else if n > 0 {
+			log.Printf("sweep %s: removed %d expired objects", prefix, n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLocalRemoveOlderThan(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewLocalFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	for key, mtime := range map[string]time.Time{
+		"guest/abc/report.pdf":  old,
+		"guest/abc/report.tex":  old,
+		"guest/def/report.pdf":  time.Now(),
+		"user-a/doc/report.pdf": old,
+		"guestbook/notes.txt":   old,
+	} {
+		if err := s.Upload(ctx, key, []byte("x"), ""); err != nil {
+			t.Fatal(err)
+		}
+		p := filepath.Join(dir, filepath.FromSlash(key))
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := s.RemoveOlderThan(ctx, "guest/", time.Now().Add(-24*time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("RemoveOlderThan = %d, %v; want 2 removed", n, err)
+	}
+	for key, want := range map[string]bool{
+		"guest/abc/report.pdf":  false,
+		"guest/abc/report.tex":  false,
+		"guest/def/report.pdf":  true,
+		"user-a/doc/report.pdf": true,
+		"guestbook/notes.txt":   true,
+	} {
+		_, _, err := s.Download(ctx, key)
+		if got := err == nil; got != want {
+			t.Errorf("%s kept = %v, want %v", key, got, want)
+		}
+	}
+
+	if n, err := s.RemoveOlderThan(ctx, "nobody/", time.Now()); err != nil || n != 0 {
+		t.Errorf("missing prefix: got %d, %v", n, err)
+	}
+}
+
+// countingSweeper records each sweep.
+type countingSweeper struct {
+	calls  atomic.Int32
+	prefix atomic.Value
+}
+
+func (c *countingSweeper) RemoveOlderThan(ctx context.Context, prefix string, cutoff time.Time) (int, error) {
+	c.prefix.Store(prefix)
+	c.calls.Add(1)
+	return 0, nil
+}
+
+func TestRunSweeper(t *testing.T) {
+	s := &countingSweeper{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunSweeper(ctx, s, "guest/", time.Hour, 10*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := s.calls.Load(); n < 2 {
+		t.Errorf("swept %d times, want repeated sweeps", n)
+	}
+	if p, _ := s.prefix.Load().(string); p != "guest/" {
+		t.Errorf("swept prefix %q", p)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunSweeper did not stop when the context was cancelled")
+	}
+}
+
+func TestRunSweeperWithoutInterval(t *testing.T) {
+	s := &countingSweeper{}
+	done := make(chan struct{})
+	go func() {
+		RunSweeper(context.Background(), s, "guest/", time.Hour, 0)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunSweeper with a zero interval did not return")
+	}
+	if n := s.calls.Load(); n != 0 {
+		t.Errorf("swept %d times, want none", n)
+	}
+}
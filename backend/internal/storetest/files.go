@@ -0,0 +1,131 @@
+package storetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// FileStore keeps files in memory. It implements research.FileStore and,
+// like the real stores, store.PrefixSweeper.
+type FileStore struct {
+	mu    sync.Mutex
+	files map[string]file
+}
+
+type file struct {
+	data []byte
+	info models.ObjectInfo
+}
+
+func NewFileStore() *FileStore {
+	return &FileStore{files: make(map[string]file)}
+}
+
+// notExist is what reading a missing key returns; it matches fs.ErrNotExist.
+func notExist(key string) error {
+	return &fs.PathError{Op: "open", Path: key, Err: fs.ErrNotExist}
+}
+
+// Ping always succeeds.
+func (s *FileStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Upload stores a copy of data under key.
+func (s *FileStore) Upload(ctx context.Context, key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = file{
+		data: append([]byte(nil), data...),
+		info: models.ObjectInfo{
+			ContentType: contentType,
+			Size:        int64(len(data)),
+			ModTime:     time.Now(),
+			ETag:        fmt.Sprintf(`"%x"`, md5.Sum(data)),
+		},
+	}
+	return nil
+}
+
+func (s *FileStore) get(key string) (file, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[key]
+	if !ok {
+		return file{}, notExist(key)
+	}
+	return f, nil
+}
+
+// Download returns a copy of the file and its content type.
+func (s *FileStore) Download(ctx context.Context, key string) ([]byte, string, error) {
+	f, err := s.get(key)
+	if err != nil {
+		return nil, "", err
+	}
+	return append([]byte(nil), f.data...), f.info.ContentType, nil
+}
+
+// DownloadStream opens the file for key for reading.
+func (s *FileStore) DownloadStream(ctx context.Context, key string) (io.ReadSeekCloser, models.ObjectInfo, error) {
+	f, err := s.get(key)
+	if err != nil {
+		return nil, models.ObjectInfo{}, err
+	}
+	return nopCloser{bytes.NewReader(f.data)}, f.info, nil
+}
+
+type nopCloser struct{ *bytes.Reader }
+
+func (nopCloser) Close() error { return nil }
+
+// Stat returns the metadata of the file for key.
+func (s *FileStore) Stat(ctx context.Context, key string) (models.ObjectInfo, error) {
+	f, err := s.get(key)
+	return f.info, err
+}
+
+// Remove deletes the file for key. Missing files are not an error.
+func (s *FileStore) Remove(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, key)
+	return nil
+}
+
+// RemoveOlderThan deletes files under prefix last written before cutoff
+// and returns how many it removed.
+func (s *FileStore) RemoveOlderThan(ctx context.Context, prefix string, cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for key, f := range s.files {
+		if strings.HasPrefix(key, prefix) && f.info.ModTime.Before(cutoff) {
+			delete(s.files, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Keys returns the stored keys in order, for assertions.
+func (s *FileStore) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.files))
+	for k := range s.files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,553 @@
+// Package storetest provides in-memory stand-ins for the stores handlers
+// depend on, so handlers can be exercised with httptest without MongoDB,
+// PostgreSQL, Redis or MinIO. They follow the real stores' semantics: the
+// same not-found errors, ownership-agnostic lookups by ID, and updates of
+// missing documents that quietly do nothing.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// ResearchStore keeps research documents and their versions in memory. It
+// implements research.ResearchStore.
+type ResearchStore struct {
+	mu       sync.Mutex
+	docs     map[primitive.ObjectID]*models.Document
+	versions map[string][]models.Version // doc ID → versions, oldest first
+}
+
+func NewResearchStore() *ResearchStore {
+	return &ResearchStore{
+		docs:     make(map[primitive.ObjectID]*models.Document),
+		versions: make(map[string][]models.Version),
+	}
+}
+
+// clone copies v through BSON, so callers get the same independent,
+// millisecond-precision values a round trip through MongoDB gives them.
+func clone[T any](v *T) *T {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("storetest: marshal %T: %v", v, err))
+	}
+	out := new(T)
+	if err := bson.Unmarshal(data, out); err != nil {
+		panic(fmt.Sprintf("storetest: unmarshal %T: %v", v, err))
+	}
+	return out
+}
+
+func parseID(id string) (primitive.ObjectID, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return oid, fmt.Errorf("invalid id: %w", err)
+	}
+	return oid, nil
+}
+
+// find returns the stored document for id, or nil. Callers hold mu.
+func (s *ResearchStore) find(id string) (*models.Document, error) {
+	oid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.docs[oid], nil
+}
+
+// sorted returns copies of the documents matching keep, newest first.
+// Callers hold mu.
+func (s *ResearchStore) sorted(keep func(*models.Document) bool) []models.Document {
+	var docs []models.Document
+	for _, d := range s.docs {
+		if keep(d) {
+			docs = append(docs, *clone(d))
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if !docs[i].CreatedAt.Equal(docs[j].CreatedAt) {
+			return docs[i].CreatedAt.After(docs[j].CreatedAt)
+		}
+		return docs[i].ID.Hex() > docs[j].ID.Hex()
+	})
+	return docs
+}
+
+func live(userID string) func(*models.Document) bool {
+	return func(d *models.Document) bool { return d.UserID == userID && d.DeletedAt == nil }
+}
+
+func (s *ResearchStore) Insert(ctx context.Context, doc *models.Document) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc.CreatedAt = time.Now()
+	doc.ID = primitive.NewObjectID()
+	s.docs[doc.ID] = clone(doc)
+	return doc.ID.Hex(), nil
+}
+
+// ListByUser returns one page of a user's documents, newest first, and the
+// cursor for the next page ("" when there are no more).
+func (s *ResearchStore) ListByUser(ctx context.Context, userID string, opts models.ListOptions) ([]models.Document, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last *models.Document
+	if opts.Cursor != "" {
+		oid, err := primitive.ObjectIDFromHex(opts.Cursor)
+		if err != nil {
+			return nil, "", models.ErrInvalidCursor
+		}
+		last = s.docs[oid]
+		if last == nil || last.UserID != userID {
+			return nil, "", models.ErrInvalidCursor
+		}
+	}
+
+	docs := s.sorted(func(d *models.Document) bool {
+		switch {
+		case d.UserID != userID || (d.DeletedAt != nil) != opts.Trashed:
+			return false
+		case opts.Model != "" && d.ModelUsed != opts.Model:
+			return false
+		case !opts.From.IsZero() && d.CreatedAt.Before(opts.From):
+			return false
+		case !opts.To.IsZero() && d.CreatedAt.After(opts.To):
+			return false
+		case opts.Tag != "" && !contains(d.Tags, opts.Tag):
+			return false
+		case opts.Domain != "" && !citesDomain(d, opts.Domain):
+			return false
+		case last != nil && !(d.CreatedAt.Before(last.CreatedAt) ||
+			d.CreatedAt.Equal(last.CreatedAt) && d.ID.Hex() < last.ID.Hex()):
+			return false
+		}
+		return true
+	})
+
+	next := ""
+	if len(docs) > opts.Limit {
+		docs = docs[:opts.Limit]
+		next = docs[len(docs)-1].ID.Hex()
+	}
+	return docs, next, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// host returns the lower-cased host of a source URL minus any "www.", or
+// "" if it has none.
+func host(href string) string {
+	u, err := url.Parse(href)
+	if err != nil || u.Scheme == "" {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func citesDomain(d *models.Document, domain string) bool {
+	domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+	for _, src := range d.Sources {
+		if host(src.Href) == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchByUser matches documents whose topic or report contains any word of
+// query, ignoring case, newest first. It stands in for MongoDB's text
+// search, which also ranks by relevance.
+func (s *ResearchStore) SearchByUser(ctx context.Context, userID, query string) ([]models.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	words := strings.Fields(strings.ToLower(query))
+	docs := s.sorted(func(d *models.Document) bool {
+		if !live(userID)(d) {
+			return false
+		}
+		text := strings.ToLower(d.Topic + " " + d.LatexContent)
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				return true
+			}
+		}
+		return false
+	})
+	if len(docs) > 50 {
+		docs = docs[:50]
+	}
+	return docs, nil
+}
+
+// DomainsByUser counts citations per source host across all of a user's
+// documents, most cited first.
+func (s *ResearchStore) DomainsByUser(ctx context.Context, userID string) ([]models.DomainCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byDomain := make(map[string]*models.DomainCount)
+	for _, d := range s.docs {
+		if !live(userID)(d) {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, src := range d.Sources {
+			h := host(src.Href)
+			if h == "" {
+				continue
+			}
+			c := byDomain[h]
+			if c == nil {
+				c = &models.DomainCount{Domain: h}
+				byDomain[h] = c
+			}
+			c.Citations++
+			if !seen[h] {
+				seen[h] = true
+				c.Documents++
+			}
+		}
+	}
+	counts := make([]models.DomainCount, 0, len(byDomain))
+	for _, c := range byDomain {
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Citations != counts[j].Citations {
+			return counts[i].Citations > counts[j].Citations
+		}
+		return counts[i].Domain < counts[j].Domain
+	})
+	return counts, nil
+}
+
+// TagsByUser counts the tags on a user's live documents, most used first.
+func (s *ResearchStore) TagsByUser(ctx context.Context, userID string) ([]models.TagCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byTag := make(map[string]int)
+	for _, d := range s.docs {
+		if live(userID)(d) {
+			for _, t := range d.Tags {
+				byTag[t]++
+			}
+		}
+	}
+	counts := make([]models.TagCount, 0, len(byTag))
+	for t, n := range byTag {
+		counts = append(counts, models.TagCount{Tag: t, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Tag < counts[j].Tag
+	})
+	return counts, nil
+}
+
+// StatsByUser counts a user's live documents, overall and per model, and
+// sums their stored file sizes.
+func (s *ResearchStore) StatsByUser(ctx context.Context, userID string) (*models.ResearchStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := &models.ResearchStats{ByModel: []models.ModelCount{}}
+	byModel := make(map[string]int)
+	var latest time.Time
+	for _, d := range s.docs {
+		if !live(userID)(d) {
+			continue
+		}
+		stats.Total++
+		stats.StorageBytes += d.PDFSize + d.TexSize
+		byModel[d.ModelUsed]++
+		if d.CreatedAt.After(latest) {
+			latest = d.CreatedAt
+		}
+	}
+	if stats.Total == 0 {
+		return stats, nil
+	}
+	stats.LatestAt = &latest
+	for m, n := range byModel {
+		stats.ByModel = append(stats.ByModel, models.ModelCount{Model: m, Count: n})
+	}
+	sort.Slice(stats.ByModel, func(i, j int) bool {
+		if stats.ByModel[i].Count != stats.ByModel[j].Count {
+			return stats.ByModel[i].Count > stats.ByModel[j].Count
+		}
+		return stats.ByModel[i].Model < stats.ByModel[j].Model
+	})
+	return stats, nil
+}
+
+// update applies fn to the stored document for id, if there is one.
+func (s *ResearchStore) update(id string, fn func(*models.Document)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.find(id)
+	if err != nil || d == nil {
+		return err
+	}
+	fn(d)
+	return nil
+}
+
+// SetTags replaces the tags of a document.
+func (s *ResearchStore) SetTags(ctx context.Context, id string, tags []string) error {
+	return s.update(id, func(d *models.Document) {
+		d.Tags = slices.Clone(tags)
+	})
+}
+
+// SetSources replaces the sources of a document.
+func (s *ResearchStore) SetSources(ctx context.Context, id string, sources []models.Source) error {
+	return s.update(id, func(d *models.Document) {
+		d.Sources = slices.Clone(sources)
+	})
+}
+
+// EachByUser calls fn for every document of a user, trashed ones included,
+// newest first. It stops at the first error fn returns.
+func (s *ResearchStore) EachByUser(ctx context.Context, userID string, fn func(*models.Document) error) error {
+	s.mu.Lock()
+	docs := s.sorted(func(d *models.Document) bool { return d.UserID == userID })
+	s.mu.Unlock()
+	for i := range docs {
+		if err := fn(&docs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByID returns the document for id whoever owns it, or
+// mongo.ErrNoDocuments.
+func (s *ResearchStore) GetByID(ctx context.Context, id string) (*models.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return clone(d), nil
+}
+
+// Update replaces a stored document, keeping its ID.
+func (s *ResearchStore) Update(ctx context.Context, id string, doc *models.Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	doc.ID = oid
+	if s.docs[oid] != nil {
+		s.docs[oid] = clone(doc)
+	}
+	return nil
+}
+
+// SaveResult records the outcome of a pipeline run, leaving the fields the
+// run doesn't own as they are.
+func (s *ResearchStore) SaveResult(ctx context.Context, id string, doc *models.Document) error {
+	res := clone(doc)
+	return s.update(id, func(d *models.Document) {
+		d.LatexContent, d.Sources, d.SearchQueries = res.LatexContent, res.Sources, res.SearchQueries
+		d.ModelUsed, d.RequestedModel = res.ModelUsed, res.RequestedModel
+		d.PDFObjectKey, d.TexObjectKey = res.PDFObjectKey, res.TexObjectKey
+		d.PDFSize, d.TexSize = res.PDFSize, res.TexSize
+		d.Status, d.Step, d.Error, d.CompileError = res.Status, res.Step, res.Error, res.CompileError
+		d.StepTimings = res.StepTimings
+	})
+}
+
+// SetStatus records the job status, current step, and error message.
+func (s *ResearchStore) SetStatus(ctx context.Context, id, status, step, errMsg string) error {
+	return s.update(id, func(d *models.Document) {
+		d.Status, d.Step, d.Error = status, step, errMsg
+	})
+}
+
+// CancelActive marks a pending or running document cancelled and reports
+// whether it was.
+func (s *ResearchStore) CancelActive(ctx context.Context, id, step, errMsg string) (bool, error) {
+	cancelled := false
+	err := s.update(id, func(d *models.Document) {
+		if d.Status == models.StatusPending || d.Status == models.StatusRunning {
+			d.Status, d.Step, d.Error = models.StatusCancelled, step, errMsg
+			cancelled = true
+		}
+	})
+	return cancelled, err
+}
+
+// SetStepTimings records the step durations of a run.
+func (s *ResearchStore) SetStepTimings(ctx context.Context, id string, timings map[string]int64) error {
+	return s.update(id, func(d *models.Document) {
+		d.StepTimings = make(map[string]int64, len(timings))
+		for k, v := range timings {
+			d.StepTimings[k] = v
+		}
+	})
+}
+
+func (s *ResearchStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	delete(s.docs, oid)
+	return nil
+}
+
+// BulkUpdateTags adds and removes tags on those of ids that belong to userID
+// and returns the IDs that were updated. Invalid, foreign or trashed IDs are
+// skipped.
+func (s *ResearchStore) BulkUpdateTags(ctx context.Context, userID string, ids, add, remove []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var updated []string
+	for _, id := range ids {
+		d, err := s.find(id)
+		if err != nil || d == nil || !live(userID)(d) {
+			continue
+		}
+		for _, t := range add {
+			if !contains(d.Tags, t) {
+				d.Tags = append(d.Tags, t)
+			}
+		}
+		kept := d.Tags[:0]
+		for _, t := range d.Tags {
+			if !contains(remove, t) {
+				kept = append(kept, t)
+			}
+		}
+		d.Tags = kept
+		updated = append(updated, d.ID.Hex())
+	}
+	return updated, nil
+}
+
+// AppendVersion stores v as the next version of its document and sets
+// v.Version.
+func (s *ResearchStore) AppendVersion(ctx context.Context, v *models.Version) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.versions[v.DocID]
+	v.Version = 1
+	if len(list) > 0 {
+		v.Version = list[len(list)-1].Version + 1
+	}
+	v.CreatedAt = time.Now()
+	v.ID = primitive.NewObjectID()
+	s.versions[v.DocID] = append(list, *clone(v))
+	return nil
+}
+
+// ListVersions returns a document's versions, newest first, without their
+// LaTeX bodies or sources.
+func (s *ResearchStore) ListVersions(ctx context.Context, docID string) ([]models.Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.versions[docID]
+	var versions []models.Version
+	for i := len(list) - 1; i >= 0; i-- {
+		v := *clone(&list[i])
+		v.LatexContent, v.Sources = "", nil
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// GetVersion returns one version of a document, or mongo.ErrNoDocuments.
+func (s *ResearchStore) GetVersion(ctx context.Context, docID string, version int) (*models.Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.versions[docID] {
+		if v := &s.versions[docID][i]; v.Version == version {
+			return clone(v), nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// DeleteVersions removes every version of a document.
+func (s *ResearchStore) DeleteVersions(ctx context.Context, docID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.versions, docID)
+	return nil
+}
+
+// SetDeleted moves a document to the trash (at != nil) or restores it.
+func (s *ResearchStore) SetDeleted(ctx context.Context, id string, at *time.Time) error {
+	return s.update(id, func(d *models.Document) {
+		d.DeletedAt = nil
+		if at != nil {
+			t := *at
+			d.DeletedAt = &t
+		}
+	})
+}
+
+// DeletedBefore returns up to limit documents trashed before cutoff,
+// without their LaTeX bodies or sources.
+func (s *ResearchStore) DeletedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs := s.sorted(func(d *models.Document) bool {
+		return d.DeletedAt != nil && d.DeletedAt.Before(cutoff)
+	})
+	if limit > 0 && len(docs) > limit {
+		docs = docs[:limit]
+	}
+	for i := range docs {
+		docs[i].LatexContent, docs[i].Sources = "", nil
+	}
+	return docs, nil
+}
+
+// CreatedBefore returns up to limit documents created before cutoff by
+// users whose ID starts with userPrefix, without their LaTeX bodies or
+// sources.
+func (s *ResearchStore) CreatedBefore(ctx context.Context, userPrefix string, cutoff time.Time, limit int) ([]models.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs := s.sorted(func(d *models.Document) bool {
+		return strings.HasPrefix(d.UserID, userPrefix) && d.CreatedAt.Before(cutoff)
+	})
+	if limit > 0 && len(docs) > limit {
+		docs = docs[:limit]
+	}
+	for i := range docs {
+		docs[i].LatexContent, docs[i].Sources = "", nil
+	}
+	return docs, nil
+}
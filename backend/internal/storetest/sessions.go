@@ -0,0 +1,64 @@
+package storetest
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/ayush/research-ai-agent/backend/internal/auth"
+)
+
+// Sessions authenticates test requests without Redis. It implements
+// auth.TokenResolver, so middleware.RequireAuth(nil, sessions) accepts the
+// bearer tokens Login hands out; requests without one, session cookies
+// included, get a 401.
+// Bearer requests also skip the CSRF check, as they do in production.
+type Sessions struct {
+	mu     sync.Mutex
+	tokens map[string]string // token hash → user ID
+	// Err, when set, is returned by every lookup to simulate an outage.
+	Err error
+}
+
+func NewSessions() *Sessions {
+	return &Sessions{tokens: make(map[string]string)}
+}
+
+// Login starts a session for userID and returns its bearer token.
+func (s *Sessions) Login(userID string) string {
+	token, hash, err := auth.NewAccessToken()
+	if err != nil {
+		panic("storetest: " + err.Error())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[string(hash)] = userID
+	return token
+}
+
+// Logout ends every session of userID.
+func (s *Sessions) Logout(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, uid := range s.tokens {
+		if uid == userID {
+			delete(s.tokens, hash)
+		}
+	}
+}
+
+// UserIDForToken returns the user a token hash belongs to, or "".
+func (s *Sessions) UserIDForToken(ctx context.Context, hash []byte) (string, error) {
+	if s.Err != nil {
+		return "", s.Err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[string(hash)], nil
+}
+
+// Authorize adds token to r as a bearer token and returns r.
+func Authorize(r *http.Request, token string) *http.Request {
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
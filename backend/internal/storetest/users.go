@@ -0,0 +1,156 @@
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/ayush/research-ai-agent/backend/internal/models"
+)
+
+// UserStore keeps user accounts in memory. It implements auth.UserStore,
+// account.UserStore and account.UserRemover; lookups of unknown users
+// return pgx.ErrNoRows like the PostgreSQL store.
+type UserStore struct {
+	mu    sync.Mutex
+	users map[string]*models.User // by ID, with the password hash
+}
+
+func NewUserStore() *UserStore {
+	return &UserStore{users: make(map[string]*models.User)}
+}
+
+// taken reports whether another user than id has username or email.
+// Callers hold mu.
+func (s *UserStore) taken(id, username, email string) bool {
+	for _, u := range s.users {
+		if u.ID == id {
+			continue
+		}
+		if username != "" && u.Username == username || email != "" && strings.EqualFold(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// public returns a copy of u as GetUserByID and friends return it, without
+// the password hash.
+func public(u *models.User) *models.User {
+	c := *u
+	c.Password = ""
+	return &c
+}
+
+func (s *UserStore) CreateUser(ctx context.Context, username, email, hashedPassword string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.taken("", username, email) {
+		return nil, fmt.Errorf("create user: %w", models.ErrUserConflict)
+	}
+	u := &models.User{
+		ID:        uuid.NewString(),
+		Username:  username,
+		Email:     email,
+		Password:  hashedPassword,
+		CreatedAt: time.Now(),
+	}
+	s.users[u.ID] = u
+	return public(u), nil
+}
+
+func (s *UserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == email {
+			c := *u
+			return &c, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (s *UserStore) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return public(u), nil
+}
+
+// GetPasswordHash returns the bcrypt password hash of a user.
+func (s *UserStore) GetPasswordHash(ctx context.Context, userID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return u.Password, nil
+}
+
+// UpdatePassword replaces the bcrypt password hash of a user.
+func (s *UserStore) UpdatePassword(ctx context.Context, userID, hashedPassword string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	u.Password = hashedPassword
+	return nil
+}
+
+// UpdateUser changes the username and/or email of a user; empty values are
+// left as they are. Changing the email clears EmailVerified. It returns
+// models.ErrUserConflict if the new username or email is taken.
+func (s *UserStore) UpdateUser(ctx context.Context, userID, username, email string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	if s.taken(userID, username, email) {
+		return nil, models.ErrUserConflict
+	}
+	if username != "" {
+		u.Username = username
+	}
+	if email != "" && email != u.Email {
+		u.Email = email
+		u.EmailVerified = false
+	}
+	return public(u), nil
+}
+
+// SetEmailVerified marks a user's email as verified if it is still email.
+func (s *UserStore) SetEmailVerified(ctx context.Context, userID, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[userID]
+	if !ok || u.Email != email {
+		return pgx.ErrNoRows
+	}
+	u.EmailVerified = true
+	return nil
+}
+
+// DeleteUser removes a user.
+func (s *UserStore) DeleteUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[userID]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(s.users, userID)
+	return nil
+}
@@ -0,0 +1,35 @@
+// Package version reports which build of the backend is running. The
+// variables are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/ayush/research-ai-agent/backend/internal/version.Commit=$(git rev-parse HEAD)"
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+)
+
+// Set with -ldflags -X; left alone they identify a local build.
+var (
+	Commit    = "dev"
+	BuildTime = "unknown"
+)
+
+// Info describes the running build.
+type Info struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the running build's info.
+func Get() Info {
+	return Info{Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+}
+
+// Handler serves the build info as JSON.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Get())
+}
@@ -1,19 +1,29 @@
 import type { User, Research, CreateResearchRequest } from "@/types";
 
-const BASE = "/api";
+const BASE = "/api/v1";
+
+// csrfToken reads the CSRF cookie the backend expects echoed on unsafe requests.
+function csrfToken(): string {
+  const match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]*)/);
+  return match ? decodeURIComponent(match[1]) : "";
+}
 
 async function request<T>(url: string, opts?: RequestInit): Promise<T> {
+  const method = (opts?.method || "GET").toUpperCase();
+  const csrf = method === "GET" || method === "HEAD" ? "" : csrfToken();
   const res = await fetch(BASE + url, {
     credentials: "include",
     ...opts,
     headers: {
       "Content-Type": "application/json",
+      ...(csrf ? { "X-CSRF-Token": csrf } : {}),
       ...(opts?.headers || {}),
     },
   });
   if (!res.ok) {
     const body = await res.json().catch(() => ({}));
-    throw new Error(body.error || `Request failed: ${res.status}`);
+    // Errors look like {"error":{"code":"...","message":"..."}}.
+    throw new Error(body.error?.message || `Request failed: ${res.status}`);
   }
   return res.json();
 }
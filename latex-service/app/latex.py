@@ -97,6 +97,42 @@ def _truncate_title(title: str, max_words: int = 8) -> str:
     return " ".join(words[:max_words]) + " \\ldots"
 
 
+# Replacements that typeset each LaTeX special character literally.
+_TEXT_ESCAPES = {
+    "\\": r"\textbackslash{}",
+    "{": r"\{",
+    "}": r"\}",
+    "$": r"\$",
+    "&": r"\&",
+    "%": r"\%",
+    "#": r"\#",
+    "_": r"\_",
+    "~": r"\textasciitilde{}",
+    "^": r"\textasciicircum{}",
+}
+
+
+def _escape_text(text: str) -> str:
+    """Escape text so every character, LaTeX specials included, is printed
+    as written. Unlike _escape_title it handles backslashes and braces, so
+    it suits text that is never meant to contain markup."""
+    return re.sub(r"[\\{}$&%#_~^]", lambda m: _TEXT_ESCAPES[m.group(0)], text)
+
+
+def _watermark_preamble(text: str) -> str:
+    """Preamble lines that print text diagonally across every page."""
+    safe = _escape_text(text)
+    # "DRAFT" fills the page at the package default; longer text shrinks.
+    scale = max(0.3, min(1.2, 6.0 / max(len(text), 1)))
+    return (
+        r"\usepackage{draftwatermark}" "\n"
+        r"\SetWatermarkText{" + safe + "}\n"
+        r"\SetWatermarkScale{" + f"{scale:.2f}" + "}\n"
+        r"\SetWatermarkAngle{45}" "\n"
+        r"\SetWatermarkColor[gray]{0.85}" "\n"
+    )
+
+
 def _escape_title(title: str) -> str:
     """Escape special LaTeX characters in a title string."""
     for ch in ["&", "%", "#", "_", "~", "^"]:
@@ -105,7 +141,10 @@ def _escape_title(title: str) -> str:
 
 
 def build_full_latex_document(
-    body: str, title: str, author: str = "Research AI Agent"
+    body: str,
+    title: str,
+    author: str = "Research AI Agent",
+    watermark: Optional[str] = None,
 ) -> str:
     """Wrap a LaTeX body in a complete, compilable document."""
     # Clean the body before wrapping
@@ -137,7 +176,8 @@ def build_full_latex_document(
         r"\usepackage[breaklinks=true,hidelinks]{hyperref}" "\n"
         r"\usepackage{url}" "\n"
         r"\usepackage{csquotes}" "\n"
-        "\n"
+        + (_watermark_preamble(watermark) if watermark else "")
+        + "\n"
         r"\pagestyle{fancy}" "\n"
         r"\fancyhf{}" "\n"
         r"\setlength{\headheight}{15pt}" "\n"
@@ -198,13 +238,17 @@ def build_full_latex_document(
 # PDF compilation — pdflatex only (no fallback)
 # ---------------------------------------------------------------------------
 
-def compile_latex_to_pdf(latex_body: str, title: str) -> Optional[bytes]:
+def compile_latex_to_pdf(
+    latex_body: str, title: str, watermark: Optional[str] = None
+) -> Optional[bytes]:
     """Build a full LaTeX document and compile it to PDF bytes via pdflatex.
 
+    A watermark, if given, is printed diagonally across every page.
+
     Returns the PDF bytes on success, or None if compilation fails.
     pdflatex is required — there is no HTML fallback.
     """
-    full_document = build_full_latex_document(latex_body, title)
+    full_document = build_full_latex_document(latex_body, title, watermark=watermark)
 
     pdflatex = shutil.which("pdflatex")
     if not pdflatex:
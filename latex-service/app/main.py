@@ -22,7 +22,7 @@ async def health():
 
 @app.post("/api/compile-pdf")
 async def api_compile_pdf(req: CompilePdfRequest):
-    pdf_bytes = compile_latex_to_pdf(req.latex_body, req.title)
+    pdf_bytes = compile_latex_to_pdf(req.latex_body, req.title, req.watermark)
     if pdf_bytes is None:
         return JSONResponse(
             status_code=500,
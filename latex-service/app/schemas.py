@@ -1,11 +1,15 @@
 """Pydantic request/response models for the LaTeX service."""
 
-from pydantic import BaseModel
+from typing import Optional
+
+from pydantic import BaseModel, Field
 
 
 class CompilePdfRequest(BaseModel):
     latex_body: str
     title: str
+    # Printed diagonally across every page when set, e.g. "DRAFT".
+    watermark: Optional[str] = Field(default=None, max_length=40)
 
 
 class CompileTexRequest(BaseModel):